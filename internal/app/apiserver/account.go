@@ -0,0 +1,305 @@
+package apiserver
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errNoReauthentication = errors.New("account has neither a password nor a second factor to confirm the deletion with")
+)
+
+// identityInfo is a login method of a user as it is shown to its owner.
+// Provider is only set for the "external" type.
+type identityInfo struct {
+	Type       string     `json:"type"`
+	Provider   string     `json:"provider,omitempty"`
+	ID         string     `json:"id"`
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// accountExport is all data kept about a user, as it is given to the user.
+type accountExport struct {
+	ExportedAt time.Time           `json:"exported_at"`
+	Profile    *model.User         `json:"profile"`
+	Roles      []string            `json:"roles"`
+	Identities []*identityInfo     `json:"identities"`
+	Sessions   []*sessionInfo      `json:"sessions"`
+	APIKeys    []*apiKeyInfo       `json:"api_keys"`
+	Grants     []*grantInfo        `json:"grants"`
+	Activity   []*model.AuditEvent `json:"activity"`
+}
+
+// handleAccountExport responds with a JSON file of all data kept about the current user.
+func (s *server) handleAccountExport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		export, err := s.exportAccount(r, u)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		w.Header().Set("Content-Disposition", `attachment; filename="account-export.json"`)
+		s.respond(w, r, http.StatusOK, export)
+	}
+}
+
+// handleAccountDelete deletes the account of the current user after checking the password
+// or, for the account without a password, the second factor. An account with neither cannot be deleted this way.
+// The account is deleted once AccountDeletionGracePeriod ends, logging in before then cancels the deletion.
+// All sessions and refresh tokens of the user are ended once the deletion is scheduled.
+func (s *server) handleAccountDelete() http.HandlerFunc {
+	type request struct {
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	type response struct {
+		DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(ctxKeyAPIKey) != nil {
+			s.error(w, r, http.StatusForbidden, errAPIKeyNotAllowed)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := *r.Context().Value(ctxKeyUser).(*model.User)
+		if !u.EncryptedPassword.Valid && !u.HasTwoFactor() {
+			s.error(w, r, http.StatusForbidden, errNoReauthentication)
+			return
+		}
+
+		if !s.reauthenticate(w, r, &u, req.Password, req.Code, req.RecoveryCode) {
+			return
+		}
+
+		if s.config.AccountDeletionGracePeriod <= 0 {
+			if err := s.deleteAccount(u.ID); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			if err := s.deleteSession(w, r); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.respond(w, r, http.StatusNoContent, nil)
+			return
+		}
+
+		u.DeletionScheduledAt = sql.NullTime{Time: time.Now().Add(s.config.AccountDeletionGracePeriod), Valid: true}
+		if err := s.store.User().Update(&u); err != nil {
+			s.userUpdateError(w, r, err)
+			return
+		}
+
+		if err := s.endAllSessions(u.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.deleteSession(w, r); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if u.Email.Valid {
+			s.sendMailOrLog(r, &mailer.Message{
+				To:      u.Email.String,
+				Subject: "Account deletion",
+				Body: fmt.Sprintf(
+					"Your account will be deleted on %s.\n\nTo keep it, log in before then.",
+					u.DeletionScheduledAt.Time.UTC().Format(time.RFC1123),
+				),
+			})
+		}
+
+		s.respond(w, r, http.StatusAccepted, &response{DeletionScheduledAt: u.DeletionScheduledAt.Time})
+	}
+}
+
+// exportAccount collects all data kept about the user.
+func (s *server) exportAccount(r *http.Request, u *model.User) (*accountExport, error) {
+	roles, err := s.store.Role().FindByUser(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.currentSessionID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := s.store.Session().FindByUser(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.store.APIKey().FindByUser(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	externals, err := s.store.ExternalIdentity().FindByUser(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := s.grantInfos(u.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	activity, _, err := s.store.AuditEvent().List(&store.AuditEventFilter{UserID: u.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	export := &accountExport{
+		ExportedAt: time.Now(),
+		Profile:    u,
+		Roles:      model.RoleNames(roles),
+		Identities: []*identityInfo{},
+		Sessions:   []*sessionInfo{},
+		APIKeys:    []*apiKeyInfo{},
+		Grants:     grants,
+		Activity:   activity,
+	}
+
+	if u.Email.Valid {
+		export.Identities = append(export.Identities, &identityInfo{
+			Type:       "email",
+			ID:         u.Email.String,
+			VerifiedAt: nullTime(u.EmailVerifiedAt),
+		})
+	}
+
+	if u.IDTelegram.Valid {
+		export.Identities = append(export.Identities, &identityInfo{
+			Type: "telegram",
+			ID:   strconv.FormatInt(u.IDTelegram.Int64, 10),
+		})
+	}
+
+	for _, i := range externals {
+		export.Identities = append(export.Identities, &identityInfo{
+			Type:     "external",
+			Provider: i.Provider,
+			ID:       i.Subject,
+		})
+	}
+
+	for _, rec := range records {
+		export.Sessions = append(export.Sessions, newSessionInfo(rec, current))
+	}
+
+	for _, k := range keys {
+		export.APIKeys = append(export.APIKeys, newAPIKeyInfo(k))
+	}
+
+	return export, nil
+}
+
+// deleteAccount removes or anonymizes the user as AccountDeletion says.
+func (s *server) deleteAccount(userID int) error {
+	if s.config.AccountDeletion == "anonymize" {
+		return s.store.User().Anonymize(userID)
+	}
+
+	return s.store.User().Delete(userID)
+}
+
+// cancelAccountDeletion cancels the pending deletion of the user who has just logged in.
+// The user can still cancel it on the next login, so a failure is only logged.
+func (s *server) cancelAccountDeletion(r *http.Request, u *model.User) {
+	if !u.DeletionScheduledAt.Valid {
+		return
+	}
+
+	updated := *u
+	updated.DeletionScheduledAt = sql.NullTime{}
+	if err := s.store.User().Update(&updated); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"request_id": r.Context().Value(ctxKeyRequestID),
+			"user_id":    u.ID,
+		}).Errorf("failed to cancel account deletion: %v", err)
+		return
+	}
+
+	u.DeletionScheduledAt = sql.NullTime{}
+}
+
+// sweepAccountDeletions deletes the accounts whose grace period ended before now.
+func (s *server) sweepAccountDeletions(now time.Time) error {
+	users, _, err := s.store.User().List(&store.UserFilter{DeletionScheduledBefore: now})
+	if err != nil {
+		return err
+	}
+
+	for _, u := range users {
+		if err := s.deleteAccount(u.ID); err != nil && err != store.ErrRecordNotFound {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runAccountDeletionSweeper calls sweepAccountDeletions every interval and logs its failures.
+func (s *server) runAccountDeletionSweeper(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		if err := s.sweepAccountDeletions(now); err != nil {
+			s.logger.Errorf("failed to delete accounts: %v", err)
+		}
+	}
+}
+
+// reauthenticate checks the password of the user or, if the user has no password, the second factor if it is enabled,
+// before a change of the account. It responds with 403 and returns false if the check fails.
+func (s *server) reauthenticate(w http.ResponseWriter, r *http.Request, u *model.User, password string, code string, recoveryCode string) bool {
+	if u.EncryptedPassword.Valid {
+		if !u.ComparePassword(password) {
+			s.error(w, r, http.StatusForbidden, errIncorrectPassword)
+			return false
+		}
+
+		return true
+	}
+
+	if u.HasTwoFactor() {
+		if err := s.verifySecondFactor(u, code, recoveryCode); err != nil {
+			s.secondFactorError(w, r, err, http.StatusForbidden)
+			return false
+		}
+	}
+
+	return true
+}
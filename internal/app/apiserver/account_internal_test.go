@@ -0,0 +1,122 @@
+package apiserver
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleAccountExport(t *testing.T) {
+	st := teststore.New()
+	u := model.TestUser(t)
+	st.User().Create(u)
+	st.APIKey().Create(model.TestAPIKey(t, u.ID))
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testTwoFactorConfig(t))
+
+	rec := testRequest(t, s, http.MethodGet, "/private/export", nil, testLogin(t, s, u))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "attachment")
+
+	res := struct {
+		Profile struct {
+			ID int `json:"id"`
+		} `json:"profile"`
+		Roles      []string            `json:"roles"`
+		Identities []*identityInfo     `json:"identities"`
+		APIKeys    []*apiKeyInfo       `json:"api_keys"`
+		Activity   []*model.AuditEvent `json:"activity"`
+	}{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	assert.Equal(t, u.ID, res.Profile.ID)
+	assert.Equal(t, []string{model.RoleUser}, res.Roles)
+	assert.Len(t, res.Identities, 1)
+	assert.Equal(t, "email", res.Identities[0].Type)
+	assert.Equal(t, u.Email.String, res.Identities[0].ID)
+	assert.Len(t, res.APIKeys, 1)
+	assert.Empty(t, res.APIKeys[0].Key)
+	assert.Len(t, res.Activity, 1)
+	assert.Equal(t, model.AuditLogin, res.Activity[0].Type)
+}
+
+func TestServer_handleAccountDelete(t *testing.T) {
+	st := teststore.New()
+	u := model.TestUser(t)
+	st.User().Create(u)
+	mail := &bytes.Buffer{}
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, mail), testTwoFactorConfig(t))
+	cookies := testLogin(t, s, u)
+
+	rec := testRequest(t, s, http.MethodDelete, "/private/account", map[string]string{"password": "wrong"}, cookies)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = testRequest(t, s, http.MethodDelete, "/private/account", map[string]string{"password": u.Password}, cookies)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Contains(t, mail.String(), "will be deleted")
+
+	found, err := st.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.True(t, found.DeletionScheduledAt.Valid)
+	assert.Equal(t, http.StatusUnauthorized, testRequest(t, s, http.MethodGet, "/private/whoami", nil, cookies).Code)
+
+	testLogin(t, s, u)
+	found, err = st.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.False(t, found.DeletionScheduledAt.Valid)
+}
+
+func TestServer_handleAccountDeleteWithoutGracePeriod(t *testing.T) {
+	st := teststore.New()
+	u := model.TestUser(t)
+	st.User().Create(u)
+	tu := model.TestUserWithTelegram(t)
+	st.User().Create(tu)
+	config := testTwoFactorConfig(t)
+	config.AccountDeletion = "delete"
+	config.AccountDeletionGracePeriod = 0
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), config)
+
+	rec := testRequest(t, s, http.MethodDelete, "/private/account", map[string]string{"password": u.Password}, testLogin(t, s, u))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	_, err := st.User().Find(u.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	_, err = st.User().Find(tu.ID)
+	assert.NoError(t, err)
+}
+
+func TestServer_sweepAccountDeletions(t *testing.T) {
+	st := teststore.New()
+	due := model.TestUser(t)
+	st.User().Create(due)
+	pending := model.TestUserWithTelegram(t)
+	st.User().Create(pending)
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testTwoFactorConfig(t))
+
+	now := time.Now()
+	due.DeletionScheduledAt = sql.NullTime{Time: now.Add(-time.Minute), Valid: true}
+	st.User().Update(due)
+	pending.DeletionScheduledAt = sql.NullTime{Time: now.Add(time.Hour), Valid: true}
+	st.User().Update(pending)
+
+	assert.NoError(t, s.sweepAccountDeletions(now))
+
+	u, err := st.User().Find(due.ID)
+	assert.NoError(t, err)
+	assert.False(t, u.Email.Valid)
+	assert.True(t, u.IsDisabled())
+
+	u, err = st.User().Find(pending.ID)
+	assert.NoError(t, err)
+	assert.True(t, u.IDTelegram.Valid)
+	assert.True(t, u.DeletionScheduledAt.Valid)
+}
@@ -0,0 +1,361 @@
+package apiserver
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+const (
+	adminDefaultPerPage = 20
+	adminMaxPerPage     = 100
+)
+
+var (
+	errAccountDisabled   = errors.New("account is disabled")
+	errAdminSelf         = errors.New("administrators cannot disable or delete their own account")
+	errAdminSelfRoles    = errors.New("administrators cannot change their own roles")
+	errInvalidPage       = errors.New("page and per_page must be positive, per_page must not exceed 100")
+	errInvalidSort       = errors.New("sort must be id, email or created_at, optionally prefixed with -")
+	errInvalidStatus     = errors.New("status must be active or disabled")
+	errInvalidTelegram   = errors.New("telegram must be true or false")
+	errRoleNotAssignable = errors.New("the user role belongs to every user and cannot be assigned or removed")
+)
+
+// handleAdminUsersList responds with a page of the users selected by the query parameters:
+// page, per_page, sort ("id", "email" or "created_at", "-" before it for the descending order),
+// email (a part of the email), telegram ("true" or "false"), created_after, created_before (RFC 3339)
+// and status ("active" or "disabled").
+func (s *server) handleAdminUsersList() http.HandlerFunc {
+	type response struct {
+		Users   []*model.User `json:"users"`
+		Total   int           `json:"total"`
+		Page    int           `json:"page"`
+		PerPage int           `json:"per_page"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		page, perPage, err := pageFromQuery(q)
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		f, err := userFilterFromQuery(q)
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		f.Limit = perPage
+		f.Offset = (page - 1) * perPage
+		users, total, err := s.store.User().List(f)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{
+			Users:   users,
+			Total:   total,
+			Page:    page,
+			PerPage: perPage,
+		})
+	}
+}
+
+// handleAdminUsersGet responds with the user by the id.
+func (s *server) handleAdminUsersGet() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, ok := s.adminTargetUser(w, r)
+		if !ok {
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, u)
+	}
+}
+
+// handleAdminUsersSetDisabled disables or enables the user by the id.
+// A disabled user cannot log in and all sessions and refresh tokens of the user are ended.
+func (s *server) handleAdminUsersSetDisabled(disabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, ok := s.adminTargetUser(w, r)
+		if !ok {
+			return
+		}
+
+		if disabled && u.ID == r.Context().Value(ctxKeyUser).(*model.User).ID {
+			s.error(w, r, http.StatusBadRequest, errAdminSelf)
+			return
+		}
+
+		if disabled == u.IsDisabled() {
+			s.respond(w, r, http.StatusOK, u)
+			return
+		}
+
+		updated := *u
+		updated.DisabledAt = sql.NullTime{}
+		if disabled {
+			updated.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+
+		if err := s.store.User().Update(&updated); err != nil {
+			s.userUpdateError(w, r, err)
+			return
+		}
+
+		event := model.AuditAdminUserEnable
+		if disabled {
+			if err := s.endAllSessions(u.ID); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			event = model.AuditAdminUserDisable
+		}
+
+		s.audit(r, event, u.ID, r.Context().Value(ctxKeyUser).(*model.User).ID)
+		s.respond(w, r, http.StatusOK, &updated)
+	}
+}
+
+// handleAdminUsersSetRole assigns the role in the route to the user by the id or takes it from the user
+// and responds with the names of the roles the user has after that.
+// The permissions are read on every request, so the change applies to the current sessions of the user too.
+func (s *server) handleAdminUsersSetRole(assigned bool) http.HandlerFunc {
+	type response struct {
+		Roles []string `json:"roles"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, ok := s.adminTargetUser(w, r)
+		if !ok {
+			return
+		}
+
+		actorID := r.Context().Value(ctxKeyUser).(*model.User).ID
+		if u.ID == actorID {
+			s.error(w, r, http.StatusBadRequest, errAdminSelfRoles)
+			return
+		}
+
+		role := mux.Vars(r)["role"]
+		if role == model.RoleUser {
+			s.error(w, r, http.StatusBadRequest, errRoleNotAssignable)
+			return
+		}
+
+		var err error
+		event := model.AuditAdminRoleUnassign
+		if assigned {
+			err = s.store.Role().Assign(u.ID, role)
+			event = model.AuditAdminRoleAssign
+		} else {
+			err = s.store.Role().Unassign(u.ID, role)
+		}
+
+		if err != nil {
+			if err == store.ErrRecordNotFound {
+				s.error(w, r, http.StatusNotFound, err)
+				return
+			}
+
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		roles, err := s.store.Role().FindByUser(u.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, event, u.ID, actorID)
+		s.respond(w, r, http.StatusOK, &response{Roles: model.RoleNames(roles)})
+	}
+}
+
+// handleAdminUsersPasswordReset makes the password of the user by the id stop working,
+// ends all sessions and refresh tokens of the user and mails the user a password reset link.
+func (s *server) handleAdminUsersPasswordReset() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, ok := s.adminTargetUser(w, r)
+		if !ok {
+			return
+		}
+
+		if !u.Email.Valid {
+			s.error(w, r, http.StatusBadRequest, errNoEmail)
+			return
+		}
+
+		if u.EncryptedPassword.Valid {
+			locked := &model.User{Password: generateToken(), PasswordHasher: s.passwordHasher}
+			if err := locked.BeforeCreate(); err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			err := s.store.User().ReplaceEncryptedPassword(u.ID, u.EncryptedPassword.String, locked.EncryptedPassword.String)
+			if err != nil && err != store.ErrRecordNotFound {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		if err := s.endAllSessions(u.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.sendPasswordReset(u.Email.String); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, model.AuditAdminPasswordReset, u.ID, r.Context().Value(ctxKeyUser).(*model.User).ID)
+		s.respond(w, r, http.StatusAccepted, nil)
+	}
+}
+
+// handleAdminUsersDelete deletes the user by the id with all records that belong to the user.
+func (s *server) handleAdminUsersDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u, ok := s.adminTargetUser(w, r)
+		if !ok {
+			return
+		}
+
+		if u.ID == r.Context().Value(ctxKeyUser).(*model.User).ID {
+			s.error(w, r, http.StatusBadRequest, errAdminSelf)
+			return
+		}
+
+		if err := s.store.User().Delete(u.ID); err != nil && err != store.ErrRecordNotFound {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, model.AuditAdminUserDelete, u.ID, r.Context().Value(ctxKeyUser).(*model.User).ID)
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// adminTargetUser finds the user by the id in the route.
+// It responds with an error and returns false if there is no such user.
+func (s *server) adminTargetUser(w http.ResponseWriter, r *http.Request) (*model.User, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		s.error(w, r, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	u, err := s.store.User().Find(id)
+	if err != nil {
+		if err == store.ErrRecordNotFound {
+			s.error(w, r, http.StatusNotFound, err)
+			return nil, false
+		}
+
+		s.error(w, r, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	return u, true
+}
+
+// endAllSessions ends all sessions and revokes all refresh tokens of the user.
+// The sessions that are kept only in cookies are ended by the time they had to start after.
+func (s *server) endAllSessions(userID int) error {
+	if err := s.store.User().SetSessionsValidAfter(userID, time.Now().Truncate(time.Microsecond)); err != nil {
+		return err
+	}
+
+	if err := s.store.Session().DeleteByUser(userID, 0); err != nil {
+		return err
+	}
+
+	return s.store.RefreshToken().RevokeByUser(userID)
+}
+
+// userFilterFromQuery reads the filter and the order of the users from the query parameters.
+func userFilterFromQuery(q url.Values) (*store.UserFilter, error) {
+	f := &store.UserFilter{
+		Email:  q.Get("email"),
+		Status: q.Get("status"),
+		Sort:   strings.TrimPrefix(q.Get("sort"), "-"),
+		Desc:   strings.HasPrefix(q.Get("sort"), "-"),
+	}
+
+	switch f.Sort {
+	case "":
+		f.Sort = store.UserSortID
+	case store.UserSortID, store.UserSortEmail, store.UserSortCreatedAt:
+	default:
+		return nil, errInvalidSort
+	}
+
+	if f.Status != "" && f.Status != store.UserStatusActive && f.Status != store.UserStatusDisabled {
+		return nil, errInvalidStatus
+	}
+
+	if v := q.Get("telegram"); v != "" {
+		telegram, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errInvalidTelegram
+		}
+
+		f.Telegram = &telegram
+	}
+
+	var err error
+	if v := q.Get("created_after"); v != "" {
+		if f.CreatedAfter, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("created_before"); v != "" {
+		if f.CreatedBefore, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+// pageFromQuery reads the page and the number of items per page from the query parameters.
+func pageFromQuery(q url.Values) (int, int, error) {
+	page, err := queryInt(q, "page", 1)
+	if err != nil || page < 1 {
+		return 0, 0, errInvalidPage
+	}
+
+	perPage, err := queryInt(q, "per_page", adminDefaultPerPage)
+	if err != nil || perPage < 1 || perPage > adminMaxPerPage {
+		return 0, 0, errInvalidPage
+	}
+
+	return page, perPage, nil
+}
+
+// queryInt reads the integer query parameter, or returns def if it is not given.
+func queryInt(q url.Values, name string, def int) (int, error) {
+	v := q.Get(name)
+	if v == "" {
+		return def, nil
+	}
+
+	return strconv.Atoi(v)
+}
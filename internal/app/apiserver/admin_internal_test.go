@@ -0,0 +1,313 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleAdminUsersList(t *testing.T) {
+	store := teststore.New()
+	admin := model.TestUser(t)
+	admin.Email.String = "admin@example.org"
+	store.User().Create(admin)
+	store.Role().Assign(admin.ID, model.RoleAdmin)
+	u := model.TestUser(t)
+	store.User().Create(u)
+	tu := model.TestUserWithTelegram(t)
+	store.User().Create(tu)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testTwoFactorConfig(t))
+	cookies := testLogin(t, s, admin)
+
+	testCases := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedIDs   []int
+		expectedTotal int
+	}{
+		{
+			name:          "all",
+			query:         "",
+			expectedCode:  http.StatusOK,
+			expectedIDs:   []int{admin.ID, u.ID, tu.ID},
+			expectedTotal: 3,
+		},
+		{
+			name:          "page",
+			query:         "?per_page=2&page=2",
+			expectedCode:  http.StatusOK,
+			expectedIDs:   []int{tu.ID},
+			expectedTotal: 3,
+		},
+		{
+			name:          "sort by email descending",
+			query:         "?sort=-email&per_page=2",
+			expectedCode:  http.StatusOK,
+			expectedIDs:   []int{u.ID, admin.ID},
+			expectedTotal: 3,
+		},
+		{
+			name:          "email",
+			query:         "?email=USER",
+			expectedCode:  http.StatusOK,
+			expectedIDs:   []int{u.ID},
+			expectedTotal: 1,
+		},
+		{
+			name:          "without telegram",
+			query:         "?telegram=false&status=active",
+			expectedCode:  http.StatusOK,
+			expectedIDs:   []int{admin.ID, u.ID},
+			expectedTotal: 2,
+		},
+		{
+			name:          "created before",
+			query:         "?created_before=2000-01-01T00:00:00Z",
+			expectedCode:  http.StatusOK,
+			expectedIDs:   []int{},
+			expectedTotal: 0,
+		},
+		{
+			name:         "invalid sort",
+			query:        "?sort=password",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid per page",
+			query:        "?per_page=1000",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid date",
+			query:        "?created_after=yesterday",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, http.MethodGet, "/admin/users"+tc.query, nil, cookies)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			res := struct {
+				Users []struct {
+					ID int `json:"id"`
+				} `json:"users"`
+				Total int `json:"total"`
+			}{}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+			ids := []int{}
+			for _, u := range res.Users {
+				ids = append(ids, u.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+			assert.Equal(t, tc.expectedTotal, res.Total)
+		})
+	}
+}
+
+func TestServer_AdminPermissions(t *testing.T) {
+	store := teststore.New()
+	moderator := model.TestUser(t)
+	moderator.Email.String = "moderator@example.org"
+	store.User().Create(moderator)
+	store.Role().Assign(moderator.ID, model.RoleModerator)
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testTwoFactorConfig(t))
+
+	assert.Equal(t, http.StatusForbidden, testRequest(t, s, http.MethodGet, "/admin/users", nil, testLogin(t, s, u)).Code)
+
+	cookies := testLogin(t, s, moderator)
+	path := fmt.Sprintf("/admin/users/%d", u.ID)
+	assert.Equal(t, http.StatusOK, testRequest(t, s, http.MethodGet, path, nil, cookies).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(t, s, http.MethodGet, "/admin/users/100", nil, cookies).Code)
+	assert.Equal(t, http.StatusForbidden, testRequest(t, s, http.MethodPost, path+"/disable", nil, cookies).Code)
+	assert.Equal(t, http.StatusForbidden, testRequest(t, s, http.MethodDelete, path, nil, cookies).Code)
+	assert.Equal(t, http.StatusForbidden, testRequest(t, s, http.MethodPut, path+"/roles/admin", nil, cookies).Code)
+}
+
+func TestServer_handleAdminUsersSetDisabled(t *testing.T) {
+	store := teststore.New()
+	admin := model.TestUser(t)
+	admin.Email.String = "admin@example.org"
+	store.User().Create(admin)
+	store.Role().Assign(admin.ID, model.RoleAdmin)
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testTwoFactorConfig(t))
+	cookies := testLogin(t, s, admin)
+	userCookies := testLogin(t, s, u)
+	path := fmt.Sprintf("/admin/users/%d", u.ID)
+
+	assert.Equal(t, http.StatusBadRequest, testRequest(t, s, http.MethodPost, fmt.Sprintf("/admin/users/%d/disable", admin.ID), nil, cookies).Code)
+
+	assert.Equal(t, http.StatusOK, testRequest(t, s, http.MethodPost, path+"/disable", nil, cookies).Code)
+	found, _ := store.User().Find(u.ID)
+	assert.True(t, found.IsDisabled())
+	assert.Equal(t, http.StatusUnauthorized, testRequest(t, s, http.MethodGet, "/private/whoami", nil, userCookies).Code)
+	rec := testRequest(t, s, http.MethodPost, "/sessions", map[string]string{
+		"email":    u.Email.String,
+		"password": u.Password,
+	}, nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	assert.Equal(t, http.StatusOK, testRequest(t, s, http.MethodPost, path+"/enable", nil, cookies).Code)
+	found, _ = store.User().Find(u.ID)
+	assert.False(t, found.IsDisabled())
+	testLogin(t, s, u)
+}
+
+func TestServer_handleAdminUsersPasswordReset(t *testing.T) {
+	store := teststore.New()
+	admin := model.TestUser(t)
+	admin.Email.String = "admin@example.org"
+	store.User().Create(admin)
+	store.Role().Assign(admin.ID, model.RoleAdmin)
+	u := model.TestUser(t)
+	store.User().Create(u)
+	tu := model.TestUserWithTelegram(t)
+	store.User().Create(tu)
+	mail := &bytes.Buffer{}
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, mail), testTwoFactorConfig(t))
+	cookies := testLogin(t, s, admin)
+
+	rec := testRequest(t, s, http.MethodPost, fmt.Sprintf("/admin/users/%d/password-reset", tu.ID), nil, cookies)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = testRequest(t, s, http.MethodPost, fmt.Sprintf("/admin/users/%d/password-reset", u.ID), nil, cookies)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Regexp(t, resetTokenRe, mail.String())
+
+	found, _ := store.User().Find(u.ID)
+	assert.False(t, found.ComparePassword(u.Password))
+}
+
+func TestServer_handleAdminUsersDelete(t *testing.T) {
+	store := teststore.New()
+	admin := model.TestUser(t)
+	admin.Email.String = "admin@example.org"
+	store.User().Create(admin)
+	store.Role().Assign(admin.ID, model.RoleAdmin)
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testTwoFactorConfig(t))
+	cookies := testLogin(t, s, admin)
+	path := fmt.Sprintf("/admin/users/%d", u.ID)
+
+	assert.Equal(t, http.StatusBadRequest, testRequest(t, s, http.MethodDelete, fmt.Sprintf("/admin/users/%d", admin.ID), nil, cookies).Code)
+	assert.Equal(t, http.StatusNoContent, testRequest(t, s, http.MethodDelete, path, nil, cookies).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(t, s, http.MethodDelete, path, nil, cookies).Code)
+}
+
+func TestServer_handleAdminUsersSetRole(t *testing.T) {
+	store := teststore.New()
+	admin := model.TestUser(t)
+	admin.Email.String = "admin@example.org"
+	store.User().Create(admin)
+	store.Role().Assign(admin.ID, model.RoleAdmin)
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testTwoFactorConfig(t))
+	cookies := testLogin(t, s, admin)
+	userCookies := testLogin(t, s, u)
+	path := fmt.Sprintf("/admin/users/%d/roles/", u.ID)
+
+	testCases := []struct {
+		name          string
+		method        string
+		path          string
+		expectedCode  int
+		expectedRoles []string
+	}{
+		{
+			name:         "own roles",
+			method:       http.MethodDelete,
+			path:         fmt.Sprintf("/admin/users/%d/roles/admin", admin.ID),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "user role",
+			method:       http.MethodPut,
+			path:         path + model.RoleUser,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown role",
+			method:       http.MethodPut,
+			path:         path + "owner",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "unknown user",
+			method:       http.MethodPut,
+			path:         "/admin/users/100/roles/moderator",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:          "assign",
+			method:        http.MethodPut,
+			path:          path + model.RoleModerator,
+			expectedCode:  http.StatusOK,
+			expectedRoles: []string{model.RoleUser, model.RoleModerator},
+		},
+		{
+			name:          "assign again",
+			method:        http.MethodPut,
+			path:          path + model.RoleModerator,
+			expectedCode:  http.StatusOK,
+			expectedRoles: []string{model.RoleUser, model.RoleModerator},
+		},
+		{
+			name:          "unassign",
+			method:        http.MethodDelete,
+			path:          path + model.RoleModerator,
+			expectedCode:  http.StatusOK,
+			expectedRoles: []string{model.RoleUser},
+		},
+		{
+			name:         "unassign not assigned",
+			method:       http.MethodDelete,
+			path:         path + model.RoleModerator,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, tc.method, tc.path, nil, cookies)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode != http.StatusOK {
+				return
+			}
+
+			res := struct {
+				Roles []string `json:"roles"`
+			}{}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+			assert.Equal(t, tc.expectedRoles, res.Roles)
+		})
+	}
+
+	assert.Equal(t, http.StatusForbidden, testRequest(t, s, http.MethodGet, "/admin/users", nil, userCookies).Code)
+	assert.Equal(t, http.StatusOK, testRequest(t, s, http.MethodPut, path+model.RoleModerator, nil, cookies).Code)
+	assert.Equal(t, http.StatusOK, testRequest(t, s, http.MethodGet, "/admin/users", nil, userCookies).Code)
+
+	rec := testRequest(t, s, http.MethodGet, fmt.Sprintf("/admin/audit-events?actor_id=%d", admin.ID), nil, cookies)
+	res := &auditEventsPage{}
+	json.NewDecoder(rec.Body).Decode(res)
+	assert.Equal(t, model.AuditAdminRoleAssign, res.Events[0].Type)
+	assert.Equal(t, model.AuditAdminRoleUnassign, res.Events[1].Type)
+}
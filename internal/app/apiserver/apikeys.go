@@ -0,0 +1,222 @@
+package apiserver
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+const (
+	apiKeyPrefix       = "brk_"
+	apiKeyPrefixLength = len(apiKeyPrefix) + 8
+	apiKeyTouchPeriod  = time.Minute
+)
+
+var (
+	errAPIKeyNotAllowed = errors.New("API keys cannot be managed with an API key")
+	errExpiresInPast    = errors.New("expires_at must be in the future")
+)
+
+// apiKeyInfo is an API key as it is shown to its owner.
+// Key is filled only once, in the response to the creation of the key.
+type apiKeyInfo struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	Key        string     `json:"key,omitempty"`
+}
+
+// newAPIKeyInfo returns the API key as it is shown to its owner.
+func newAPIKeyInfo(k *model.APIKey) *apiKeyInfo {
+	scopes := k.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	return &apiKeyInfo{
+		ID:         k.ID,
+		Name:       k.Name,
+		Prefix:     k.Prefix,
+		Scopes:     scopes,
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  nullTime(k.ExpiresAt),
+		LastUsedAt: nullTime(k.LastUsedAt),
+		LastUsedIP: k.LastUsedIP,
+	}
+}
+
+// handleAPIKeysCreate creates a new API key for the current user.
+// The key itself is returned only in this response.
+func (s *server) handleAPIKeysCreate() http.HandlerFunc {
+	type request struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(ctxKeyAPIKey) != nil {
+			s.error(w, r, http.StatusForbidden, errAPIKeyNotAllowed)
+			return
+		}
+
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		now := time.Now()
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+			s.error(w, r, http.StatusBadRequest, errExpiresInPast)
+			return
+		}
+
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		key := apiKeyPrefix + generateToken()
+		k := &model.APIKey{
+			UserID:    u.ID,
+			Name:      req.Name,
+			Prefix:    key[:apiKeyPrefixLength],
+			KeyHash:   hashToken(key),
+			Scopes:    req.Scopes,
+			CreatedAt: now,
+		}
+		if req.ExpiresAt != nil {
+			k.ExpiresAt = sql.NullTime{Time: *req.ExpiresAt, Valid: true}
+		}
+
+		if err := s.store.APIKey().Create(k); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		info := newAPIKeyInfo(k)
+		info.Key = key
+		s.respond(w, r, http.StatusCreated, info)
+	}
+}
+
+// handleAPIKeysList responds with the API keys of the current user.
+func (s *server) handleAPIKeysList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		keys, err := s.store.APIKey().FindByUser(u.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		list := []*apiKeyInfo{}
+		for _, k := range keys {
+			list = append(list, newAPIKeyInfo(k))
+		}
+
+		s.respond(w, r, http.StatusOK, list)
+	}
+}
+
+// handleAPIKeysDelete revokes an API key of the current user.
+func (s *server) handleAPIKeysDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(ctxKeyAPIKey) != nil {
+			s.error(w, r, http.StatusForbidden, errAPIKeyNotAllowed)
+			return
+		}
+
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		k, err := s.store.APIKey().Find(id)
+		if err != nil || k.UserID != u.ID {
+			s.error(w, r, http.StatusNotFound, store.ErrRecordNotFound)
+			return
+		}
+
+		if err := s.store.APIKey().Delete(id); err != nil && err != store.ErrRecordNotFound {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// userFromAPIKey verifies the API key and finds the user it belongs to.
+// The time and the address of the use are recorded on the key, at most once per apiKeyTouchPeriod from the same address.
+func (s *server) userFromAPIKey(r *http.Request, raw string) (*model.User, *model.APIKey, error) {
+	if len(raw) <= apiKeyPrefixLength {
+		return nil, nil, errNotAuthenticated
+	}
+
+	k, err := s.store.APIKey().FindByPrefix(raw[:apiKeyPrefixLength])
+	if err != nil {
+		if err == store.ErrRecordNotFound {
+			return nil, nil, errNotAuthenticated
+		}
+
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(hashToken(raw)), []byte(k.KeyHash)) != 1 || k.IsExpired(now) {
+		return nil, nil, errNotAuthenticated
+	}
+
+	u, err := s.store.User().Find(k.UserID)
+	if err != nil {
+		return nil, nil, errNotAuthenticated
+	}
+
+	ip := clientIP(r)
+	if !k.LastUsedAt.Valid || k.LastUsedIP != ip || now.Sub(k.LastUsedAt.Time) >= apiKeyTouchPeriod {
+		k.LastUsedAt = sql.NullTime{Time: now, Valid: true}
+		k.LastUsedIP = ip
+		if err := s.store.APIKey().UpdateLastUsed(k); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return u, k, nil
+}
+
+// apiKeyFromRequest returns the API key from the "X-API-Key" or the "Authorization: ApiKey" header.
+func apiKeyFromRequest(r *http.Request) (string, bool) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key, true
+	}
+
+	h := r.Header.Get("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "ApiKey ") {
+		return "", false
+	}
+
+	return strings.TrimSpace(h[7:]), true
+}
+
+// nullTime returns a pointer to the time or nil if it is not set.
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
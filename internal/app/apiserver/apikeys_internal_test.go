@@ -0,0 +1,242 @@
+package apiserver
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleAPIKeysCreate(t *testing.T) {
+	u := model.TestUser(t)
+	u.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	store := teststore.New()
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
+	cookies := testLogin(t, s, u)
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name: "valid",
+			payload: map[string]interface{}{
+				"name":   "ci",
+				"scopes": []string{"users:read"},
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name: "valid with expiry",
+			payload: map[string]interface{}{
+				"name":       "ci",
+				"expires_at": time.Now().Add(time.Hour),
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name: "expiry in the past",
+			payload: map[string]interface{}{
+				"name":       "ci",
+				"expires_at": time.Now().Add(-time.Hour),
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "empty name",
+			payload: map[string]interface{}{
+				"name": "",
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "invalid payload",
+			payload:      "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPost, "/private/api-keys", b)
+			for _, c := range cookies {
+				req.AddCookie(c)
+			}
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_AuthenticateUserWithAPIKey(t *testing.T) {
+	u := model.TestUser(t)
+	u.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	store := teststore.New()
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
+	cookies := testLogin(t, s, u)
+
+	createKey := func(payload map[string]interface{}) *apiKeyInfo {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(payload)
+		req, _ := http.NewRequest(http.MethodPost, "/private/api-keys", b)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		s.ServeHTTP(rec, req)
+		info := &apiKeyInfo{}
+		json.NewDecoder(rec.Body).Decode(info)
+		return info
+	}
+
+	valid := createKey(map[string]interface{}{"name": "ci"})
+	expired := apiKeyPrefix + generateToken()
+	store.APIKey().Create(&model.APIKey{
+		UserID:    u.ID,
+		Name:      "expired",
+		Prefix:    expired[:apiKeyPrefixLength],
+		KeyHash:   hashToken(expired),
+		CreatedAt: time.Now().Add(-time.Hour),
+		ExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+	})
+
+	testCases := []struct {
+		name         string
+		header       string
+		value        string
+		expectedCode int
+	}{
+		{
+			name:         "x-api-key header",
+			header:       "X-API-Key",
+			value:        valid.Key,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "authorization header",
+			header:       "Authorization",
+			value:        "ApiKey " + valid.Key,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "wrong secret",
+			header:       "X-API-Key",
+			value:        valid.Prefix + "wrong",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "expired",
+			header:       "X-API-Key",
+			value:        expired,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "too short",
+			header:       "X-API-Key",
+			value:        "brk_",
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/private/whoami", nil)
+			req.Header.Set(tc.header, tc.value)
+			req.RemoteAddr = "10.0.0.1:1234"
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	k, _ := store.APIKey().Find(valid.ID)
+	assert.True(t, k.LastUsedAt.Valid)
+	assert.Equal(t, "10.0.0.1", k.LastUsedIP)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/private/api-keys", bytes.NewBufferString(`{"name": "nested"}`))
+	req.Header.Set("X-API-Key", valid.Key)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestServer_handleAPIKeysDelete(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	u.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	store.User().Create(u)
+	other := model.TestUser(t)
+	other.Email.String = "other@example.org"
+	store.User().Create(other)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
+	cookies := testLogin(t, s, u)
+
+	own := model.TestAPIKey(t, u.ID)
+	store.APIKey().Create(own)
+	foreign := model.TestAPIKey(t, other.ID)
+	foreign.Prefix = "brk_foreign"
+	store.APIKey().Create(foreign)
+
+	testCases := []struct {
+		name         string
+		id           int
+		expectedCode int
+	}{
+		{
+			name:         "key of another user",
+			id:           foreign.ID,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "own key",
+			id:           own.ID,
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "already deleted",
+			id:           own.ID,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/private/api-keys/%d", tc.id), nil)
+			for _, c := range cookies {
+				req.AddCookie(c)
+			}
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/private/api-keys", nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	list := []*apiKeyInfo{}
+	json.NewDecoder(rec.Body).Decode(&list)
+	assert.Empty(t, list)
+}
@@ -2,14 +2,47 @@ package apiserver
 
 import (
 	"database/sql"
+	"fmt"
 	"net/http"
+	"os"
 
 	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/sessionstore"
 	"github.com/http-rest-API/internal/app/store/sqlstore"
+	"golang.org/x/crypto/bcrypt"
 )
 
-// Start creates a new server with new store and sessionStore.
+// Start loads the signing keys and creates a new server with new store and sessionStore.
 func Start(config *Config) error {
+	if err := loadSigningKeys(config.SigningKeys); err != nil {
+		return err
+	}
+
+	if err := checkExternalProviders(config.ExternalProviders); err != nil {
+		return err
+	}
+
+	if config.VerifyEmail != "block" && config.VerifyEmail != "restrict" {
+		return fmt.Errorf("unknown verify_email mode %q", config.VerifyEmail)
+	}
+
+	if config.AccountDeletion != "delete" && config.AccountDeletion != "anonymize" {
+		return fmt.Errorf("unknown account_deletion mode %q", config.AccountDeletion)
+	}
+
+	hasher, err := newPasswordHasher(config)
+	if err != nil {
+		return err
+	}
+
+	policy, err := newPasswordPolicy(config)
+	if err != nil {
+		return err
+	}
+
 	db, err := newDB(config.DatabaseURL)
 	if err != nil {
 		return err
@@ -17,8 +50,20 @@ func Start(config *Config) error {
 
 	defer db.Close()
 	store := sqlstore.New(db)
-	sessionStore := sessions.NewCookieStore([]byte(config.SessionKey))
-	s := newServer(store, sessionStore)
+	sessionStore, err := newSessionStore(config, store)
+	if err != nil {
+		return err
+	}
+
+	mailer, err := newMailer(config)
+	if err != nil {
+		return err
+	}
+
+	s := newServer(store, sessionStore, mailer, config)
+	s.passwordPolicy = policy
+	s.passwordHasher = hasher
+	go s.runAccountDeletionSweeper(config.AccountDeletionSweepInterval)
 
 	return http.ListenAndServe(config.BindAddr, s)
 }
@@ -36,3 +81,92 @@ func newDB(databaseURL string) (*sql.DB, error) {
 
 	return db, nil
 }
+
+// newSessionStore creates the session store selected by config.SessionBackend.
+func newSessionStore(config *Config, store store.Store) (sessions.Store, error) {
+	switch config.SessionBackend {
+	case "cookie":
+		return sessions.NewCookieStore([]byte(config.SessionKey)), nil
+	case "database":
+		return sessionstore.New(store.Session(), []byte(config.SessionKey)), nil
+	default:
+		return nil, fmt.Errorf("unknown session backend %q", config.SessionBackend)
+	}
+}
+
+// newMailer creates the mailer selected by config.MailBackend.
+func newMailer(config *Config) (mailer.Mailer, error) {
+	switch config.MailBackend {
+	case "smtp":
+		return mailer.NewSMTP(config.SMTPAddr, config.MailFrom, config.SMTPUsername, config.SMTPPassword), nil
+	case "file":
+		f, err := os.OpenFile(config.MailFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+
+		return mailer.NewWriter(f, config.MailFrom), nil
+	case "stdout":
+		return mailer.NewWriter(os.Stdout, config.MailFrom), nil
+	default:
+		return nil, fmt.Errorf("unknown mail backend %q", config.MailBackend)
+	}
+}
+
+// newPasswordHasher creates the hasher of new passwords selected by config.PasswordScheme.
+func newPasswordHasher(config *Config) (model.PasswordHasher, error) {
+	switch config.PasswordScheme {
+	case "bcrypt":
+		if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt_cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+
+		return &model.BcryptHasher{Cost: config.BcryptCost}, nil
+	case "argon2id":
+		if config.Argon2Memory == 0 || config.Argon2Iterations == 0 || config.Argon2Parallelism == 0 {
+			return nil, fmt.Errorf("argon2_memory, argon2_iterations and argon2_parallelism must be positive")
+		}
+
+		return model.NewArgon2idHasher(config.Argon2Memory, config.Argon2Iterations, config.Argon2Parallelism), nil
+	default:
+		return nil, fmt.Errorf("unknown password scheme %q", config.PasswordScheme)
+	}
+}
+
+// newPasswordPolicy creates the policy of new passwords from the config and loads its blocklist file.
+func newPasswordPolicy(config *Config) (*model.PasswordPolicy, error) {
+	if config.PasswordMinLength < 1 {
+		return nil, fmt.Errorf("password_min_length must be positive")
+	}
+
+	if config.PasswordMaxLength != 0 && config.PasswordMaxLength < config.PasswordMinLength {
+		return nil, fmt.Errorf("password_max_length must not be less than password_min_length")
+	}
+
+	policy := &model.PasswordPolicy{
+		MinLength:            config.PasswordMinLength,
+		MaxLength:            config.PasswordMaxLength,
+		RequireUpper:         config.PasswordRequireUpper,
+		RequireLower:         config.PasswordRequireLower,
+		RequireDigit:         config.PasswordRequireDigit,
+		RequireSymbol:        config.PasswordRequireSymbol,
+		ForbidEmailLocalPart: config.PasswordForbidEmail,
+	}
+
+	if config.PasswordBlocklistFile == "" {
+		return policy, nil
+	}
+
+	f, err := os.Open(config.PasswordBlocklistFile)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	if err := policy.LoadBlocklist(f); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,133 @@
+package apiserver
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errInvalidUserID  = errors.New("user_id must be a number")
+	errInvalidActorID = errors.New("actor_id must be a number")
+)
+
+// auditEventsPage is a page of audit events with the number of all selected events.
+type auditEventsPage struct {
+	Events  []*model.AuditEvent `json:"events"`
+	Total   int                 `json:"total"`
+	Page    int                 `json:"page"`
+	PerPage int                 `json:"per_page"`
+}
+
+// audit records the event of the type about the user with the client and the id of the request,
+// actorID is the administrator who acted on the user or zero if the user acted.
+// A failure to record the event is logged and does not fail the request.
+func (s *server) audit(r *http.Request, eventType string, userID int, actorID int) {
+	requestID, _ := r.Context().Value(ctxKeyRequestID).(string)
+	e := &model.AuditEvent{
+		Type:      eventType,
+		UserID:    userID,
+		ActorID:   actorID,
+		IP:        clientIP(r),
+		UserAgent: r.UserAgent(),
+		RequestID: requestID,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.store.AuditEvent().Create(e); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"user_id":    userID,
+			"type":       eventType,
+		}).Errorf("failed to record audit event: %v", err)
+	}
+}
+
+// handleActivity responds with a page of the audit events about the current user, the newest first,
+// selected by the query parameters: page, per_page and type.
+func (s *server) handleActivity() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		q := r.URL.Query()
+		s.respondAuditEvents(w, r, q, &store.AuditEventFilter{
+			UserID: u.ID,
+			Type:   q.Get("type"),
+		})
+	}
+}
+
+// handleAdminAuditEvents responds with a page of the audit events of all users, the newest first,
+// selected by the query parameters: page, per_page, user_id, actor_id, type, ip,
+// after and before (RFC 3339).
+func (s *server) handleAdminAuditEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		f, err := auditEventFilterFromQuery(q)
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		s.respondAuditEvents(w, r, q, f)
+	}
+}
+
+// respondAuditEvents responds with the page of the events selected by the filter that the query parameters ask for.
+func (s *server) respondAuditEvents(w http.ResponseWriter, r *http.Request, q url.Values, f *store.AuditEventFilter) {
+	page, perPage, err := pageFromQuery(q)
+	if err != nil {
+		s.error(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	f.Limit = perPage
+	f.Offset = (page - 1) * perPage
+	events, total, err := s.store.AuditEvent().List(f)
+	if err != nil {
+		s.error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.respond(w, r, http.StatusOK, &auditEventsPage{
+		Events:  events,
+		Total:   total,
+		Page:    page,
+		PerPage: perPage,
+	})
+}
+
+// auditEventFilterFromQuery reads the filter of the audit events from the query parameters.
+func auditEventFilterFromQuery(q url.Values) (*store.AuditEventFilter, error) {
+	f := &store.AuditEventFilter{
+		Type: q.Get("type"),
+		IP:   q.Get("ip"),
+	}
+
+	var err error
+	if f.UserID, err = queryInt(q, "user_id", 0); err != nil {
+		return nil, errInvalidUserID
+	}
+
+	if f.ActorID, err = queryInt(q, "actor_id", 0); err != nil {
+		return nil, errInvalidActorID
+	}
+
+	if v := q.Get("after"); v != "" {
+		if f.After, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := q.Get("before"); v != "" {
+		if f.Before, err = time.Parse(time.RFC3339, v); err != nil {
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
@@ -0,0 +1,142 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleActivity(t *testing.T) {
+	st := teststore.New()
+	u := model.TestUser(t)
+	st.User().Create(u)
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testTwoFactorConfig(t))
+
+	rec := testRequest(t, s, http.MethodPost, "/sessions", map[string]string{
+		"email":    u.Email.String,
+		"password": "wrong",
+	}, nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	assert.Equal(t, http.StatusNoContent, testRequest(t, s, http.MethodDelete, "/sessions", nil, testLogin(t, s, u)).Code)
+	cookies := testLogin(t, s, u)
+
+	rec = testRequest(t, s, http.MethodGet, "/private/activity", nil, cookies)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	res := &auditEventsPage{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(res))
+	assert.Equal(t, 4, res.Total)
+	types := []string{}
+	for _, e := range res.Events {
+		assert.Equal(t, u.ID, e.UserID)
+		assert.NotEmpty(t, e.RequestID)
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []string{model.AuditLogin, model.AuditLogout, model.AuditLogin, model.AuditLoginFailed}, types)
+
+	rec = testRequest(t, s, http.MethodGet, "/private/activity?type=login_failed&per_page=1", nil, cookies)
+	res = &auditEventsPage{}
+	json.NewDecoder(rec.Body).Decode(res)
+	assert.Equal(t, 1, res.Total)
+	assert.Equal(t, 1, res.PerPage)
+
+	assert.Equal(t, http.StatusBadRequest, testRequest(t, s, http.MethodGet, "/private/activity?page=0", nil, cookies).Code)
+	assert.Equal(t, http.StatusUnauthorized, testRequest(t, s, http.MethodGet, "/private/activity", nil, nil).Code)
+}
+
+func TestServer_handleAdminAuditEvents(t *testing.T) {
+	st := teststore.New()
+	admin := model.TestUser(t)
+	admin.Email.String = "admin@example.org"
+	st.User().Create(admin)
+	st.Role().Assign(admin.ID, model.RoleAdmin)
+	moderator := model.TestUser(t)
+	moderator.Email.String = "moderator@example.org"
+	st.User().Create(moderator)
+	st.Role().Assign(moderator.ID, model.RoleModerator)
+	u := model.TestUser(t)
+	st.User().Create(u)
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testTwoFactorConfig(t))
+	cookies := testLogin(t, s, admin)
+
+	assert.Equal(t, http.StatusForbidden, testRequest(t, s, http.MethodGet, "/admin/audit-events", nil, testLogin(t, s, u)).Code)
+	assert.Equal(t, http.StatusForbidden, testRequest(t, s, http.MethodGet, "/admin/audit-events", nil, testLogin(t, s, moderator)).Code)
+
+	assert.Equal(t, http.StatusOK, testRequest(t, s, http.MethodPost, fmt.Sprintf("/admin/users/%d/disable", u.ID), nil, cookies).Code)
+
+	testCases := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedTotal int
+	}{
+		{
+			name:          "all",
+			query:         "",
+			expectedCode:  http.StatusOK,
+			expectedTotal: 4,
+		},
+		{
+			name:          "user",
+			query:         fmt.Sprintf("?user_id=%d", u.ID),
+			expectedCode:  http.StatusOK,
+			expectedTotal: 2,
+		},
+		{
+			name:          "actor",
+			query:         fmt.Sprintf("?actor_id=%d", admin.ID),
+			expectedCode:  http.StatusOK,
+			expectedTotal: 1,
+		},
+		{
+			name:          "type",
+			query:         "?type=login",
+			expectedCode:  http.StatusOK,
+			expectedTotal: 3,
+		},
+		{
+			name:          "time range",
+			query:         "?after=2000-01-01T00:00:00Z&before=2001-01-01T00:00:00Z",
+			expectedCode:  http.StatusOK,
+			expectedTotal: 0,
+		},
+		{
+			name:         "invalid user",
+			query:        "?user_id=abc",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid time",
+			query:        "?after=yesterday",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, http.MethodGet, "/admin/audit-events"+tc.query, nil, cookies)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedCode == http.StatusOK {
+				res := &auditEventsPage{}
+				json.NewDecoder(rec.Body).Decode(res)
+				assert.Equal(t, tc.expectedTotal, res.Total)
+			}
+		})
+	}
+
+	rec := testRequest(t, s, http.MethodGet, fmt.Sprintf("/admin/audit-events?actor_id=%d", admin.ID), nil, cookies)
+	res := &auditEventsPage{}
+	json.NewDecoder(rec.Body).Decode(res)
+	assert.Equal(t, model.AuditAdminUserDisable, res.Events[0].Type)
+	assert.Equal(t, u.ID, res.Events[0].UserID)
+}
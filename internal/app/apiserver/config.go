@@ -1,22 +1,176 @@
 package apiserver
 
+import (
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
 // Config holds the configuration settings for the server application.
 // It includes the following fields:
 // - BindAddr: the address the server will bind to, used for listening to incoming connections.
 // - LogLevel: the logging level for the application, defining the verbosity of the logs.
 // - DatabaseURL: the URL for connecting to the database, including the necessary credentials and connection string.
 // - SessionKey: the secret key used for securing user sessions, which ensures the integrity of session data.
+// - SessionBackend: where sessions are kept, "cookie" for signed cookies or "database" for revocable session records.
+// - TokenIssuer: the value of the "iss" claim of issued access tokens.
+// - AccessTokenTTL: how long an issued access token stays valid.
+// - RefreshTokenTTL: how long an issued refresh token stays valid.
+// - SigningKeys: the keys for signing access tokens, the first one signs new tokens and all of them verify tokens.
+// - TelegramBotToken: the token of the bot that signs the Telegram login data.
+// - TelegramAuthMaxAge: how old the auth_date of the Telegram login data may be.
+// - PublicURL: the address of the site that is used in the links sent to users.
+// - PasswordResetTTL: how long a password reset token stays valid.
+// - VerifyEmail: what an unverified email allows, "block" refuses the login with email and password,
+// "restrict" allows the login but only to the routes that do not require a verified email.
+// - EmailVerificationTTL: how long an email verification link stays valid.
+// - EmailResendInterval: how often the verification email can be sent to the same user.
+// - MailBackend: how emails are delivered, "smtp", "file" for appending them to MailFile or "stdout".
+// - MailFile: the file the emails are appended to with the "file" backend.
+// - MailFrom: the address the emails are sent from.
+// - SMTPAddr, SMTPUsername, SMTPPassword: the address and the credentials of the SMTP server.
+// - TwoFactorIssuer: the name of the service that authenticator apps show next to the TOTP codes.
+// - TwoFactorChallengeTTL: how long the user has to enter the second factor after the password.
+// - LoginBackoff: how long the next password guess for an account is refused after the first failure, the delay doubles with each failure.
+// - LoginLockoutThreshold: after how many failures in a row the account is locked.
+// - LoginIPLockoutThreshold: after how many failures in a row the client address is locked.
+// - LoginLockoutDuration: how long the account or the client address stays locked,
+// failures older than that are forgotten.
+// - PasswordScheme: how new passwords are hashed, "argon2id" or "bcrypt",
+// the passwords hashed by the other scheme or with other parameters are rehashed on the next login.
+// - BcryptCost: the cost of the "bcrypt" scheme.
+// - Argon2Memory, Argon2Iterations, Argon2Parallelism: the memory in KiB, the number of passes
+// and the number of threads of the "argon2id" scheme.
+// - PasswordMinLength, PasswordMaxLength: the length of new passwords in characters, zero PasswordMaxLength means no limit.
+// - PasswordRequireUpper, PasswordRequireLower, PasswordRequireDigit, PasswordRequireSymbol: the character classes new passwords must contain.
+// - PasswordForbidEmail: whether new passwords must not contain the part of the user's email before "@".
+// - PasswordBlocklistFile: the file of breached or common passwords, one per line, that new passwords must not be.
+// - AccountDeletion: what happens to the account the user deleted once the grace period ends,
+// "delete" removes it with all its records and "anonymize" keeps a disabled record without the personal data.
+// - AccountDeletionGracePeriod: how long the user can cancel the deletion by logging in, zero deletes the account at once.
+// - AccountDeletionSweepInterval: how often the accounts whose grace period ended are deleted.
+// - OAuthCodeTTL: how long an authorization code issued to an OAuth client stays valid.
+// - OAuthConsentTTL: how long the user has to answer the consent page of an OAuth client.
+// - ExternalProviders: the OpenID Connect providers that users can log in with at /enter/oauth/{name}.
+// - RateLimits: the limits of the requests of one client to the public, enter, telegram and private route groups.
 type Config struct {
-	BindAddr    string `toml:"bind_addr"`
-	LogLevel    string `toml:"log_level"`
-	DatabaseURL string `toml:"database_url"`
-	SessionKey  string `toml:"session_key"`
+	BindAddr        string        `toml:"bind_addr"`
+	LogLevel        string        `toml:"log_level"`
+	DatabaseURL     string        `toml:"database_url"`
+	SessionKey      string        `toml:"session_key"`
+	SessionBackend  string        `toml:"session_backend"`
+	TokenIssuer     string        `toml:"token_issuer"`
+	AccessTokenTTL  time.Duration `toml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `toml:"refresh_token_ttl"`
+	SigningKeys     []*SigningKey `toml:"signing_keys"`
+
+	TelegramBotToken   string        `toml:"telegram_bot_token"`
+	TelegramAuthMaxAge time.Duration `toml:"telegram_auth_max_age"`
+
+	PublicURL        string        `toml:"public_url"`
+	PasswordResetTTL time.Duration `toml:"password_reset_ttl"`
+
+	VerifyEmail          string        `toml:"verify_email"`
+	EmailVerificationTTL time.Duration `toml:"email_verification_ttl"`
+	EmailResendInterval  time.Duration `toml:"email_resend_interval"`
+
+	MailBackend  string `toml:"mail_backend"`
+	MailFile     string `toml:"mail_file"`
+	MailFrom     string `toml:"mail_from"`
+	SMTPAddr     string `toml:"smtp_addr"`
+	SMTPUsername string `toml:"smtp_username"`
+	SMTPPassword string `toml:"smtp_password"`
+
+	TwoFactorIssuer       string        `toml:"two_factor_issuer"`
+	TwoFactorChallengeTTL time.Duration `toml:"two_factor_challenge_ttl"`
+
+	LoginBackoff            time.Duration `toml:"login_backoff"`
+	LoginLockoutThreshold   int           `toml:"login_lockout_threshold"`
+	LoginIPLockoutThreshold int           `toml:"login_ip_lockout_threshold"`
+	LoginLockoutDuration    time.Duration `toml:"login_lockout_duration"`
+
+	PasswordScheme    string `toml:"password_scheme"`
+	BcryptCost        int    `toml:"bcrypt_cost"`
+	Argon2Memory      uint32 `toml:"argon2_memory"`
+	Argon2Iterations  uint32 `toml:"argon2_iterations"`
+	Argon2Parallelism uint8  `toml:"argon2_parallelism"`
+
+	PasswordMinLength     int    `toml:"password_min_length"`
+	PasswordMaxLength     int    `toml:"password_max_length"`
+	PasswordRequireUpper  bool   `toml:"password_require_upper"`
+	PasswordRequireLower  bool   `toml:"password_require_lower"`
+	PasswordRequireDigit  bool   `toml:"password_require_digit"`
+	PasswordRequireSymbol bool   `toml:"password_require_symbol"`
+	PasswordForbidEmail   bool   `toml:"password_forbid_email"`
+	PasswordBlocklistFile string `toml:"password_blocklist_file"`
+
+	AccountDeletion              string        `toml:"account_deletion"`
+	AccountDeletionGracePeriod   time.Duration `toml:"account_deletion_grace_period"`
+	AccountDeletionSweepInterval time.Duration `toml:"account_deletion_sweep_interval"`
+
+	OAuthCodeTTL    time.Duration `toml:"oauth_code_ttl"`
+	OAuthConsentTTL time.Duration `toml:"oauth_consent_ttl"`
+
+	ExternalProviders []*ExternalProvider `toml:"external_providers"`
+
+	RateLimits RateLimits `toml:"rate_limits"`
 }
 
 // NewConfig returns a new config with filled fields from the toml file.
 func NewConfig() *Config {
 	return &Config{
-		BindAddr:    ":8080",
-		LogLevel:    "debug",
+		BindAddr:        ":8080",
+		LogLevel:        "debug",
+		SessionBackend:  "database",
+		TokenIssuer:     domainURL,
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 30 * 24 * time.Hour,
+
+		TelegramAuthMaxAge: 24 * time.Hour,
+
+		PublicURL:        domainURL,
+		PasswordResetTTL: time.Hour,
+
+		VerifyEmail:          "restrict",
+		EmailVerificationTTL: 48 * time.Hour,
+		EmailResendInterval:  time.Minute,
+
+		MailBackend: "stdout",
+		MailFrom:    "noreply@localhost",
+
+		TwoFactorIssuer:       "http-rest-API",
+		TwoFactorChallengeTTL: 5 * time.Minute,
+
+		LoginBackoff:            time.Second,
+		LoginLockoutThreshold:   10,
+		LoginIPLockoutThreshold: 100,
+		LoginLockoutDuration:    15 * time.Minute,
+
+		PasswordScheme:    "argon2id",
+		BcryptCost:        bcrypt.DefaultCost,
+		Argon2Memory:      64 * 1024,
+		Argon2Iterations:  3,
+		Argon2Parallelism: 4,
+
+		PasswordMinLength:    6,
+		PasswordMaxLength:    30,
+		PasswordRequireUpper: true,
+		PasswordRequireLower: true,
+		PasswordRequireDigit: true,
+		PasswordForbidEmail:  true,
+
+		AccountDeletion:              "anonymize",
+		AccountDeletionGracePeriod:   14 * 24 * time.Hour,
+		AccountDeletionSweepInterval: time.Hour,
+
+		OAuthCodeTTL:    time.Minute,
+		OAuthConsentTTL: 10 * time.Minute,
+
+		RateLimits: RateLimits{
+			Public:   RateLimit{Requests: 60, Period: time.Minute, Burst: 20},
+			Enter:    RateLimit{Requests: 120, Period: time.Minute},
+			Telegram: RateLimit{Requests: 30, Period: time.Minute, Burst: 10},
+			Private:  RateLimit{Requests: 300, Period: time.Minute},
+		},
 	}
 }
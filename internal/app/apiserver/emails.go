@@ -0,0 +1,298 @@
+package apiserver
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	emailVerificationName = "email_verification"
+	emailChangeName       = "email_change"
+)
+
+var (
+	errEmailNotVerified         = errors.New("email is not verified")
+	errEmailAlreadyVerified     = errors.New("email is already verified")
+	errNoEmail                  = errors.New("account has no email")
+	errInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	errTooManyRequests          = errors.New("too many requests")
+	errSameEmail                = errors.New("new email is the same as the current one")
+)
+
+// emailVerification is the value signed into the token of an email verification or change link.
+// The link is valid only while the user has the same email, or the same pending email for a change.
+type emailVerification struct {
+	UserID    int
+	Email     string
+	ExpiresAt int64
+}
+
+// handleEmailVerify confirms the email by the token of the verification link.
+func (s *server) handleEmailVerify() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		v, err := s.parseEmailToken(emailVerificationName, r.URL.Query().Get("token"))
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := s.store.User().VerifyEmail(v.UserID, v.Email); err != nil {
+			s.error(w, r, http.StatusBadRequest, errInvalidVerificationToken)
+			return
+		}
+
+		u, err := s.store.User().Find(v.UserID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, u)
+	}
+}
+
+// handleEmailChange stores the new email of the current user as pending and sends a confirmation link to it.
+// The current email gets a notice about the change.
+// It requires the password or, for the user without a password, the second factor if it is enabled.
+func (s *server) handleEmailChange() http.HandlerFunc {
+	type request struct {
+		Email        string `json:"email"`
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := *r.Context().Value(ctxKeyUser).(*model.User)
+		if !u.Email.Valid {
+			s.error(w, r, http.StatusBadRequest, errNoEmail)
+			return
+		}
+
+		if !s.reauthenticate(w, r, &u, req.Password, req.Code, req.RecoveryCode) {
+			return
+		}
+
+		if req.Email == u.Email.String {
+			s.error(w, r, http.StatusBadRequest, errSameEmail)
+			return
+		}
+
+		if _, err := s.store.User().FindByEmail(req.Email); err != store.ErrRecordNotFound {
+			if err == nil {
+				s.error(w, r, http.StatusConflict, store.ErrEmailTaken)
+				return
+			}
+
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		u.PendingEmail = sql.NullString{String: req.Email, Valid: req.Email != ""}
+		if err := s.store.User().SetPendingEmail(&u); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.sendEmailChange(&u); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.sendMailOrLog(r, &mailer.Message{
+			To:      u.Email.String,
+			Subject: "Your email is being changed",
+			Body: fmt.Sprintf(
+				"A change of the email of your account to %s was requested. The change takes effect once the new address is confirmed.\n\nIf you did not ask for it, change your password.",
+				u.PendingEmail.String,
+			),
+		})
+
+		s.respond(w, r, http.StatusAccepted, nil)
+	}
+}
+
+// handleEmailConfirm replaces the email with the pending one by the token of the confirmation link.
+func (s *server) handleEmailConfirm() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		v, err := s.parseEmailToken(emailChangeName, r.URL.Query().Get("token"))
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := s.store.User().ConfirmEmail(v.UserID, v.Email); err != nil {
+			switch err {
+			case store.ErrEmailTaken:
+				s.error(w, r, http.StatusConflict, err)
+			case store.ErrRecordNotFound:
+				s.error(w, r, http.StatusBadRequest, errInvalidVerificationToken)
+			default:
+				s.error(w, r, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		u, err := s.store.User().Find(v.UserID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, u)
+	}
+}
+
+// handleEmailResend sends the verification email to the current user again.
+func (s *server) handleEmailResend() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		if !u.Email.Valid {
+			s.error(w, r, http.StatusBadRequest, errNoEmail)
+			return
+		}
+
+		if !u.NeedsEmailVerification() {
+			s.error(w, r, http.StatusConflict, errEmailAlreadyVerified)
+			return
+		}
+
+		if wait, ok := s.resendThrottle.allow(strconv.Itoa(u.ID), time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			s.error(w, r, http.StatusTooManyRequests, errTooManyRequests)
+			return
+		}
+
+		if err := s.sendEmailVerification(u); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusAccepted, nil)
+	}
+}
+
+// requireVerifiedEmail rejects the users whose email is not verified yet.
+func (s *server) requireVerifiedEmail(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(ctxKeyUser).(*model.User).NeedsEmailVerification() {
+			s.error(w, r, http.StatusForbidden, errEmailNotVerified)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoginBlocked reports whether the user may not log in with email and password until the email is verified.
+// A blocked user is sent the verification email again, at most once per EmailResendInterval.
+func (s *server) isLoginBlocked(r *http.Request, u *model.User) bool {
+	if s.config.VerifyEmail != "block" || !u.NeedsEmailVerification() {
+		return false
+	}
+
+	if _, ok := s.resendThrottle.allow(strconv.Itoa(u.ID), time.Now()); ok {
+		s.sendEmailVerificationOrLog(r, u)
+	}
+
+	return true
+}
+
+// sendEmailVerification mails the link that confirms the email of the user.
+func (s *server) sendEmailVerification(u *model.User) error {
+	token, err := s.signEmailToken(emailVerificationName, u.ID, u.Email.String)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      u.Email.String,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf(
+			"To confirm your email, open the link below. It is valid for %s.\n\n%s/email/verify?token=%s\n\nIf you did not create an account, ignore this email.",
+			s.config.EmailVerificationTTL,
+			s.config.PublicURL,
+			url.QueryEscape(token),
+		),
+	})
+}
+
+// sendEmailChange mails the link that confirms the pending email of the user to the pending email.
+func (s *server) sendEmailChange(u *model.User) error {
+	token, err := s.signEmailToken(emailChangeName, u.ID, u.PendingEmail.String)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      u.PendingEmail.String,
+		Subject: "Confirm your new email",
+		Body: fmt.Sprintf(
+			"To use this address for your account, open the link below. It is valid for %s.\n\n%s/email/confirm?token=%s\n\nIf you did not ask for it, ignore this email.",
+			s.config.EmailVerificationTTL,
+			s.config.PublicURL,
+			url.QueryEscape(token),
+		),
+	})
+}
+
+// sendEmailVerificationOrLog sends the verification email and only logs a failure,
+// so the request that triggered it does not fail because of the mail delivery.
+func (s *server) sendEmailVerificationOrLog(r *http.Request, u *model.User) {
+	if err := s.sendEmailVerification(u); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"request_id": r.Context().Value(ctxKeyRequestID),
+			"user_id":    u.ID,
+		}).Errorf("failed to send email verification: %v", err)
+	}
+}
+
+// sendMailOrLog sends the message and only logs a failure.
+func (s *server) sendMailOrLog(r *http.Request, msg *mailer.Message) {
+	if err := s.mailer.Send(msg); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"request_id": r.Context().Value(ctxKeyRequestID),
+		}).Errorf("failed to send email: %v", err)
+	}
+}
+
+// signEmailToken returns the signed token of a link that confirms the email of the user.
+func (s *server) signEmailToken(name string, userID int, email string) (string, error) {
+	return s.signer.Encode(name, &emailVerification{
+		UserID:    userID,
+		Email:     email,
+		ExpiresAt: time.Now().Add(s.config.EmailVerificationTTL).Unix(),
+	})
+}
+
+// parseEmailToken verifies the signature and the expiration of the token of a link that confirms an email.
+func (s *server) parseEmailToken(name string, token string) (*emailVerification, error) {
+	v := &emailVerification{}
+	if err := s.signer.Decode(name, token, v); err != nil {
+		return nil, errInvalidVerificationToken
+	}
+
+	if time.Now().Unix() >= v.ExpiresAt {
+		return nil, errInvalidVerificationToken
+	}
+
+	return v, nil
+}
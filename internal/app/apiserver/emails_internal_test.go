@@ -0,0 +1,340 @@
+package apiserver
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+var verificationTokenRe = regexp.MustCompile(`/email/verify\?token=(\S+)`)
+
+func TestServer_handleEmailVerify(t *testing.T) {
+	store := teststore.New()
+	mail := &bytes.Buffer{}
+	config := NewConfig()
+	config.SessionKey = "secret"
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, mail), config)
+
+	rec := httptest.NewRecorder()
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]string{
+		"email":            "user@example.org",
+		"password":         "Password123",
+		"confirm_password": "Password123",
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/users", b)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	token := verificationTokenRe.FindStringSubmatch(mail.String())[1]
+
+	u, _ := store.User().FindByEmail("user@example.org")
+	sign := func(v *emailVerification) string {
+		token, err := s.signer.Encode(emailVerificationName, v)
+		assert.NoError(t, err)
+		return url.QueryEscape(token)
+	}
+
+	testCases := []struct {
+		name         string
+		token        string
+		expectedCode int
+	}{
+		{
+			name:         "invalid",
+			token:        "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "expired",
+			token: sign(&emailVerification{
+				UserID:    u.ID,
+				Email:     u.Email.String,
+				ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+			}),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "another email",
+			token: sign(&emailVerification{
+				UserID:    u.ID,
+				Email:     "other@example.org",
+				ExpiresAt: time.Now().Add(time.Hour).Unix(),
+			}),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "valid",
+			token:        token,
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/email/verify?token="+tc.token, nil)
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	u, _ = store.User().Find(u.ID)
+	assert.False(t, u.NeedsEmailVerification())
+}
+
+func TestServer_RequireVerifiedEmail(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
+	cookies := testLogin(t, s, u)
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		s.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get("/private/whoami"))
+	assert.Equal(t, http.StatusForbidden, get("/private/api-keys"))
+
+	store.User().VerifyEmail(u.ID, u.Email.String)
+	assert.Equal(t, http.StatusOK, get("/private/whoami"))
+	assert.Equal(t, http.StatusOK, get("/private/api-keys"))
+}
+
+func TestServer_BlockUnverifiedLogin(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	mail := &bytes.Buffer{}
+	config := testConfig(t)
+	config.SessionKey = "secret"
+	config.VerifyEmail = "block"
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, mail), config)
+
+	login := func(path string) int {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]string{
+			"email":    u.Email.String,
+			"password": u.Password,
+		})
+		req, _ := http.NewRequest(http.MethodPost, path, b)
+		s.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, login("/sessions"))
+	assert.Equal(t, http.StatusForbidden, login("/tokens"))
+	assert.Len(t, verificationTokenRe.FindAllString(mail.String(), -1), 1)
+
+	store.User().VerifyEmail(u.ID, u.Email.String)
+	assert.Equal(t, http.StatusOK, login("/sessions"))
+	assert.Equal(t, http.StatusOK, login("/tokens"))
+}
+
+func TestServer_handleEmailResend(t *testing.T) {
+	store := teststore.New()
+	unverified := model.TestUser(t)
+	store.User().Create(unverified)
+	verified := model.TestUser(t)
+	verified.Email.String = "verified@example.org"
+	verified.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	store.User().Create(verified)
+	telegramOnly := model.TestUserWithTelegram(t)
+	store.User().Create(telegramOnly)
+	mail := &bytes.Buffer{}
+	config := NewConfig()
+	config.SessionKey = "secret"
+	config.TelegramBotToken = testBotToken
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, mail), config)
+
+	unverifiedCookies := testLogin(t, s, unverified)
+	testCases := []struct {
+		name         string
+		cookies      []*http.Cookie
+		expectedCode int
+	}{
+		{
+			name:         "unverified",
+			cookies:      unverifiedCookies,
+			expectedCode: http.StatusAccepted,
+		},
+		{
+			name:         "too soon",
+			cookies:      unverifiedCookies,
+			expectedCode: http.StatusTooManyRequests,
+		},
+		{
+			name:         "verified",
+			cookies:      testLogin(t, s, verified),
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "no email",
+			cookies:      testTelegramLogin(t, s, telegramOnly.IDTelegram.Int64),
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "not authenticated",
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/private/email/resend", nil)
+			for _, c := range tc.cookies {
+				req.AddCookie(c)
+			}
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	assert.Len(t, verificationTokenRe.FindAllString(mail.String(), -1), 1)
+}
+
+var confirmationTokenRe = regexp.MustCompile(`/email/confirm\?token=(\S+)`)
+
+func TestServer_handleEmailChange(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	oldEmail := u.Email.String
+	store.User().Create(u)
+	other := model.TestUser(t)
+	other.Email.String = "other@example.org"
+	store.User().Create(other)
+	mail := &bytes.Buffer{}
+	config := NewConfig()
+	config.SessionKey = "secret"
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, mail), config)
+	cookies := testLogin(t, s, u)
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name: "wrong password",
+			payload: map[string]string{
+				"email":    "new@example.org",
+				"password": "wrong",
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name: "same email",
+			payload: map[string]string{
+				"email":    oldEmail,
+				"password": "password",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "taken email",
+			payload: map[string]string{
+				"email":    other.Email.String,
+				"password": "password",
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name: "invalid email",
+			payload: map[string]string{
+				"email":    "invalid",
+				"password": "password",
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "valid",
+			payload: map[string]string{
+				"email":    "new@example.org",
+				"password": "password",
+			},
+			expectedCode: http.StatusAccepted,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPut, "/private/email", b)
+			for _, c := range cookies {
+				req.AddCookie(c)
+			}
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	assert.Contains(t, mail.String(), "To: new@example.org")
+	assert.Contains(t, mail.String(), "To: "+oldEmail)
+	_, err := store.User().FindByEmail("new@example.org")
+	assert.Error(t, err)
+
+	token := confirmationTokenRe.FindStringSubmatch(mail.String())[1]
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/email/confirm?token="+token, nil)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	changed, err := store.User().FindByEmail("new@example.org")
+	assert.NoError(t, err)
+	assert.Equal(t, u.ID, changed.ID)
+	assert.False(t, changed.NeedsEmailVerification())
+	_, err = store.User().FindByEmail(oldEmail)
+	assert.Error(t, err)
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/email/confirm?token="+token, nil)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestServer_handleEmailChangeWithoutPassword(t *testing.T) {
+	p := newTestExternalProvider(t)
+	st := teststore.New()
+	mail := &bytes.Buffer{}
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, mail), testExternalConfig(t, p))
+	cookies := testExternalLogin(t, s, p, "/enter/oauth/fake", nil).Result().Cookies()
+
+	rec := testRequest(t, s, http.MethodPut, "/private/email", map[string]string{"email": "new@example.org"}, cookies)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Contains(t, mail.String(), "To: new@example.org")
+
+	_, recoveryCodes := testEnableTwoFactor(t, s, cookies)
+	rec = testRequest(t, s, http.MethodPut, "/private/email", map[string]string{"email": "other@example.org"}, cookies)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = testRequest(t, s, http.MethodPut, "/private/email", map[string]string{
+		"email":         "other@example.org",
+		"recovery_code": recoveryCodes[0],
+	}, cookies)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Contains(t, mail.String(), "To: other@example.org")
+}
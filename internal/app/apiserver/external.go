@@ -0,0 +1,387 @@
+package apiserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/gorilla/mux"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"golang.org/x/oauth2"
+)
+
+const (
+	externalLoginName = "external_login"
+	externalLoginTTL  = 10 * time.Minute
+)
+
+var (
+	errUnknownProvider       = errors.New("unknown external provider")
+	errInvalidExternalState  = errors.New("invalid or expired external login")
+	errNoIDToken             = errors.New("the provider returned no id_token")
+	errInvalidNonce          = errors.New("the nonce of the id_token does not match")
+	errExternalEmailTaken    = errors.New("the email of the external account belongs to another account, log in and link the external account")
+	errExternalLinkedToOther = errors.New("the external account is linked to another account")
+)
+
+// ExternalProvider is an OpenID Connect provider that users can log in with.
+// It includes the following fields:
+// - Name: the name of the provider in the login and callback routes, such as "google".
+// - Issuer: the issuer URL of the provider, its configuration is discovered at Issuer + "/.well-known/openid-configuration".
+// - ClientID, ClientSecret: the credentials of this server registered at the provider.
+// - Scopes: the scopes that are asked for in addition to "openid", such as "email".
+type ExternalProvider struct {
+	Name         string   `toml:"name"`
+	Issuer       string   `toml:"issuer"`
+	ClientID     string   `toml:"client_id"`
+	ClientSecret string   `toml:"client_secret"`
+	Scopes       []string `toml:"scopes"`
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+// externalLoginState is what the server remembers between the redirect to the provider and the callback,
+// it is signed into a cookie of the browser.
+// LinkUserID is set when the logged in user links the external account instead of logging in with it.
+type externalLoginState struct {
+	Provider   string
+	State      string
+	Nonce      string
+	Verifier   string
+	LinkUserID int
+	Next       string
+	ExpiresAt  int64
+}
+
+// externalClaims are the claims of an ID token of an external provider that are used besides the subject.
+type externalClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// discover returns the configuration of the provider, it is fetched once on the first use.
+func (p *ExternalProvider) discover(ctx context.Context) (*oidc.Provider, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.provider != nil {
+		return p.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, p.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	p.provider = provider
+	return provider, nil
+}
+
+// oauth2Config returns the OAuth 2.0 configuration of this server as a client of the provider.
+func (p *ExternalProvider) oauth2Config(provider *oidc.Provider, redirectURL string) *oauth2.Config {
+	scopes := []string{oidc.ScopeOpenID}
+	for _, scope := range p.Scopes {
+		if scope != oidc.ScopeOpenID {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+	}
+}
+
+// checkExternalProviders checks that every configured provider has a unique name, an issuer and a client id.
+func checkExternalProviders(providers []*ExternalProvider) error {
+	names := map[string]bool{}
+	for _, p := range providers {
+		if p.Name == "" {
+			return errors.New("external provider without name")
+		}
+
+		if names[p.Name] {
+			return fmt.Errorf("duplicate external provider %q", p.Name)
+		}
+
+		if p.Issuer == "" || p.ClientID == "" {
+			return fmt.Errorf("external provider %q without issuer or client_id", p.Name)
+		}
+
+		names[p.Name] = true
+	}
+
+	return nil
+}
+
+// handleExternalLogin redirects the browser to the provider by the name to log in there.
+// With link=true the logged in user links the external account to the current account instead.
+// The next parameter is the path on this site where the browser goes after the login.
+func (s *server) handleExternalLogin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := s.externalProvider(w, r)
+		if !ok {
+			return
+		}
+
+		st := &externalLoginState{
+			Provider:  p.Name,
+			State:     generateToken(),
+			Nonce:     generateToken(),
+			Verifier:  oauth2.GenerateVerifier(),
+			Next:      localPath(r.URL.Query().Get("next")),
+			ExpiresAt: time.Now().Add(externalLoginTTL).Unix(),
+		}
+
+		if r.URL.Query().Get("link") == "true" {
+			u, err := s.userFromSession(r)
+			if err != nil {
+				s.error(w, r, http.StatusUnauthorized, errNotAuthenticated)
+				return
+			}
+
+			st.LinkUserID = u.ID
+		}
+
+		provider, err := p.discover(r.Context())
+		if err != nil {
+			s.error(w, r, http.StatusBadGateway, err)
+			return
+		}
+
+		value, err := s.signer.Encode(externalLoginName, st)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		http.SetCookie(w, s.externalLoginCookie(value, int(externalLoginTTL.Seconds())))
+		http.Redirect(w, r, p.oauth2Config(provider, s.externalCallbackURL(p)).AuthCodeURL(
+			st.State,
+			oidc.Nonce(st.Nonce),
+			oauth2.S256ChallengeOption(st.Verifier),
+		), http.StatusFound)
+	}
+}
+
+// handleExternalCallback completes the login at the provider by the name.
+// It checks the state, exchanges the code for the tokens, verifies the ID token with its nonce,
+// then logs in the user the external account is linked to, creating the user on the first login, or links the account.
+func (s *server) handleExternalCallback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := s.externalProvider(w, r)
+		if !ok {
+			return
+		}
+
+		st := &externalLoginState{}
+		c, err := r.Cookie(externalLoginName)
+		if err != nil || s.signer.Decode(externalLoginName, c.Value, st) != nil ||
+			st.Provider != p.Name || time.Now().Unix() > st.ExpiresAt {
+			s.error(w, r, http.StatusBadRequest, errInvalidExternalState)
+			return
+		}
+
+		http.SetCookie(w, s.externalLoginCookie("", -1))
+
+		q := r.URL.Query()
+		if subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(st.State)) != 1 {
+			s.error(w, r, http.StatusBadRequest, errInvalidExternalState)
+			return
+		}
+
+		if e := q.Get("error"); e != "" {
+			s.error(w, r, http.StatusUnauthorized, fmt.Errorf("%s: %s", e, q.Get("error_description")))
+			return
+		}
+
+		provider, err := p.discover(r.Context())
+		if err != nil {
+			s.error(w, r, http.StatusBadGateway, err)
+			return
+		}
+
+		token, err := p.oauth2Config(provider, s.externalCallbackURL(p)).Exchange(
+			r.Context(),
+			q.Get("code"),
+			oauth2.VerifierOption(st.Verifier),
+		)
+		if err != nil {
+			s.error(w, r, http.StatusUnauthorized, err)
+			return
+		}
+
+		rawIDToken, ok := token.Extra("id_token").(string)
+		if !ok {
+			s.error(w, r, http.StatusUnauthorized, errNoIDToken)
+			return
+		}
+
+		idToken, err := provider.Verifier(&oidc.Config{ClientID: p.ClientID}).Verify(r.Context(), rawIDToken)
+		if err != nil {
+			s.error(w, r, http.StatusUnauthorized, err)
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(idToken.Nonce), []byte(st.Nonce)) != 1 {
+			s.error(w, r, http.StatusUnauthorized, errInvalidNonce)
+			return
+		}
+
+		claims := &externalClaims{}
+		if err := idToken.Claims(claims); err != nil {
+			s.error(w, r, http.StatusUnauthorized, err)
+			return
+		}
+
+		identity := &model.ExternalIdentity{
+			Provider:  p.Name,
+			Subject:   idToken.Subject,
+			Email:     claims.Email,
+			CreatedAt: time.Now(),
+		}
+
+		if st.LinkUserID != 0 {
+			s.externalLink(w, r, identity, st)
+			return
+		}
+
+		s.externalLogin(w, r, identity, claims.EmailVerified, st)
+	}
+}
+
+// externalLogin finds the user by the verified external account or creates a new one and creates a session.
+// A new user gets the email of the external account only if the provider verified it.
+// A user with the second factor is sent to the login page with a challenge to complete the login there.
+func (s *server) externalLogin(w http.ResponseWriter, r *http.Request, identity *model.ExternalIdentity, emailVerified bool, st *externalLoginState) {
+	u, err := s.store.User().FindByExternalIdentity(identity.Provider, identity.Subject)
+	if err != nil {
+		if err != store.ErrRecordNotFound {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		u = &model.User{}
+		if identity.Email != "" && emailVerified {
+			u.Email = sql.NullString{String: identity.Email, Valid: true}
+			u.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		}
+
+		if err := s.store.User().CreateWithExternalIdentity(u, identity); err != nil {
+			switch err {
+			case store.ErrEmailTaken:
+				s.error(w, r, http.StatusConflict, errExternalEmailTaken)
+			case store.ErrExternalTaken:
+				s.error(w, r, http.StatusConflict, err)
+			default:
+				s.error(w, r, http.StatusUnprocessableEntity, err)
+			}
+
+			return
+		}
+
+		s.audit(r, model.AuditRegister, u.ID, 0)
+	} else if u.IsDisabled() {
+		s.error(w, r, http.StatusForbidden, errAccountDisabled)
+		return
+	} else if u.HasTwoFactor() {
+		challenge, err := s.signTwoFactorChallenge(u.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		http.Redirect(w, r, s.config.PublicURL+"/enter/login?"+url.Values{
+			"challenge": {challenge},
+			"next":      {st.Next},
+		}.Encode(), http.StatusFound)
+		return
+	}
+
+	if err := s.startSession(w, r, u); err != nil {
+		s.error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.audit(r, model.AuditExternalLogin, u.ID, 0)
+
+	http.Redirect(w, r, s.config.PublicURL+st.Next, http.StatusFound)
+}
+
+// externalLink links the verified external account to the user who started the login,
+// if that user is still logged in.
+func (s *server) externalLink(w http.ResponseWriter, r *http.Request, identity *model.ExternalIdentity, st *externalLoginState) {
+	u, err := s.userFromSession(r)
+	if err != nil || u.ID != st.LinkUserID {
+		s.error(w, r, http.StatusUnauthorized, errNotAuthenticated)
+		return
+	}
+
+	identity.UserID = u.ID
+	if err := s.store.ExternalIdentity().Create(identity); err != nil {
+		if err == store.ErrExternalTaken {
+			s.error(w, r, http.StatusConflict, errExternalLinkedToOther)
+			return
+		}
+
+		s.error(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	http.Redirect(w, r, s.config.PublicURL+st.Next, http.StatusFound)
+}
+
+// externalProvider returns the configured provider by the name in the path.
+// If there is none, it responds with 404 and returns false.
+func (s *server) externalProvider(w http.ResponseWriter, r *http.Request) (*ExternalProvider, bool) {
+	name := mux.Vars(r)["provider"]
+	for _, p := range s.config.ExternalProviders {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	s.error(w, r, http.StatusNotFound, errUnknownProvider)
+	return nil, false
+}
+
+// externalCallbackURL returns the address the provider sends the browser back to.
+func (s *server) externalCallbackURL(p *ExternalProvider) string {
+	return s.config.PublicURL + "/enter/oauth/" + p.Name + "/callback"
+}
+
+// externalLoginCookie returns the cookie that keeps the signed login state until the callback,
+// a negative maxAge expires it.
+func (s *server) externalLoginCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     externalLoginName,
+		Value:    value,
+		Path:     "/enter/oauth",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(s.config.PublicURL, "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// localPath returns the path if it stays on this site, otherwise the main page.
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/private/main"
+	}
+
+	return path
+}
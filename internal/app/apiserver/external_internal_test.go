@@ -0,0 +1,411 @@
+package apiserver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+// testExternalProvider is a fake OpenID Connect provider that logs in one account without asking.
+// It includes the following fields:
+// - server: serves the discovery document, the keys and the token endpoint.
+// - key: the key that signs ID tokens.
+// - subject, email, emailVerified: the account that logs in.
+// - nonce: the nonce put into the next ID token, it is taken from the authorization request unless the test sets another.
+// - challenge, code: the PKCE challenge and the code of the pending authorization.
+type testExternalProvider struct {
+	server        *httptest.Server
+	key           *rsa.PrivateKey
+	subject       string
+	email         string
+	emailVerified bool
+	nonce         string
+	challenge     string
+	code          string
+}
+
+// newTestExternalProvider starts a fake provider that is stopped when the test ends.
+func newTestExternalProvider(t *testing.T) *testExternalProvider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &testExternalProvider{
+		key:           key,
+		subject:       "110169484474386276334",
+		email:         "external@example.org",
+		emailVerified: true,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/auth",
+			"token_endpoint":                        p.server.URL + "/token",
+			"jwks_uri":                              p.server.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		k := &SigningKey{KID: "fake", privateKey: p.key}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []*jwk{k.jwk()}})
+	})
+	mux.HandleFunc("/token", p.handleToken(t))
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+// handleToken exchanges the code of the pending authorization for an ID token.
+func (p *testExternalProvider) handleToken(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, _ := r.BasicAuth()
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if clientID != "client" || secret != "secret" || p.code == "" || r.PostFormValue("code") != p.code ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != p.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		p.code = ""
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":            p.server.URL,
+			"sub":            p.subject,
+			"aud":            clientID,
+			"iat":            time.Now().Unix(),
+			"exp":            time.Now().Add(time.Minute).Unix(),
+			"nonce":          p.nonce,
+			"email":          p.email,
+			"email_verified": p.emailVerified,
+		})
+		token.Header["kid"] = "fake"
+		idToken, err := token.SignedString(p.key)
+		if err != nil {
+			t.Error(err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     idToken,
+		})
+	}
+}
+
+// authorize plays the user who logs in at the provider after the redirect to the location,
+// it returns the query the provider sends the browser back with.
+func (p *testExternalProvider) authorize(t *testing.T, location string) url.Values {
+	t.Helper()
+
+	u, err := url.Parse(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := u.Query()
+	assert.Equal(t, p.server.URL+"/auth", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "client", q.Get("client_id"))
+	assert.Equal(t, domainURL+"/enter/oauth/fake/callback", q.Get("redirect_uri"))
+	assert.Equal(t, "openid email", q.Get("scope"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+
+	p.nonce = q.Get("nonce")
+	p.challenge = q.Get("code_challenge")
+	p.code = generateToken()
+
+	return url.Values{"state": {q.Get("state")}, "code": {p.code}}
+}
+
+func TestServer_handleExternalLogin(t *testing.T) {
+	p := newTestExternalProvider(t)
+	st := teststore.New()
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testExternalConfig(t, p))
+
+	rec := testRequest(t, s, http.MethodGet, "/enter/oauth/unknown", nil, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = testExternalLogin(t, s, p, "/enter/oauth/fake", nil)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, domainURL+"/private/main", rec.Header().Get("Location"))
+
+	u, err := st.User().FindByExternalIdentity("fake", p.subject)
+	assert.NoError(t, err)
+	assert.Equal(t, p.email, u.Email.String)
+	assert.False(t, u.NeedsEmailVerification())
+
+	whoami := testRequest(t, s, http.MethodGet, "/private/whoami", nil, rec.Result().Cookies())
+	assert.Equal(t, http.StatusOK, whoami.Code)
+
+	rec = testExternalLogin(t, s, p, "/enter/oauth/fake?next="+url.QueryEscape("/authorize?client_id=app"), nil)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, domainURL+"/authorize?client_id=app", rec.Header().Get("Location"))
+
+	again, err := st.User().FindByExternalIdentity("fake", p.subject)
+	assert.NoError(t, err)
+	assert.Equal(t, u.ID, again.ID)
+
+	rec = testExternalLogin(t, s, p, "/enter/oauth/fake?next="+url.QueryEscape("//evil.example.org"), nil)
+	assert.Equal(t, domainURL+"/private/main", rec.Header().Get("Location"))
+
+	u.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	st.User().Update(u)
+	rec = testExternalLogin(t, s, p, "/enter/oauth/fake", nil)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestServer_handleExternalLoginEmail(t *testing.T) {
+	p := newTestExternalProvider(t)
+	st := teststore.New()
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testExternalConfig(t, p))
+
+	local := model.TestUser(t)
+	st.User().Create(local)
+
+	p.email = local.Email.String
+	rec := testExternalLogin(t, s, p, "/enter/oauth/fake", nil)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	p.emailVerified = false
+	rec = testExternalLogin(t, s, p, "/enter/oauth/fake", nil)
+	assert.Equal(t, http.StatusFound, rec.Code)
+
+	u, err := st.User().FindByExternalIdentity("fake", p.subject)
+	assert.NoError(t, err)
+	assert.NotEqual(t, local.ID, u.ID)
+	assert.False(t, u.Email.Valid)
+}
+
+func TestServer_handleExternalLoginTwoFactor(t *testing.T) {
+	p := newTestExternalProvider(t)
+	st := teststore.New()
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testExternalConfig(t, p))
+
+	rec := testExternalLogin(t, s, p, "/enter/oauth/fake", nil)
+	secret, _ := testEnableTwoFactor(t, s, rec.Result().Cookies())
+
+	rec = testExternalLogin(t, s, p, "/enter/oauth/fake?next=/private/export", nil)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	location, err := url.Parse(rec.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "/enter/login", location.Path)
+	assert.Equal(t, "/private/export", location.Query().Get("next"))
+	for _, c := range rec.Result().Cookies() {
+		assert.NotEqual(t, sessionName, c.Name)
+	}
+
+	code, _ := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+	rec = testRequest(t, s, http.MethodPost, "/sessions/2fa", map[string]string{
+		"challenge": location.Query().Get("challenge"),
+		"code":      code,
+	}, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, http.StatusOK, testRequest(t, s, http.MethodGet, "/private/whoami", nil, rec.Result().Cookies()).Code)
+}
+
+func TestServer_handleExternalCallback(t *testing.T) {
+	p := newTestExternalProvider(t)
+	st := teststore.New()
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testExternalConfig(t, p))
+
+	testCases := []struct {
+		name         string
+		callback     func(q url.Values, cookies []*http.Cookie) (url.Values, []*http.Cookie)
+		expectedCode int
+	}{
+		{
+			name: "no state cookie",
+			callback: func(q url.Values, cookies []*http.Cookie) (url.Values, []*http.Cookie) {
+				return q, nil
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "wrong state",
+			callback: func(q url.Values, cookies []*http.Cookie) (url.Values, []*http.Cookie) {
+				q.Set("state", "wrong")
+				return q, cookies
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "denied at provider",
+			callback: func(q url.Values, cookies []*http.Cookie) (url.Values, []*http.Cookie) {
+				q.Del("code")
+				q.Set("error", "access_denied")
+				return q, cookies
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "wrong code",
+			callback: func(q url.Values, cookies []*http.Cookie) (url.Values, []*http.Cookie) {
+				q.Set("code", "wrong")
+				return q, cookies
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "wrong nonce",
+			callback: func(q url.Values, cookies []*http.Cookie) (url.Values, []*http.Cookie) {
+				p.nonce = "wrong"
+				return q, cookies
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "valid",
+			callback: func(q url.Values, cookies []*http.Cookie) (url.Values, []*http.Cookie) {
+				return q, cookies
+			},
+			expectedCode: http.StatusFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, http.MethodGet, "/enter/oauth/fake", nil, nil)
+			assert.Equal(t, http.StatusFound, rec.Code)
+
+			q, cookies := tc.callback(p.authorize(t, rec.Header().Get("Location")), rec.Result().Cookies())
+			rec = testRequest(t, s, http.MethodGet, "/enter/oauth/fake/callback?"+q.Encode(), nil, cookies)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	_, err := st.User().FindByExternalIdentity("fake", p.subject)
+	assert.NoError(t, err)
+}
+
+func TestServer_handleExternalLink(t *testing.T) {
+	p := newTestExternalProvider(t)
+	st := teststore.New()
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testExternalConfig(t, p))
+
+	rec := testRequest(t, s, http.MethodGet, "/enter/oauth/fake?link=true", nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	u := model.TestUser(t)
+	st.User().Create(u)
+	cookies := testLogin(t, s, u)
+
+	rec = testExternalLogin(t, s, p, "/enter/oauth/fake?link=true&next=/private/export", cookies)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, domainURL+"/private/export", rec.Header().Get("Location"))
+
+	linked, err := st.User().FindByExternalIdentity("fake", p.subject)
+	assert.NoError(t, err)
+	assert.Equal(t, u.ID, linked.ID)
+
+	export := &accountExport{}
+	json.NewDecoder(testRequest(t, s, http.MethodGet, "/private/export", nil, cookies).Body).Decode(export)
+	assert.Contains(t, export.Identities, &identityInfo{Type: "external", Provider: "fake", ID: p.subject})
+
+	other := model.TestUser(t)
+	other.Email.String = "other@example.org"
+	st.User().Create(other)
+	rec = testExternalLogin(t, s, p, "/enter/oauth/fake?link=true", testLogin(t, s, other))
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestServer_handleExternalOnlyAccount(t *testing.T) {
+	p := newTestExternalProvider(t)
+	p.emailVerified = false
+	st := teststore.New()
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testExternalConfig(t, p))
+	admin := model.TestUser(t)
+	st.User().Create(admin)
+	st.Role().Assign(admin.ID, model.RoleAdmin)
+	adminCookies := testLogin(t, s, admin)
+
+	testExternalLogin(t, s, p, "/enter/oauth/fake", nil)
+	u, err := st.User().FindByExternalIdentity("fake", p.subject)
+	assert.NoError(t, err)
+	path := fmt.Sprintf("/admin/users/%d", u.ID)
+
+	assert.Equal(t, http.StatusOK, testRequest(t, s, http.MethodPost, path+"/disable", nil, adminCookies).Code)
+	assert.Equal(t, http.StatusOK, testRequest(t, s, http.MethodPost, path+"/enable", nil, adminCookies).Code)
+
+	cookies := testExternalLogin(t, s, p, "/enter/oauth/fake", nil).Result().Cookies()
+	rec := testRequest(t, s, http.MethodDelete, "/private/account", nil, cookies)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	_, recoveryCodes := testEnableTwoFactor(t, s, cookies)
+	rec = testRequest(t, s, http.MethodDelete, "/private/account", nil, cookies)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = testRequest(t, s, http.MethodDelete, "/private/account", map[string]string{"recovery_code": recoveryCodes[0]}, cookies)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	u, _ = st.User().Find(u.ID)
+	assert.True(t, u.DeletionScheduledAt.Valid)
+	assert.Equal(t, http.StatusUnauthorized, testRequest(t, s, http.MethodGet, "/private/whoami", nil, cookies).Code)
+
+	location, err := url.Parse(testExternalLogin(t, s, p, "/enter/oauth/fake", nil).Header().Get("Location"))
+	assert.NoError(t, err)
+	rec = testRequest(t, s, http.MethodPost, "/sessions/2fa", map[string]string{
+		"challenge":     location.Query().Get("challenge"),
+		"recovery_code": recoveryCodes[1],
+	}, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	u, _ = st.User().Find(u.ID)
+	assert.False(t, u.DeletionScheduledAt.Valid)
+}
+
+// testExternalConfig returns a config with the fake provider named "fake".
+func testExternalConfig(t *testing.T, p *testExternalProvider) *Config {
+	t.Helper()
+
+	config := testTwoFactorConfig(t)
+	config.ExternalProviders = []*ExternalProvider{
+		{
+			Name:         "fake",
+			Issuer:       p.server.URL,
+			ClientID:     "client",
+			ClientSecret: "secret",
+			Scopes:       []string{"email"},
+		},
+	}
+
+	return config
+}
+
+// testExternalLogin goes through the login at the fake provider starting at the path with the cookies
+// and returns the response to the callback.
+func testExternalLogin(t *testing.T, s *server, p *testExternalProvider, path string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := testRequest(t, s, http.MethodGet, path, nil, cookies)
+	if rec.Code != http.StatusFound {
+		t.Fatalf("external login failed with %d", rec.Code)
+	}
+
+	q := p.authorize(t, rec.Header().Get("Location"))
+	return testRequest(t, s, http.MethodGet, "/enter/oauth/fake/callback?"+q.Encode(), nil, append(cookies, rec.Result().Cookies()...))
+}
@@ -0,0 +1,174 @@
+package apiserver
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/telegram"
+)
+
+var (
+	errTelegramAlreadyLinked = errors.New("another telegram account is already linked")
+	errEmailAlreadyLinked    = errors.New("an email is already linked")
+)
+
+// handleIdentitiesLinkTelegram attaches the Telegram account verified by the Login Widget data to the current user.
+func (s *server) handleIdentitiesLinkTelegram() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := &telegram.LoginData{}
+		if err := json.NewDecoder(r.Body).Decode(data); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := data.Verify(s.config.TelegramBotToken, s.config.TelegramAuthMaxAge, time.Now()); err != nil {
+			if err == telegram.ErrNoBotToken {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.error(w, r, http.StatusUnauthorized, err)
+			return
+		}
+
+		u := *r.Context().Value(ctxKeyUser).(*model.User)
+		if u.IDTelegram.Valid && u.IDTelegram.Int64 != data.ID {
+			s.error(w, r, http.StatusConflict, errTelegramAlreadyLinked)
+			return
+		}
+
+		u.IDTelegram = sql.NullInt64{Int64: data.ID, Valid: true}
+		setTelegramProfile(&u, data.User())
+		if err := s.store.User().LinkTelegram(&u); err != nil {
+			s.identityError(w, r, err, http.StatusInternalServerError)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &u)
+	}
+}
+
+// handleIdentitiesUnlinkTelegram detaches the Telegram account from the current user.
+// It requires the password or, for the user without a password, the second factor if it is enabled.
+func (s *server) handleIdentitiesUnlinkTelegram() http.HandlerFunc {
+	type request struct {
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		if !s.reauthenticate(w, r, u, req.Password, req.Code, req.RecoveryCode) {
+			return
+		}
+
+		if err := s.store.User().UnlinkTelegram(u.ID); err != nil {
+			s.identityError(w, r, err, http.StatusInternalServerError)
+			return
+		}
+
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// handleIdentitiesLinkEmail adds email and password to the current user that logs in only with Telegram.
+func (s *server) handleIdentitiesLinkEmail() http.HandlerFunc {
+	type request struct {
+		Email           string `json:"email"`
+		Password        string `json:"password"`
+		ConfirmPassword string `json:"confirm_password"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := *r.Context().Value(ctxKeyUser).(*model.User)
+		if u.Email.Valid {
+			s.error(w, r, http.StatusConflict, errEmailAlreadyLinked)
+			return
+		}
+
+		if req.ConfirmPassword != req.Password {
+			s.error(w, r, http.StatusBadRequest, errConfirmPasswordIsRequired)
+			return
+		}
+
+		if !s.checkPassword(w, r, req.Password, req.Email) {
+			return
+		}
+
+		u.Email = sql.NullString{String: req.Email, Valid: req.Email != ""}
+		u.Password = req.Password
+		u.PasswordHasher = s.passwordHasher
+		if err := s.store.User().LinkEmail(&u); err != nil {
+			s.identityError(w, r, err, http.StatusUnprocessableEntity)
+			return
+		}
+
+		u.EmailVerifiedAt = sql.NullTime{}
+		s.sendEmailVerificationOrLog(r, &u)
+
+		u.Sanitize()
+		s.respond(w, r, http.StatusOK, &u)
+	}
+}
+
+// handleIdentitiesUnlinkEmail removes email and password from the current user.
+// It requires the password or, for the user without a password, the second factor if it is enabled.
+func (s *server) handleIdentitiesUnlinkEmail() http.HandlerFunc {
+	type request struct {
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		if !s.reauthenticate(w, r, u, req.Password, req.Code, req.RecoveryCode) {
+			return
+		}
+
+		if err := s.store.User().UnlinkEmail(u.ID); err != nil {
+			s.identityError(w, r, err, http.StatusInternalServerError)
+			return
+		}
+
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// identityError responds with the status that matches the error of linking or unlinking an identity,
+// other errors are responded with the given code.
+func (s *server) identityError(w http.ResponseWriter, r *http.Request, err error, code int) {
+	switch err {
+	case store.ErrTelegramTaken, store.ErrEmailTaken, store.ErrLastLoginMethod:
+		s.error(w, r, http.StatusConflict, err)
+	case store.ErrRecordNotFound:
+		s.error(w, r, http.StatusNotFound, err)
+	default:
+		s.error(w, r, code, err)
+	}
+}
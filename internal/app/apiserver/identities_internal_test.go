@@ -0,0 +1,303 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/http-rest-API/internal/app/telegram"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+const testBotToken = "123456:test-bot-token"
+
+func TestServer_handleIdentitiesLinkTelegram(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	other := model.TestUserWithTelegram(t)
+	other.IDTelegram.Int64 = 87654321
+	store.User().Create(other)
+	config := NewConfig()
+	config.TelegramBotToken = testBotToken
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), config)
+	cookies := testLogin(t, s, u)
+	now := time.Now()
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name: "tampered",
+			payload: func() *telegram.LoginData {
+				d := telegram.TestLoginData(t, testBotToken, 12345678, now)
+				d.ID = 11111111
+
+				return d
+			}(),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "linked to another user",
+			payload:      telegram.TestLoginData(t, testBotToken, 87654321, now),
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "valid",
+			payload:      telegram.TestLoginData(t, testBotToken, 12345678, now),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "same account again",
+			payload:      telegram.TestLoginData(t, testBotToken, 12345678, now),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "another account",
+			payload:      telegram.TestLoginData(t, testBotToken, 11111111, now),
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "invalid payload",
+			payload:      "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPost, "/private/identities/telegram", b)
+			for _, c := range cookies {
+				req.AddCookie(c)
+			}
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	linked, err := store.User().FindByIDTelegram(12345678)
+	assert.NoError(t, err)
+	assert.Equal(t, u.ID, linked.ID)
+	assert.Equal(t, "john", linked.TelegramUsername.String)
+
+	rec := httptest.NewRecorder()
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(telegram.TestLoginData(t, testBotToken, 12345678, now))
+	req, _ := http.NewRequest(http.MethodPost, "/telegram/check", b)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	_, err = store.User().FindByIDTelegram(11111111)
+	assert.Error(t, err)
+}
+
+func TestServer_handleIdentitiesLinkEmail(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUserWithTelegram(t)
+	store.User().Create(u)
+	other := model.TestUser(t)
+	store.User().Create(other)
+	config := NewConfig()
+	config.TelegramBotToken = testBotToken
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), config)
+	cookies := testTelegramLogin(t, s, u.IDTelegram.Int64)
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name: "confirm password mismatch",
+			payload: map[string]string{
+				"email":            "telegram@example.org",
+				"password":         "Password123",
+				"confirm_password": "Password321",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "easy password",
+			payload: map[string]string{
+				"email":            "telegram@example.org",
+				"password":         "password",
+				"confirm_password": "password",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "invalid email",
+			payload: map[string]string{
+				"email":            "invalid",
+				"password":         "Password123",
+				"confirm_password": "Password123",
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "email of another user",
+			payload: map[string]string{
+				"email":            other.Email.String,
+				"password":         "Password123",
+				"confirm_password": "Password123",
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name: "valid",
+			payload: map[string]string{
+				"email":            "telegram@example.org",
+				"password":         "Password123",
+				"confirm_password": "Password123",
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "already linked",
+			payload: map[string]string{
+				"email":            "another@example.org",
+				"password":         "Password123",
+				"confirm_password": "Password123",
+			},
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPost, "/private/identities/email", b)
+			for _, c := range cookies {
+				req.AddCookie(c)
+			}
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	testLogin(t, s, &model.User{
+		Email:    u.Email,
+		Password: "Password123",
+	})
+}
+
+func TestServer_handleIdentitiesUnlink(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	config := NewConfig()
+	config.TelegramBotToken = testBotToken
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), config)
+	cookies := testLogin(t, s, u)
+
+	unlink := func(identity string, password string) int {
+		return testRequest(t, s, http.MethodDelete, "/private/identities/"+identity, map[string]string{"password": password}, cookies).Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, unlink("telegram", ""))
+	assert.Equal(t, http.StatusForbidden, unlink("email", "wrong password"))
+	assert.Equal(t, http.StatusConflict, unlink("email", u.Password))
+	assert.Equal(t, http.StatusNoContent, unlink("telegram", u.Password))
+
+	rec := httptest.NewRecorder()
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(telegram.TestLoginData(t, testBotToken, 12345678, time.Now()))
+	req, _ := http.NewRequest(http.MethodPost, "/private/identities/telegram", b)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, http.StatusNoContent, unlink("email", u.Password))
+	assert.Equal(t, http.StatusConflict, unlink("telegram", ""))
+
+	linked, err := store.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.False(t, linked.Email.Valid)
+	assert.True(t, linked.IDTelegram.Valid)
+}
+
+func TestServer_handleIdentitiesUnlinkTwoFactor(t *testing.T) {
+	store := teststore.New()
+	config := testTwoFactorConfig(t)
+	config.TelegramBotToken = testBotToken
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), config)
+	cookies := testTelegramLogin(t, s, 12345678)
+	secret, recoveryCodes := testEnableTwoFactor(t, s, cookies)
+
+	code, err := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+	assert.NoError(t, err)
+
+	// The Telegram account is the only login method, so a confirmed unlink is refused with 409.
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name:         "no second factor",
+			payload:      nil,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name: "invalid recovery code",
+			payload: map[string]string{
+				"recovery_code": "00000-00000",
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name: "valid code",
+			payload: map[string]string{
+				"code": code,
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name: "valid recovery code",
+			payload: map[string]string{
+				"recovery_code": recoveryCodes[0],
+			},
+			expectedCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, http.MethodDelete, "/private/identities/telegram", tc.payload, cookies)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+// testTelegramLogin logs in with the Telegram account and returns the session cookies.
+func testTelegramLogin(t *testing.T, s *server, id int64) []*http.Cookie {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(telegram.TestLoginData(t, s.config.TelegramBotToken, id, time.Now()))
+	req, _ := http.NewRequest(http.MethodPost, "/telegram/check", b)
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("telegram login failed with %d", rec.Code)
+	}
+
+	return rec.Result().Cookies()
+}
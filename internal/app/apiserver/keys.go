@@ -0,0 +1,104 @@
+package apiserver
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+var (
+	errInvalidPrivateKey = errors.New("private key is not a PEM encoded RSA key")
+)
+
+// SigningKey is an RSA key for signing access tokens.
+// It includes the following fields:
+// - KID: the key id that is put into the "kid" header of tokens signed by this key.
+// - PrivateKeyFile: the path to the PEM encoded private key.
+type SigningKey struct {
+	KID            string `toml:"kid"`
+	PrivateKeyFile string `toml:"private_key_file"`
+	privateKey     *rsa.PrivateKey
+}
+
+// jwk is a public key in the JSON Web Key format.
+type jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// load reads the private key from PrivateKeyFile.
+func (k *SigningKey) load() error {
+	b, err := os.ReadFile(k.PrivateKeyFile)
+	if err != nil {
+		return err
+	}
+
+	key, err := parsePrivateKey(b)
+	if err != nil {
+		return fmt.Errorf("%s: %w", k.PrivateKeyFile, err)
+	}
+
+	k.privateKey = key
+	return nil
+}
+
+// jwk returns the public part of the key in the JSON Web Key format.
+func (k *SigningKey) jwk() *jwk {
+	pub := k.privateKey.PublicKey
+	return &jwk{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: k.KID,
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}
+}
+
+// loadSigningKeys reads the private keys of all configured signing keys.
+func loadSigningKeys(keys []*SigningKey) error {
+	for _, k := range keys {
+		if k.KID == "" {
+			return errors.New("signing key without kid")
+		}
+
+		if err := k.load(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parsePrivateKey parses a PEM encoded RSA private key in PKCS #1 or PKCS #8 form.
+func parsePrivateKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errInvalidPrivateKey
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errInvalidPrivateKey
+	}
+
+	return rsaKey, nil
+}
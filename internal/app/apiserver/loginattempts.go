@@ -0,0 +1,113 @@
+package apiserver
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errTooManyLoginAttempts = errors.New("too many failed login attempts, try again later")
+)
+
+// loginAttemptLimit is the key the failed password guesses are counted for,
+// the delay after the first failure and the number of failures that locks the key.
+type loginAttemptLimit struct {
+	key       string
+	backoff   time.Duration
+	threshold int
+}
+
+// loginAttemptLimits returns the limits of the failed guesses for the account and for the client address.
+// The account is the user, nil if there is no user with the email, then the guesses are counted for the email.
+// The client address is only locked and not slowed down, since many users may share it.
+func (s *server) loginAttemptLimits(r *http.Request, email string, u *model.User) []loginAttemptLimit {
+	return []loginAttemptLimit{
+		{
+			key:       loginAccountKey(email, u),
+			backoff:   s.config.LoginBackoff,
+			threshold: s.config.LoginLockoutThreshold,
+		},
+		{
+			key:       "ip:" + clientIP(r),
+			threshold: s.config.LoginIPLockoutThreshold,
+		},
+	}
+}
+
+// loginAccountKey returns the key the failed guesses for the account are counted for.
+// It is the id of the user, since users that log in without an email have none,
+// and the email for an unknown user, so that guessing an unknown email looks the same.
+func loginAccountKey(email string, u *model.User) string {
+	if u != nil {
+		return "user:" + strconv.Itoa(u.ID)
+	}
+
+	return "email:" + strings.ToLower(email)
+}
+
+// checkLoginAttempts responds with 429 and returns false if the account or the client address
+// has to wait before the next guess because of the previous failures.
+func (s *server) checkLoginAttempts(w http.ResponseWriter, r *http.Request, email string, u *model.User) bool {
+	var lockedUntil time.Time
+	for _, l := range s.loginAttemptLimits(r, email, u) {
+		a, err := s.store.LoginAttempt().Find(l.key)
+		if err != nil {
+			if err == store.ErrRecordNotFound {
+				continue
+			}
+
+			s.error(w, r, http.StatusInternalServerError, err)
+			return false
+		}
+
+		if until := a.LockedUntil(l.backoff, l.threshold, s.config.LoginLockoutDuration); until.After(lockedUntil) {
+			lockedUntil = until
+		}
+	}
+
+	if wait := time.Until(lockedUntil); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		s.error(w, r, http.StatusTooManyRequests, errTooManyLoginAttempts)
+		return false
+	}
+
+	return true
+}
+
+// failLogin records a failed guess for the account and for the client address,
+// audits it for the user, nil if there is no user with the email, and responds with 401 and the given error.
+func (s *server) failLogin(w http.ResponseWriter, r *http.Request, email string, u *model.User, err error) {
+	userID := 0
+	if u != nil {
+		userID = u.ID
+	}
+	s.audit(r, model.AuditLoginFailed, userID, 0)
+
+	now := time.Now()
+	for _, l := range s.loginAttemptLimits(r, email, u) {
+		if _, err := s.store.LoginAttempt().Fail(l.key, now, s.config.LoginLockoutDuration); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	s.error(w, r, http.StatusUnauthorized, err)
+}
+
+// resetLoginAttempts forgets the failed guesses for the account of the user after a successful login.
+// The failures of the client address are kept, so that one known password does not unlock guessing the others.
+func (s *server) resetLoginAttempts(r *http.Request, u *model.User) {
+	if err := s.store.LoginAttempt().Reset(loginAccountKey("", u)); err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"request_id": r.Context().Value(ctxKeyRequestID),
+		}).Errorf("failed to reset login attempts: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/http-rest-API/internal/app/telegram"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_LoginBackoff(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	config := NewConfig()
+	config.LoginBackoff = time.Minute
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), config)
+
+	login := func(password string) *http.Response {
+		return testRequest(t, s, http.MethodPost, "/sessions", map[string]string{
+			"email":    u.Email.String,
+			"password": password,
+		}, nil).Result()
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login("invalid").StatusCode)
+
+	res := login(u.Password)
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "60", res.Header.Get("Retry-After"))
+
+	rec := testRequest(t, s, http.MethodPost, "/tokens", map[string]string{
+		"email":    u.Email.String,
+		"password": u.Password,
+	}, nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
+
+func TestServer_LoginLockout(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	other := model.TestUser(t)
+	other.Email.String = "other@example.org"
+	store.User().Create(other)
+	config := NewConfig()
+	config.LoginBackoff = 0
+	config.LoginLockoutThreshold = 3
+	config.LoginIPLockoutThreshold = 5
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), config)
+
+	login := func(email string, password string) *http.Response {
+		return testRequest(t, s, http.MethodPost, "/sessions", map[string]string{
+			"email":    email,
+			"password": password,
+		}, nil).Result()
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, login(u.Email.String, "invalid").StatusCode)
+	assert.Equal(t, http.StatusOK, login(u.Email.String, u.Password).StatusCode)
+
+	for i := 0; i < config.LoginLockoutThreshold; i++ {
+		assert.Equal(t, http.StatusUnauthorized, login(u.Email.String, "invalid").StatusCode)
+	}
+
+	res := login(u.Email.String, u.Password)
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "900", res.Header.Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, login(other.Email.String, other.Password).StatusCode)
+
+	assert.Equal(t, http.StatusUnauthorized, login("unknown@example.org", "invalid").StatusCode)
+	res = login(other.Email.String, other.Password)
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "900", res.Header.Get("Retry-After"))
+}
+
+func TestServer_LoginLockoutWithoutEmail(t *testing.T) {
+	config := testTwoFactorConfig(t)
+	config.TelegramBotToken = testBotToken
+	config.TelegramAuthMaxAge = time.Hour
+	config.LoginLockoutThreshold = 2
+	s := newServer(teststore.New(), sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), config)
+
+	challenge := func(id int64) string {
+		rec := testRequest(t, s, http.MethodPost, "/telegram/webapp", map[string]string{
+			"init_data": telegram.TestWebAppInitData(t, testBotToken, id, time.Now()),
+		}, nil)
+		res := map[string]string{}
+		json.NewDecoder(rec.Body).Decode(&res)
+
+		return res["challenge"]
+	}
+
+	_, lockedCodes := testEnableTwoFactor(t, s, testTelegramLogin(t, s, 11111111))
+	secret, _ := testEnableTwoFactor(t, s, testTelegramLogin(t, s, 22222222))
+
+	locked := challenge(11111111)
+	for i := 0; i < config.LoginLockoutThreshold; i++ {
+		rec := testRequest(t, s, http.MethodPost, "/sessions/2fa", map[string]string{"challenge": locked, "code": "000000"}, nil)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+
+	rec := testRequest(t, s, http.MethodPost, "/sessions/2fa", map[string]string{"challenge": locked, "recovery_code": lockedCodes[0]}, nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	code, _ := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+	rec = testRequest(t, s, http.MethodPost, "/sessions/2fa", map[string]string{"challenge": challenge(22222222), "code": code}, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = testRequest(t, s, http.MethodPost, "/sessions/2fa", map[string]string{"challenge": challenge(11111111), "recovery_code": lockedCodes[0]}, nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
@@ -0,0 +1,672 @@
+package apiserver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+const (
+	oauthConsentName = "oauth_consent"
+	idTokenType      = "JWT"
+)
+
+var (
+	errUnknownClient           = errors.New("unknown client_id")
+	errInvalidRedirectURI      = errors.New("redirect_uri is not registered for the client")
+	errUnsupportedResponseType = errors.New("response_type must be code")
+	errOpenIDScopeRequired     = errors.New("scope must include openid")
+	errUnsupportedScope        = errors.New("scope may only include openid, profile and email")
+	errPKCERequired            = errors.New("code_challenge with code_challenge_method S256 is required")
+	errLoginRequired           = errors.New("the user is not logged in")
+	errConsentRequired         = errors.New("the user has not authorized the client")
+	errAccessDenied            = errors.New("the user denied the authorization")
+	errInvalidConsentRequest   = errors.New("invalid or expired consent request")
+	errInvalidClient           = errors.New("invalid client credentials")
+	errUnsupportedGrantType    = errors.New("grant_type must be authorization_code")
+	errInvalidCode             = errors.New("invalid, expired or used authorization code")
+)
+
+// supportedScopes are the scopes that OAuth clients can ask for.
+var supportedScopes = []string{model.ScopeOpenID, model.ScopeProfile, model.ScopeEmail}
+
+// authorizationRequest is a validated request of an OAuth client to be authorized by the user.
+// While the user decides, it is signed into the consent request, UserID, ID and ExpiresAt are only set then.
+type authorizationRequest struct {
+	ClientID      string
+	RedirectURI   string
+	Scopes        []string
+	State         string
+	Nonce         string
+	CodeChallenge string
+	UserID        int
+	ID            string
+	ExpiresAt     int64
+}
+
+// oauthAccessClaims are the claims of an access token.
+// ClientID and Scope are only set in the tokens issued to OAuth clients, which work only at /userinfo.
+type oauthAccessClaims struct {
+	jwt.RegisteredClaims
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+}
+
+// userClaims are the claims about the user that the scopes release to an OAuth client.
+type userClaims struct {
+	Email             string `json:"email,omitempty"`
+	EmailVerified     *bool  `json:"email_verified,omitempty"`
+	Name              string `json:"name,omitempty"`
+	GivenName         string `json:"given_name,omitempty"`
+	FamilyName        string `json:"family_name,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+	Locale            string `json:"locale,omitempty"`
+}
+
+// idTokenClaims are the claims of an ID token.
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce string `json:"nonce,omitempty"`
+	userClaims
+}
+
+// handleOpenIDConfiguration publishes the OpenID Connect discovery document.
+func (s *server) handleOpenIDConfiguration() http.HandlerFunc {
+	type response struct {
+		Issuer                            string   `json:"issuer"`
+		AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+		TokenEndpoint                     string   `json:"token_endpoint"`
+		UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+		JWKSURI                           string   `json:"jwks_uri"`
+		ScopesSupported                   []string `json:"scopes_supported"`
+		ResponseTypesSupported            []string `json:"response_types_supported"`
+		GrantTypesSupported               []string `json:"grant_types_supported"`
+		SubjectTypesSupported             []string `json:"subject_types_supported"`
+		IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+		TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+		CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+		ClaimsSupported                   []string `json:"claims_supported"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respond(w, r, http.StatusOK, &response{
+			Issuer:                            s.config.TokenIssuer,
+			AuthorizationEndpoint:             s.config.PublicURL + "/authorize",
+			TokenEndpoint:                     s.config.PublicURL + "/token",
+			UserinfoEndpoint:                  s.config.PublicURL + "/userinfo",
+			JWKSURI:                           s.config.PublicURL + "/.well-known/jwks.json",
+			ScopesSupported:                   supportedScopes,
+			ResponseTypesSupported:            []string{"code"},
+			GrantTypesSupported:               []string{"authorization_code"},
+			SubjectTypesSupported:             []string{"public"},
+			IDTokenSigningAlgValuesSupported:  []string{jwt.SigningMethodRS256.Alg()},
+			TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+			CodeChallengeMethodsSupported:     []string{"S256"},
+			ClaimsSupported: []string{
+				"sub", "email", "email_verified", "name", "given_name", "family_name", "preferred_username", "picture", "locale",
+			},
+		})
+	}
+}
+
+// handleAuthorize starts the authorization code flow of an OAuth client.
+// A user who is not logged in is sent to the login page, which comes back here after the login.
+// The code is sent to the client at once if the user already agreed to the scopes, otherwise the user is sent to the consent page.
+// The "prompt" parameter "none" fails instead of showing a page and "consent" shows the consent page in any case.
+func (s *server) handleAuthorize() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, client, ok := s.parseAuthorizationRequest(w, r, r.URL.Query())
+		if !ok {
+			return
+		}
+
+		prompt := r.URL.Query().Get("prompt")
+		u, err := s.userFromSession(r)
+		if err != nil {
+			if err != errNotAuthenticated {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			if prompt == "none" {
+				s.redirectAuthorizationError(w, r, req, "login_required", errLoginRequired)
+				return
+			}
+
+			http.Redirect(w, r, s.config.PublicURL+"/enter/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+			return
+		}
+
+		if u.IsDisabled() {
+			s.redirectAuthorizationError(w, r, req, "access_denied", errAccountDisabled)
+			return
+		}
+
+		grant, err := s.store.OAuthGrant().Find(u.ID, client.ClientID)
+		if err != nil && err != store.ErrRecordNotFound {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if grant != nil && grant.Covers(req.Scopes) && prompt != "consent" {
+			redirectTo, err := s.issueAuthorizationCode(req, u.ID)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			http.Redirect(w, r, redirectTo, http.StatusFound)
+			return
+		}
+
+		if prompt == "none" {
+			s.redirectAuthorizationError(w, r, req, "consent_required", errConsentRequired)
+			return
+		}
+
+		req.UserID = u.ID
+		req.ID = uuid.New().String()
+		req.ExpiresAt = time.Now().Add(s.config.OAuthConsentTTL).Unix()
+		consent, err := s.signer.Encode(oauthConsentName, req)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		http.Redirect(w, r, s.config.PublicURL+"/enter/consent?request="+url.QueryEscape(consent), http.StatusFound)
+	}
+}
+
+// handleAuthorizeConsentInfo responds with the name of the OAuth client and the scopes it asks for,
+// so that the consent page can show them to the user.
+func (s *server) handleAuthorizeConsentInfo() http.HandlerFunc {
+	type response struct {
+		ClientName string   `json:"client_name"`
+		Scopes     []string `json:"scopes"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, client, ok := s.consentRequest(w, r, r.URL.Query().Get("request"))
+		if !ok {
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{
+			ClientName: client.Name,
+			Scopes:     req.Scopes,
+		})
+	}
+}
+
+// handleAuthorizeConsent records the answer of the user on the consent page
+// and responds with the address the browser goes to next: the redirect URI of the client
+// with the authorization code if the user approved, or with the access_denied error if not.
+func (s *server) handleAuthorizeConsent() http.HandlerFunc {
+	type request struct {
+		Request string `json:"request"`
+		Approve bool   `json:"approve"`
+	}
+
+	type response struct {
+		RedirectTo string `json:"redirect_to"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		body := &request{}
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		req, client, ok := s.consentRequest(w, r, body.Request)
+		if !ok {
+			return
+		}
+
+		if err := s.store.Revocation().Revoke(req.ID, time.Unix(req.ExpiresAt, 0)); err != nil {
+			if err == store.ErrRecordNotFound {
+				s.error(w, r, http.StatusBadRequest, errInvalidConsentRequest)
+				return
+			}
+
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !body.Approve {
+			s.respond(w, r, http.StatusOK, &response{
+				RedirectTo: authorizationRedirect(req, url.Values{
+					"error":             {"access_denied"},
+					"error_description": {errAccessDenied.Error()},
+				}),
+			})
+			return
+		}
+
+		grant, err := s.store.OAuthGrant().Find(req.UserID, client.ClientID)
+		if err != nil {
+			if err != store.ErrRecordNotFound {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			grant = &model.OAuthGrant{
+				UserID:    req.UserID,
+				ClientID:  client.ClientID,
+				CreatedAt: time.Now(),
+			}
+		}
+
+		for _, scope := range req.Scopes {
+			if !hasScope(grant.Scopes, scope) {
+				grant.Scopes = append(grant.Scopes, scope)
+			}
+		}
+
+		if err := s.store.OAuthGrant().Save(grant); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		redirectTo, err := s.issueAuthorizationCode(req, req.UserID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{RedirectTo: redirectTo})
+	}
+}
+
+// handleOAuthToken exchanges an authorization code and its PKCE code verifier for an access token and an ID token.
+// The request is form encoded as OAuth 2.0 requires, a confidential client authenticates with its secret
+// in the Authorization header or in the form.
+func (s *server) handleOAuthToken() http.HandlerFunc {
+	type response struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int    `json:"expires_in"`
+		IDToken     string `json:"id_token"`
+		Scope       string `json:"scope"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		if err := r.ParseForm(); err != nil {
+			s.oauthError(w, r, http.StatusBadRequest, "invalid_request", err)
+			return
+		}
+
+		client, ok := s.authenticateClient(w, r)
+		if !ok {
+			return
+		}
+
+		if r.PostForm.Get("grant_type") != "authorization_code" {
+			s.oauthError(w, r, http.StatusBadRequest, "unsupported_grant_type", errUnsupportedGrantType)
+			return
+		}
+
+		code, err := s.store.OAuthCode().Consume(hashToken(r.PostForm.Get("code")))
+		if err != nil {
+			if err == store.ErrRecordNotFound {
+				s.oauthError(w, r, http.StatusBadRequest, "invalid_grant", errInvalidCode)
+				return
+			}
+
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		redirectURI := r.PostForm.Get("redirect_uri")
+		if code.ClientID != client.ClientID ||
+			(redirectURI != "" && redirectURI != code.RedirectURI) ||
+			code.IsExpired(time.Now()) ||
+			!code.VerifyCodeVerifier(r.PostForm.Get("code_verifier")) {
+			s.oauthError(w, r, http.StatusBadRequest, "invalid_grant", errInvalidCode)
+			return
+		}
+
+		u, err := s.store.User().Find(code.UserID)
+		if err != nil {
+			s.oauthError(w, r, http.StatusBadRequest, "invalid_grant", errInvalidCode)
+			return
+		}
+
+		if u.IsDisabled() {
+			s.oauthError(w, r, http.StatusBadRequest, "invalid_grant", errAccountDisabled)
+			return
+		}
+
+		accessToken, err := s.issueOAuthAccessToken(u, client.ClientID, code.Scopes)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		idToken, err := s.issueIDToken(u, client.ClientID, code.Nonce, code.Scopes)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{
+			AccessToken: accessToken,
+			TokenType:   "Bearer",
+			ExpiresIn:   int(s.config.AccessTokenTTL.Seconds()),
+			IDToken:     idToken,
+			Scope:       strings.Join(code.Scopes, " "),
+		})
+	}
+}
+
+// handleUserinfo responds with the claims about the user that the scopes of the access token of an OAuth client release.
+func (s *server) handleUserinfo() http.HandlerFunc {
+	type response struct {
+		Subject string `json:"sub"`
+		userClaims
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims := &oauthAccessClaims{}
+		token, ok := bearerToken(r)
+		if ok {
+			if _, err := s.parseToken(token, accessTokenType, claims); err != nil {
+				ok = false
+			}
+		}
+
+		var u *model.User
+		if ok && claims.ClientID != "" {
+			if id, err := strconv.Atoi(claims.Subject); err == nil {
+				u, _ = s.store.User().Find(id)
+			}
+		}
+
+		if u == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			s.error(w, r, http.StatusUnauthorized, errNotAuthenticated)
+			return
+		}
+
+		if u.IsDisabled() {
+			s.error(w, r, http.StatusForbidden, errAccountDisabled)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{
+			Subject:    claims.Subject,
+			userClaims: newUserClaims(u, strings.Fields(claims.Scope)),
+		})
+	}
+}
+
+// parseAuthorizationRequest validates the parameters of the authorization request of an OAuth client.
+// An unknown client or redirect URI is reported to the browser, the other errors are sent to the client at the redirect URI.
+// It returns false if it has responded with an error.
+func (s *server) parseAuthorizationRequest(w http.ResponseWriter, r *http.Request, q url.Values) (*authorizationRequest, *model.OAuthClient, bool) {
+	client, err := s.store.OAuthClient().FindByClientID(q.Get("client_id"))
+	if err != nil {
+		if err == store.ErrRecordNotFound {
+			s.error(w, r, http.StatusBadRequest, errUnknownClient)
+			return nil, nil, false
+		}
+
+		s.error(w, r, http.StatusInternalServerError, err)
+		return nil, nil, false
+	}
+
+	redirectURI := q.Get("redirect_uri")
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+
+	if !client.HasRedirectURI(redirectURI) {
+		s.error(w, r, http.StatusBadRequest, errInvalidRedirectURI)
+		return nil, nil, false
+	}
+
+	req := &authorizationRequest{
+		ClientID:      client.ClientID,
+		RedirectURI:   redirectURI,
+		Scopes:        strings.Fields(q.Get("scope")),
+		State:         q.Get("state"),
+		Nonce:         q.Get("nonce"),
+		CodeChallenge: q.Get("code_challenge"),
+	}
+
+	if q.Get("response_type") != "code" {
+		s.redirectAuthorizationError(w, r, req, "unsupported_response_type", errUnsupportedResponseType)
+		return nil, nil, false
+	}
+
+	if !hasScope(req.Scopes, model.ScopeOpenID) {
+		s.redirectAuthorizationError(w, r, req, "invalid_scope", errOpenIDScopeRequired)
+		return nil, nil, false
+	}
+
+	for _, scope := range req.Scopes {
+		if !hasScope(supportedScopes, scope) {
+			s.redirectAuthorizationError(w, r, req, "invalid_scope", errUnsupportedScope)
+			return nil, nil, false
+		}
+	}
+
+	if req.CodeChallenge == "" || q.Get("code_challenge_method") != "S256" {
+		s.redirectAuthorizationError(w, r, req, "invalid_request", errPKCERequired)
+		return nil, nil, false
+	}
+
+	return req, client, true
+}
+
+// consentRequest verifies the consent request that the consent page sends back
+// and that it was made for the user of the current session.
+// It returns false if it has responded with an error.
+func (s *server) consentRequest(w http.ResponseWriter, r *http.Request, token string) (*authorizationRequest, *model.OAuthClient, bool) {
+	req := &authorizationRequest{}
+	if err := s.signer.Decode(oauthConsentName, token, req); err != nil || time.Now().Unix() >= req.ExpiresAt {
+		s.error(w, r, http.StatusBadRequest, errInvalidConsentRequest)
+		return nil, nil, false
+	}
+
+	revoked, err := s.store.Revocation().IsRevoked(req.ID)
+	if err != nil {
+		s.error(w, r, http.StatusInternalServerError, err)
+		return nil, nil, false
+	}
+
+	if revoked {
+		s.error(w, r, http.StatusBadRequest, errInvalidConsentRequest)
+		return nil, nil, false
+	}
+
+	u, err := s.userFromSession(r)
+	if err != nil {
+		if err == errNotAuthenticated {
+			s.error(w, r, http.StatusUnauthorized, err)
+			return nil, nil, false
+		}
+
+		s.error(w, r, http.StatusInternalServerError, err)
+		return nil, nil, false
+	}
+
+	if u.ID != req.UserID {
+		s.error(w, r, http.StatusForbidden, errInvalidConsentRequest)
+		return nil, nil, false
+	}
+
+	if u.IsDisabled() {
+		s.error(w, r, http.StatusForbidden, errAccountDisabled)
+		return nil, nil, false
+	}
+
+	client, err := s.store.OAuthClient().FindByClientID(req.ClientID)
+	if err != nil {
+		if err == store.ErrRecordNotFound {
+			s.error(w, r, http.StatusBadRequest, errInvalidConsentRequest)
+			return nil, nil, false
+		}
+
+		s.error(w, r, http.StatusInternalServerError, err)
+		return nil, nil, false
+	}
+
+	return req, client, true
+}
+
+// authenticateClient finds the OAuth client of the token request and checks its secret, public clients have none.
+// It returns false if it has responded with an error.
+func (s *server) authenticateClient(w http.ResponseWriter, r *http.Request) (*model.OAuthClient, bool) {
+	clientID, secret, basic := r.BasicAuth()
+	if !basic {
+		clientID = r.PostForm.Get("client_id")
+		secret = r.PostForm.Get("client_secret")
+	}
+
+	client, err := s.store.OAuthClient().FindByClientID(clientID)
+	if err != nil && err != store.ErrRecordNotFound {
+		s.error(w, r, http.StatusInternalServerError, err)
+		return nil, false
+	}
+
+	if err == store.ErrRecordNotFound ||
+		(!client.IsPublic() && subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(client.SecretHash)) != 1) {
+		if basic {
+			w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+		}
+
+		s.oauthError(w, r, http.StatusUnauthorized, "invalid_client", errInvalidClient)
+		return nil, false
+	}
+
+	return client, true
+}
+
+// issueAuthorizationCode saves a new authorization code for the request
+// and returns the redirect URI of the client with the code.
+func (s *server) issueAuthorizationCode(req *authorizationRequest, userID int) (string, error) {
+	code := generateToken()
+	now := time.Now()
+	if err := s.store.OAuthCode().Create(&model.OAuthCode{
+		CodeHash:      hashToken(code),
+		ClientID:      req.ClientID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        req.Scopes,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(s.config.OAuthCodeTTL),
+	}); err != nil {
+		return "", err
+	}
+
+	return authorizationRedirect(req, url.Values{"code": {code}}), nil
+}
+
+// issueOAuthAccessToken creates a signed access token of the OAuth client for the user.
+func (s *server) issueOAuthAccessToken(u *model.User, clientID string, scopes []string) (string, error) {
+	now := time.Now()
+	claims := &oauthAccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.config.TokenIssuer,
+			Subject:   strconv.Itoa(u.ID),
+			Audience:  jwt.ClaimStrings{clientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.AccessTokenTTL)),
+			ID:        uuid.New().String(),
+		},
+		ClientID: clientID,
+		Scope:    strings.Join(scopes, " "),
+	}
+
+	return s.signToken(accessTokenType, claims)
+}
+
+// issueIDToken creates a signed ID token of the user for the OAuth client.
+func (s *server) issueIDToken(u *model.User, clientID string, nonce string, scopes []string) (string, error) {
+	now := time.Now()
+	claims := &idTokenClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.config.TokenIssuer,
+			Subject:   strconv.Itoa(u.ID),
+			Audience:  jwt.ClaimStrings{clientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.AccessTokenTTL)),
+		},
+		Nonce:      nonce,
+		userClaims: newUserClaims(u, scopes),
+	}
+
+	return s.signToken(idTokenType, claims)
+}
+
+// redirectAuthorizationError sends the error of the authorization request to the client at its redirect URI.
+func (s *server) redirectAuthorizationError(w http.ResponseWriter, r *http.Request, req *authorizationRequest, kind string, err error) {
+	http.Redirect(w, r, authorizationRedirect(req, url.Values{
+		"error":             {kind},
+		"error_description": {err.Error()},
+	}), http.StatusFound)
+}
+
+// oauthError responds with an error in the format of OAuth 2.0.
+func (s *server) oauthError(w http.ResponseWriter, r *http.Request, code int, kind string, err error) {
+	s.respond(w, r, code, map[string]string{
+		"error":             kind,
+		"error_description": err.Error(),
+	})
+}
+
+// authorizationRedirect returns the redirect URI of the request with the parameters and the state added to its query.
+func authorizationRedirect(req *authorizationRequest, params url.Values) string {
+	u, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		return req.RedirectURI
+	}
+
+	q := u.Query()
+	for name, values := range params {
+		q[name] = values
+	}
+
+	if req.State != "" {
+		q.Set("state", req.State)
+	}
+
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// newUserClaims returns the claims about the user that the scopes release.
+func newUserClaims(u *model.User, scopes []string) userClaims {
+	c := userClaims{}
+	if hasScope(scopes, model.ScopeEmail) && u.Email.Valid {
+		verified := u.EmailVerifiedAt.Valid
+		c.Email = u.Email.String
+		c.EmailVerified = &verified
+	}
+
+	if hasScope(scopes, model.ScopeProfile) {
+		c.GivenName = u.TelegramFirstName.String
+		c.FamilyName = u.TelegramLastName.String
+		c.Name = strings.TrimSpace(c.GivenName + " " + c.FamilyName)
+		c.PreferredUsername = u.TelegramUsername.String
+		c.Picture = u.TelegramPhotoURL.String
+		c.Locale = u.TelegramLanguageCode.String
+	}
+
+	return c
+}
@@ -0,0 +1,483 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testClientSecret  = "secret"
+	testCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	testRedirectURI   = "https://app.example.org/callback"
+)
+
+func TestServer_handleOpenIDConfiguration(t *testing.T) {
+	config := testTwoFactorConfig(t)
+	s := newServer(teststore.New(), sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), config)
+
+	rec := testRequest(t, s, http.MethodGet, "/.well-known/openid-configuration", nil, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	res := map[string]interface{}{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	assert.Equal(t, config.TokenIssuer, res["issuer"])
+	assert.Equal(t, config.PublicURL+"/authorize", res["authorization_endpoint"])
+	assert.Equal(t, config.PublicURL+"/token", res["token_endpoint"])
+	assert.Equal(t, config.PublicURL+"/userinfo", res["userinfo_endpoint"])
+	assert.Equal(t, config.PublicURL+"/.well-known/jwks.json", res["jwks_uri"])
+	assert.Equal(t, []interface{}{"S256"}, res["code_challenge_methods_supported"])
+}
+
+func TestServer_handleAuthorize(t *testing.T) {
+	st := teststore.New()
+	u := model.TestUser(t)
+	st.User().Create(u)
+	client := testOAuthClient(t, st)
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testTwoFactorConfig(t))
+	cookies := testLogin(t, s, u)
+
+	testCases := []struct {
+		name             string
+		query            func(q url.Values)
+		cookies          []*http.Cookie
+		expectedCode     int
+		expectedLocation string
+		expectedError    string
+	}{
+		{
+			name:          "unknown client",
+			query:         func(q url.Values) { q.Set("client_id", "unknown") },
+			cookies:       cookies,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: errUnknownClient.Error(),
+		},
+		{
+			name:          "unregistered redirect uri",
+			query:         func(q url.Values) { q.Set("redirect_uri", "https://evil.example.org/callback") },
+			cookies:       cookies,
+			expectedCode:  http.StatusBadRequest,
+			expectedError: errInvalidRedirectURI.Error(),
+		},
+		{
+			name:             "without openid scope",
+			query:            func(q url.Values) { q.Set("scope", "email") },
+			cookies:          cookies,
+			expectedCode:     http.StatusFound,
+			expectedLocation: testRedirectURI,
+			expectedError:    "invalid_scope",
+		},
+		{
+			name:             "unsupported scope",
+			query:            func(q url.Values) { q.Set("scope", "openid admin") },
+			cookies:          cookies,
+			expectedCode:     http.StatusFound,
+			expectedLocation: testRedirectURI,
+			expectedError:    "invalid_scope",
+		},
+		{
+			name:             "without pkce",
+			query:            func(q url.Values) { q.Del("code_challenge") },
+			cookies:          cookies,
+			expectedCode:     http.StatusFound,
+			expectedLocation: testRedirectURI,
+			expectedError:    "invalid_request",
+		},
+		{
+			name:             "plain pkce",
+			query:            func(q url.Values) { q.Set("code_challenge_method", "plain") },
+			cookies:          cookies,
+			expectedCode:     http.StatusFound,
+			expectedLocation: testRedirectURI,
+			expectedError:    "invalid_request",
+		},
+		{
+			name:             "not logged in",
+			query:            func(q url.Values) {},
+			expectedCode:     http.StatusFound,
+			expectedLocation: domainURL + "/enter/login",
+		},
+		{
+			name:             "not logged in without prompt",
+			query:            func(q url.Values) { q.Set("prompt", "none") },
+			expectedCode:     http.StatusFound,
+			expectedLocation: testRedirectURI,
+			expectedError:    "login_required",
+		},
+		{
+			name:             "without consent",
+			query:            func(q url.Values) {},
+			cookies:          cookies,
+			expectedCode:     http.StatusFound,
+			expectedLocation: domainURL + "/enter/consent",
+		},
+		{
+			name:             "without consent and prompt",
+			query:            func(q url.Values) { q.Set("prompt", "none") },
+			cookies:          cookies,
+			expectedCode:     http.StatusFound,
+			expectedLocation: testRedirectURI,
+			expectedError:    "consent_required",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			q := testAuthorizeQuery(client)
+			tc.query(q)
+			rec := testRequest(t, s, http.MethodGet, "/authorize?"+q.Encode(), nil, tc.cookies)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedCode != http.StatusFound {
+				assert.Contains(t, rec.Body.String(), tc.expectedError)
+				return
+			}
+
+			location, err := url.Parse(rec.Header().Get("Location"))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedLocation, location.Scheme+"://"+location.Host+location.Path)
+			assert.Equal(t, tc.expectedError, location.Query().Get("error"))
+			if tc.expectedError != "" {
+				assert.Equal(t, "xyz", location.Query().Get("state"))
+			}
+		})
+	}
+}
+
+func TestServer_OAuthAuthorizationCodeFlow(t *testing.T) {
+	st := teststore.New()
+	u := model.TestUser(t)
+	st.User().Create(u)
+	client := testOAuthClient(t, st)
+	config := testTwoFactorConfig(t)
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), config)
+	cookies := testLogin(t, s, u)
+
+	rec := testRequest(t, s, http.MethodGet, "/authorize?"+testAuthorizeQuery(client).Encode(), nil, cookies)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	location, _ := url.Parse(rec.Header().Get("Location"))
+	consent := location.Query().Get("request")
+	assert.NotEmpty(t, consent)
+
+	rec = testRequest(t, s, http.MethodGet, "/authorize/consent?request="+url.QueryEscape(consent), nil, cookies)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	info := struct {
+		ClientName string   `json:"client_name"`
+		Scopes     []string `json:"scopes"`
+	}{}
+	json.NewDecoder(rec.Body).Decode(&info)
+	assert.Equal(t, client.Name, info.ClientName)
+	assert.Equal(t, []string{model.ScopeOpenID, model.ScopeEmail}, info.Scopes)
+
+	code := testApproveConsent(t, s, consent, cookies)
+	rec = testRequest(t, s, http.MethodPost, "/authorize/consent", map[string]interface{}{"request": consent, "approve": true}, cookies)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = testTokenRequest(t, s, client.ClientID, testClientSecret, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {testRedirectURI},
+		"code_verifier": {testCodeVerifier},
+	})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	tokens := struct {
+		AccessToken string `json:"access_token"`
+		IDToken     string `json:"id_token"`
+		Scope       string `json:"scope"`
+	}{}
+	json.NewDecoder(rec.Body).Decode(&tokens)
+	assert.Equal(t, "openid email", tokens.Scope)
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokens.IDToken, claims, s.verificationKey, jwt.WithAudience(client.ClientID), jwt.WithIssuer(config.TokenIssuer))
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprint(u.ID), claims["sub"])
+	assert.Equal(t, "n-0S6_WzA2Mj", claims["nonce"])
+	assert.Equal(t, u.Email.String, claims["email"])
+	assert.Equal(t, false, claims["email_verified"])
+
+	rec = testTokenRequest(t, s, client.ClientID, testClientSecret, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"code_verifier": {testCodeVerifier},
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "invalid_grant")
+
+	req, _ := http.NewRequest(http.MethodGet, "/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	userinfo := map[string]interface{}{}
+	json.NewDecoder(rec.Body).Decode(&userinfo)
+	assert.Equal(t, fmt.Sprint(u.ID), userinfo["sub"])
+	assert.Equal(t, u.Email.String, userinfo["email"])
+
+	req, _ = http.NewRequest(http.MethodGet, "/private/whoami", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = testRequest(t, s, http.MethodGet, "/authorize?"+testAuthorizeQuery(client).Encode(), nil, cookies)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	location, _ = url.Parse(rec.Header().Get("Location"))
+	assert.Equal(t, testRedirectURI, location.Scheme+"://"+location.Host+location.Path)
+	assert.NotEmpty(t, location.Query().Get("code"))
+
+	rec = testRequest(t, s, http.MethodGet, "/private/grants", nil, cookies)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), client.Name)
+	assert.Equal(t, http.StatusNoContent, testRequest(t, s, http.MethodDelete, "/private/grants/"+client.ClientID, nil, cookies).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(t, s, http.MethodDelete, "/private/grants/"+client.ClientID, nil, cookies).Code)
+}
+
+func TestServer_handleAuthorizeConsent(t *testing.T) {
+	st := teststore.New()
+	u := model.TestUser(t)
+	st.User().Create(u)
+	other := model.TestUser(t)
+	other.Email.String = "other@example.org"
+	st.User().Create(other)
+	client := testOAuthClient(t, st)
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testTwoFactorConfig(t))
+	cookies := testLogin(t, s, u)
+
+	rec := testRequest(t, s, http.MethodGet, "/authorize?"+testAuthorizeQuery(client).Encode(), nil, cookies)
+	location, _ := url.Parse(rec.Header().Get("Location"))
+	consent := location.Query().Get("request")
+
+	rec = testRequest(t, s, http.MethodPost, "/authorize/consent", map[string]interface{}{"request": consent, "approve": true}, testLogin(t, s, other))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = testRequest(t, s, http.MethodPost, "/authorize/consent", map[string]interface{}{"request": consent + "x", "approve": true}, cookies)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = testRequest(t, s, http.MethodPost, "/authorize/consent", map[string]interface{}{"request": consent, "approve": false}, cookies)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	res := map[string]string{}
+	json.NewDecoder(rec.Body).Decode(&res)
+	location, _ = url.Parse(res["redirect_to"])
+	assert.Equal(t, "access_denied", location.Query().Get("error"))
+	assert.Equal(t, "xyz", location.Query().Get("state"))
+
+	_, err := st.OAuthGrant().Find(u.ID, client.ClientID)
+	assert.Error(t, err)
+}
+
+func TestServer_handleOAuthToken(t *testing.T) {
+	st := teststore.New()
+	u := model.TestUser(t)
+	st.User().Create(u)
+	client := testOAuthClient(t, st)
+	public := model.TestOAuthClient(t)
+	public.ClientID = "public"
+	public.SecretHash = ""
+	st.OAuthClient().Create(public)
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testTwoFactorConfig(t))
+	cookies := testLogin(t, s, u)
+	for _, c := range []*model.OAuthClient{client, public} {
+		st.OAuthGrant().Save(&model.OAuthGrant{UserID: u.ID, ClientID: c.ClientID, Scopes: []string{model.ScopeOpenID, model.ScopeEmail}})
+	}
+
+	testCases := []struct {
+		name          string
+		clientID      string
+		secret        string
+		codeClient    *model.OAuthClient
+		params        url.Values
+		expectedCode  int
+		expectedError string
+	}{
+		{
+			name:         "confidential",
+			clientID:     client.ClientID,
+			secret:       testClientSecret,
+			codeClient:   client,
+			params:       url.Values{"grant_type": {"authorization_code"}, "code_verifier": {testCodeVerifier}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "public",
+			clientID:     public.ClientID,
+			codeClient:   public,
+			params:       url.Values{"grant_type": {"authorization_code"}, "code_verifier": {testCodeVerifier}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:          "wrong secret",
+			clientID:      client.ClientID,
+			secret:        "wrong",
+			codeClient:    client,
+			params:        url.Values{"grant_type": {"authorization_code"}, "code_verifier": {testCodeVerifier}},
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: "invalid_client",
+		},
+		{
+			name:          "code of another client",
+			clientID:      public.ClientID,
+			codeClient:    client,
+			params:        url.Values{"grant_type": {"authorization_code"}, "code_verifier": {testCodeVerifier}},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "invalid_grant",
+		},
+		{
+			name:          "wrong code verifier",
+			clientID:      client.ClientID,
+			secret:        testClientSecret,
+			codeClient:    client,
+			params:        url.Values{"grant_type": {"authorization_code"}, "code_verifier": {strings.Repeat("a", 43)}},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "invalid_grant",
+		},
+		{
+			name:          "wrong redirect uri",
+			clientID:      client.ClientID,
+			secret:        testClientSecret,
+			codeClient:    client,
+			params:        url.Values{"grant_type": {"authorization_code"}, "code_verifier": {testCodeVerifier}, "redirect_uri": {"https://app.example.org/other"}},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "invalid_grant",
+		},
+		{
+			name:          "unsupported grant type",
+			clientID:      client.ClientID,
+			secret:        testClientSecret,
+			codeClient:    client,
+			params:        url.Values{"grant_type": {"password"}},
+			expectedCode:  http.StatusBadRequest,
+			expectedError: "unsupported_grant_type",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, http.MethodGet, "/authorize?"+testAuthorizeQuery(tc.codeClient).Encode(), nil, cookies)
+			location, _ := url.Parse(rec.Header().Get("Location"))
+			tc.params.Set("code", location.Query().Get("code"))
+
+			rec = testTokenRequest(t, s, tc.clientID, tc.secret, tc.params)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			if tc.expectedError != "" {
+				assert.Contains(t, rec.Body.String(), tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestServer_handleAdminClients(t *testing.T) {
+	st := teststore.New()
+	admin := model.TestUser(t)
+	admin.Email.String = "admin@example.org"
+	st.User().Create(admin)
+	st.Role().Assign(admin.ID, model.RoleAdmin)
+	moderator := model.TestUser(t)
+	moderator.Email.String = "moderator@example.org"
+	st.User().Create(moderator)
+	st.Role().Assign(moderator.ID, model.RoleModerator)
+	s := newServer(st, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testTwoFactorConfig(t))
+	cookies := testLogin(t, s, admin)
+
+	payload := map[string]interface{}{"name": "Wiki", "redirect_uris": []string{testRedirectURI}}
+	assert.Equal(t, http.StatusForbidden, testRequest(t, s, http.MethodPost, "/admin/clients", payload, testLogin(t, s, moderator)).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, testRequest(t, s, http.MethodPost, "/admin/clients", map[string]interface{}{"name": "Wiki"}, cookies).Code)
+
+	rec := testRequest(t, s, http.MethodPost, "/admin/clients", payload, cookies)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	created := &clientInfo{}
+	json.NewDecoder(rec.Body).Decode(created)
+	assert.NotEmpty(t, created.ClientID)
+	assert.NotEmpty(t, created.ClientSecret)
+	assert.False(t, created.Public)
+
+	c, err := st.OAuthClient().FindByClientID(created.ClientID)
+	assert.NoError(t, err)
+	assert.Equal(t, hashToken(created.ClientSecret), c.SecretHash)
+
+	rec = testRequest(t, s, http.MethodGet, "/admin/clients", nil, cookies)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), created.ClientSecret)
+
+	path := fmt.Sprintf("/admin/clients/%d", created.ID)
+	assert.Equal(t, http.StatusNoContent, testRequest(t, s, http.MethodDelete, path, nil, cookies).Code)
+	assert.Equal(t, http.StatusNotFound, testRequest(t, s, http.MethodDelete, path, nil, cookies).Code)
+}
+
+// testOAuthClient registers a confidential OAuth client with testClientSecret.
+func testOAuthClient(t *testing.T, st *teststore.Store) *model.OAuthClient {
+	t.Helper()
+
+	c := model.TestOAuthClient(t)
+	c.SecretHash = hashToken(testClientSecret)
+	if err := st.OAuthClient().Create(c); err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+// testAuthorizeQuery returns the parameters of a valid authorization request of the client.
+func testAuthorizeQuery(c *model.OAuthClient) url.Values {
+	return url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.ClientID},
+		"redirect_uri":          {testRedirectURI},
+		"scope":                 {"openid email"},
+		"state":                 {"xyz"},
+		"nonce":                 {"n-0S6_WzA2Mj"},
+		"code_challenge":        {testCodeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+}
+
+// testApproveConsent approves the consent request and returns the authorization code sent to the client.
+func testApproveConsent(t *testing.T, s *server, consent string, cookies []*http.Cookie) string {
+	t.Helper()
+
+	rec := testRequest(t, s, http.MethodPost, "/authorize/consent", map[string]interface{}{"request": consent, "approve": true}, cookies)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("consent failed with %d", rec.Code)
+	}
+
+	res := map[string]string{}
+	json.NewDecoder(rec.Body).Decode(&res)
+	location, err := url.Parse(res["redirect_to"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return location.Query().Get("code")
+}
+
+// testTokenRequest sends the form encoded token request authenticated with the client id and the secret, if any.
+func testTokenRequest(t *testing.T, s *server, clientID string, secret string, params url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+
+	if secret == "" {
+		params.Set("client_id", clientID)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "/token", strings.NewReader(params.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if secret != "" {
+		req.SetBasicAuth(clientID, secret)
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	return rec
+}
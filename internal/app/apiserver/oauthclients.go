@@ -0,0 +1,189 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// clientInfo is an OAuth client as it is shown to administrators.
+// ClientSecret is filled only once, in the response to the registration of a confidential client.
+type clientInfo struct {
+	ID           int       `json:"id"`
+	ClientID     string    `json:"client_id"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Public       bool      `json:"public"`
+	CreatedAt    time.Time `json:"created_at"`
+	ClientSecret string    `json:"client_secret,omitempty"`
+}
+
+// grantInfo is a consent to an OAuth client as it is shown to the user who gave it.
+type grantInfo struct {
+	ClientID   string    `json:"client_id"`
+	ClientName string    `json:"client_name"`
+	Scopes     []string  `json:"scopes"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// newClientInfo returns the OAuth client as it is shown to administrators.
+func newClientInfo(c *model.OAuthClient) *clientInfo {
+	return &clientInfo{
+		ID:           c.ID,
+		ClientID:     c.ClientID,
+		Name:         c.Name,
+		RedirectURIs: c.RedirectURIs,
+		Public:       c.IsPublic(),
+		CreatedAt:    c.CreatedAt,
+	}
+}
+
+// handleAdminClientsCreate registers a new OAuth client.
+// A confidential client gets a secret that is returned only in this response, a public client must use PKCE alone.
+func (s *server) handleAdminClientsCreate() http.HandlerFunc {
+	type request struct {
+		Name         string   `json:"name"`
+		RedirectURIs []string `json:"redirect_uris"`
+		Public       bool     `json:"public"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		c := &model.OAuthClient{
+			ClientID:     uuid.New().String(),
+			Name:         req.Name,
+			RedirectURIs: req.RedirectURIs,
+			CreatedAt:    time.Now(),
+		}
+
+		var secret string
+		if !req.Public {
+			secret = generateToken()
+			c.SecretHash = hashToken(secret)
+		}
+
+		if err := s.store.OAuthClient().Create(c); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		info := newClientInfo(c)
+		info.ClientSecret = secret
+		s.respond(w, r, http.StatusCreated, info)
+	}
+}
+
+// handleAdminClientsList responds with all registered OAuth clients.
+func (s *server) handleAdminClientsList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		clients, err := s.store.OAuthClient().FindAll()
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := []*clientInfo{}
+		for _, c := range clients {
+			res = append(res, newClientInfo(c))
+		}
+
+		s.respond(w, r, http.StatusOK, res)
+	}
+}
+
+// handleAdminClientsDelete deletes the OAuth client by the id with its codes and the consents of users to it.
+// The access tokens already issued to the client stay valid until they expire.
+func (s *server) handleAdminClientsDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := s.store.OAuthClient().Delete(id); err != nil {
+			if err == store.ErrRecordNotFound {
+				s.error(w, r, http.StatusNotFound, err)
+				return
+			}
+
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// handleGrantsList responds with the OAuth clients the current user has authorized.
+func (s *server) handleGrantsList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		grants, err := s.grantInfos(u.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, grants)
+	}
+}
+
+// handleGrantsRevoke withdraws the consent of the current user to the OAuth client by the client id,
+// so that the client has to ask the user again.
+func (s *server) handleGrantsRevoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		if err := s.store.OAuthGrant().Delete(u.ID, mux.Vars(r)["client_id"]); err != nil {
+			if err == store.ErrRecordNotFound {
+				s.error(w, r, http.StatusNotFound, err)
+				return
+			}
+
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// grantInfos returns the consents of the user with the names of the OAuth clients.
+func (s *server) grantInfos(userID int) ([]*grantInfo, error) {
+	grants, err := s.store.OAuthGrant().FindByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*grantInfo{}
+	for _, g := range grants {
+		c, err := s.store.OAuthClient().FindByClientID(g.ClientID)
+		if err != nil {
+			if err == store.ErrRecordNotFound {
+				continue
+			}
+
+			return nil, err
+		}
+
+		res = append(res, &grantInfo{
+			ClientID:   g.ClientID,
+			ClientName: c.Name,
+			Scopes:     g.Scopes,
+			CreatedAt:  g.CreatedAt,
+		})
+	}
+
+	return res, nil
+}
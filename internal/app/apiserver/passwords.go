@@ -0,0 +1,264 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errInvalidResetToken = errors.New("invalid or expired password reset token")
+	errIncorrectPassword = errors.New("incorrect password")
+	errNoPassword        = errors.New("account has no password")
+)
+
+// handlePasswordForgot sends a password reset token to the email if it belongs to a user.
+// The response is the same whether or not the email exists, and the token is sent in the background
+// so that the response does not take longer for an existing email either.
+func (s *server) handlePasswordForgot() http.HandlerFunc {
+	type request struct {
+		Email string `json:"email"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		requestID := r.Context().Value(ctxKeyRequestID)
+		s.background.Add(1)
+		go func() {
+			defer s.background.Done()
+
+			if err := s.sendPasswordReset(req.Email); err != nil {
+				s.logger.WithFields(logrus.Fields{
+					"request_id": requestID,
+				}).Errorf("failed to send password reset: %v", err)
+			}
+		}()
+
+		s.respond(w, r, http.StatusAccepted, nil)
+	}
+}
+
+// handlePasswordReset sets a new password by a password reset token and ends all sessions of the user.
+func (s *server) handlePasswordReset() http.HandlerFunc {
+	type request struct {
+		Token           string `json:"token"`
+		Password        string `json:"password"`
+		ConfirmPassword string `json:"confirm_password"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.ConfirmPassword != req.Password {
+			s.error(w, r, http.StatusBadRequest, errConfirmPasswordIsRequired)
+			return
+		}
+
+		pr, err := s.store.PasswordReset().FindByTokenHash(hashToken(req.Token))
+		if err != nil {
+			if err == store.ErrRecordNotFound {
+				s.error(w, r, http.StatusBadRequest, errInvalidResetToken)
+				return
+			}
+
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if pr.IsUsed() || pr.IsExpired(time.Now()) {
+			s.error(w, r, http.StatusBadRequest, errInvalidResetToken)
+			return
+		}
+
+		u, err := s.store.User().Find(pr.UserID)
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, errInvalidResetToken)
+			return
+		}
+
+		if !s.checkPassword(w, r, req.Password, u.Email.String) {
+			return
+		}
+
+		u.Password = req.Password
+		u.PasswordHasher = s.passwordHasher
+		if err := u.Validate(); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.store.User().ResetPassword(u, pr.ID); err != nil {
+			if err == store.ErrRecordNotFound {
+				s.error(w, r, http.StatusBadRequest, errInvalidResetToken)
+				return
+			}
+
+			s.userUpdateError(w, r, err)
+			return
+		}
+
+		if err := s.endAllSessions(u.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, model.AuditPasswordReset, u.ID, 0)
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// handlePasswordChange sets a new password of the current user, ends all other sessions of the user
+// and revokes all refresh tokens of the user.
+func (s *server) handlePasswordChange() http.HandlerFunc {
+	type request struct {
+		CurrentPassword string `json:"current_password"`
+		Password        string `json:"password"`
+		ConfirmPassword string `json:"confirm_password"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := *r.Context().Value(ctxKeyUser).(*model.User)
+		if !u.EncryptedPassword.Valid {
+			s.error(w, r, http.StatusBadRequest, errNoPassword)
+			return
+		}
+
+		if !u.ComparePassword(req.CurrentPassword) {
+			s.error(w, r, http.StatusForbidden, errIncorrectPassword)
+			return
+		}
+
+		if req.ConfirmPassword != req.Password {
+			s.error(w, r, http.StatusBadRequest, errConfirmPasswordIsRequired)
+			return
+		}
+
+		if !s.checkPassword(w, r, req.Password, u.Email.String) {
+			return
+		}
+
+		u.Password = req.Password
+		u.PasswordHasher = s.passwordHasher
+		if err := s.store.User().UpdatePassword(&u); err != nil {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err := s.endOtherSessions(w, r, &u); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.store.RefreshToken().RevokeByUser(u.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.audit(r, model.AuditPasswordChange, u.ID, 0)
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// sendPasswordReset issues a password reset token for the user with the email and mails it.
+// An unknown email is not an error.
+func (s *server) sendPasswordReset(email string) error {
+	if email == "" {
+		return nil
+	}
+
+	u, err := s.store.User().FindByEmail(email)
+	if err != nil {
+		if err == store.ErrRecordNotFound {
+			return nil
+		}
+
+		return err
+	}
+
+	token := generateToken()
+	now := time.Now()
+	if err := s.store.PasswordReset().Create(&model.PasswordReset{
+		UserID:    u.ID,
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.config.PasswordResetTTL),
+	}); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(&mailer.Message{
+		To:      u.Email.String,
+		Subject: "Password reset",
+		Body: fmt.Sprintf(
+			"To set a new password, open the link below. It is valid for %s.\n\n%s/enter/reset-password?token=%s\n\nIf you did not ask for a password reset, ignore this email.",
+			s.config.PasswordResetTTL,
+			s.config.PublicURL,
+			token,
+		),
+	})
+}
+
+// rehashPassword replaces the hash of the password the user has just logged in with
+// if it was not made by the hasher of the server with its current parameters.
+// The old hash still works, so a failure is only logged.
+func (s *server) rehashPassword(r *http.Request, u *model.User, password string) {
+	if !u.NeedsRehash(s.passwordHasher) {
+		return
+	}
+
+	old := u.EncryptedPassword.String
+	rehashed := &model.User{Password: password, PasswordHasher: s.passwordHasher}
+	err := rehashed.BeforeCreate()
+	if err == nil {
+		err = s.store.User().ReplaceEncryptedPassword(u.ID, old, rehashed.EncryptedPassword.String)
+	}
+
+	if err != nil && err != store.ErrRecordNotFound {
+		s.logger.WithFields(logrus.Fields{
+			"request_id": r.Context().Value(ctxKeyRequestID),
+		}).Errorf("failed to rehash password: %v", err)
+	}
+}
+
+// checkPassword responds with 400 and the broken rules of the password policy and returns false
+// if the new password of the user with the email breaks the policy.
+func (s *server) checkPassword(w http.ResponseWriter, r *http.Request, password string, email string) bool {
+	err := s.passwordPolicy.Check(password, email)
+	if err == nil {
+		return true
+	}
+
+	var policyErr *model.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		s.respond(w, r, http.StatusBadRequest, map[string]interface{}{
+			"error":      errEasyPassword.Error(),
+			"violations": policyErr.Violations,
+		})
+		return false
+	}
+
+	s.error(w, r, http.StatusBadRequest, err)
+	return false
+}
@@ -0,0 +1,460 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/sessionstore"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var resetTokenRe = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+func TestServer_handlePasswordForgot(t *testing.T) {
+	u := model.TestUser(t)
+	store := teststore.New()
+	store.User().Create(u)
+	mail := &bytes.Buffer{}
+	s := newServer(store, sessionstore.New(store.Session(), []byte("secret")), mailer.TestMailer(t, mail), NewConfig())
+
+	forgot := func(email string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]string{
+			"email": email,
+		})
+		req, _ := http.NewRequest(http.MethodPost, "/password/forgot", b)
+		s.ServeHTTP(rec, req)
+		s.background.Wait()
+
+		return rec
+	}
+
+	unknown := forgot("unknown@example.org")
+	assert.Equal(t, http.StatusAccepted, unknown.Code)
+	assert.Zero(t, mail.Len())
+
+	known := forgot(u.Email.String)
+	assert.Equal(t, http.StatusAccepted, known.Code)
+	assert.Equal(t, unknown.Body.String(), known.Body.String())
+	assert.Contains(t, mail.String(), "To: "+u.Email.String)
+	assert.Contains(t, mail.String(), "/enter/reset-password?token=")
+	assert.Regexp(t, resetTokenRe, mail.String())
+}
+
+func TestServer_handlePasswordReset(t *testing.T) {
+	u := model.TestUser(t)
+	store := teststore.New()
+	store.User().Create(u)
+	mail := &bytes.Buffer{}
+	s := newServer(store, sessionstore.New(store.Session(), []byte("secret")), mailer.TestMailer(t, mail), testConfig(t))
+	cookies := testLogin(t, s, u)
+	tokens, err := s.issueTokens(u, "family")
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]string{
+		"email": u.Email.String,
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/password/forgot", b)
+	s.ServeHTTP(rec, req)
+	s.background.Wait()
+	token := resetTokenRe.FindStringSubmatch(mail.String())[1]
+
+	expired := generateToken()
+	store.PasswordReset().Create(&model.PasswordReset{
+		UserID:    u.ID,
+		TokenHash: hashToken(expired),
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+		ExpiresAt: time.Now().Add(-time.Hour),
+	})
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name: "confirm password mismatch",
+			payload: map[string]string{
+				"token":            token,
+				"password":         "NewPassword1",
+				"confirm_password": "NewPassword2",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "easy password",
+			payload: map[string]string{
+				"token":            token,
+				"password":         "password",
+				"confirm_password": "password",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "unknown token",
+			payload: map[string]string{
+				"token":            "unknown",
+				"password":         "NewPassword1",
+				"confirm_password": "NewPassword1",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "expired token",
+			payload: map[string]string{
+				"token":            expired,
+				"password":         "NewPassword1",
+				"confirm_password": "NewPassword1",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "valid",
+			payload: map[string]string{
+				"token":            token,
+				"password":         "NewPassword1",
+				"confirm_password": "NewPassword1",
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name: "used token",
+			payload: map[string]string{
+				"token":            token,
+				"password":         "NewPassword2",
+				"confirm_password": "NewPassword2",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid payload",
+			payload:      "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPost, "/password/reset", b)
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	rec = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/private/whoami", nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rt, err := store.RefreshToken().FindByTokenHash(hashToken(tokens.RefreshToken))
+	assert.NoError(t, err)
+	assert.True(t, rt.IsUsed())
+
+	testLogin(t, s, &model.User{
+		Email:    u.Email,
+		Password: "NewPassword1",
+	})
+}
+
+func TestServer_handlePasswordResetWithCookieSessions(t *testing.T) {
+	u := model.TestUser(t)
+	store := teststore.New()
+	store.User().Create(u)
+	mail := &bytes.Buffer{}
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, mail), testConfig(t))
+	cookies := testLogin(t, s, u)
+	other := testLogin(t, s, u)
+
+	testRequest(t, s, http.MethodPost, "/password/forgot", map[string]string{"email": u.Email.String}, nil)
+	s.background.Wait()
+	rec := testRequest(t, s, http.MethodPost, "/password/reset", map[string]string{
+		"token":            resetTokenRe.FindStringSubmatch(mail.String())[1],
+		"password":         "NewPassword1",
+		"confirm_password": "NewPassword1",
+	}, nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, testRequest(t, s, http.MethodGet, "/private/whoami", nil, cookies).Code)
+	assert.Equal(t, http.StatusUnauthorized, testRequest(t, s, http.MethodGet, "/private/whoami", nil, other).Code)
+
+	cookies = testLogin(t, s, &model.User{
+		Email:    u.Email,
+		Password: "NewPassword1",
+	})
+	assert.Equal(t, http.StatusOK, testRequest(t, s, http.MethodGet, "/private/whoami", nil, cookies).Code)
+}
+
+func TestServer_handlePasswordChange(t *testing.T) {
+	u := model.TestUser(t)
+	store := teststore.New()
+	store.User().Create(u)
+	s := newServer(store, sessionstore.New(store.Session(), []byte("secret")), mailer.TestMailer(t, &bytes.Buffer{}), testConfig(t))
+	other := testLogin(t, s, u)
+	cookies := testLogin(t, s, u)
+	tokens, err := s.issueTokens(u, "family")
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name: "wrong current password",
+			payload: map[string]string{
+				"current_password": "wrong",
+				"password":         "NewPassword1",
+				"confirm_password": "NewPassword1",
+			},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name: "confirm password mismatch",
+			payload: map[string]string{
+				"current_password": u.Password,
+				"password":         "NewPassword1",
+				"confirm_password": "NewPassword2",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "easy password",
+			payload: map[string]string{
+				"current_password": u.Password,
+				"password":         "newpassword",
+				"confirm_password": "newpassword",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "too long password",
+			payload: map[string]string{
+				"current_password": u.Password,
+				"password":         "NewPassword1NewPassword1NewPassword1",
+				"confirm_password": "NewPassword1NewPassword1NewPassword1",
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid payload",
+			payload:      "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name: "valid",
+			payload: map[string]string{
+				"current_password": u.Password,
+				"password":         "NewPassword1",
+				"confirm_password": "NewPassword1",
+			},
+			expectedCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPut, "/private/password", b)
+			for _, c := range cookies {
+				req.AddCookie(c)
+			}
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	whoami := func(cookies []*http.Cookie) int {
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/private/whoami", nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		s.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, whoami(cookies))
+	assert.Equal(t, http.StatusUnauthorized, whoami(other))
+
+	rt, err := store.RefreshToken().FindByTokenHash(hashToken(tokens.RefreshToken))
+	assert.NoError(t, err)
+	assert.True(t, rt.IsUsed())
+
+	testLogin(t, s, &model.User{
+		Email:    u.Email,
+		Password: "NewPassword1",
+	})
+}
+
+func TestServer_handlePasswordChangeWithCookieSessions(t *testing.T) {
+	u := model.TestUser(t)
+	store := teststore.New()
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), testConfig(t))
+	other := testLogin(t, s, u)
+	cookies := testLogin(t, s, u)
+
+	rec := testRequest(t, s, http.MethodPut, "/private/password", map[string]string{
+		"current_password": u.Password,
+		"password":         "NewPassword1",
+		"confirm_password": "NewPassword1",
+	}, cookies)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, testRequest(t, s, http.MethodGet, "/private/whoami", nil, other).Code)
+	assert.Equal(t, http.StatusUnauthorized, testRequest(t, s, http.MethodGet, "/private/whoami", nil, cookies).Code)
+	assert.Equal(t, http.StatusOK, testRequest(t, s, http.MethodGet, "/private/whoami", nil, rec.Result().Cookies()).Code)
+}
+
+func TestServer_RehashPasswordOnLogin(t *testing.T) {
+	u := model.TestUser(t)
+	u.PasswordHasher = &model.BcryptHasher{Cost: bcrypt.MinCost}
+	store := teststore.New()
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
+
+	testCases := []struct {
+		name   string
+		hasher model.PasswordHasher
+		prefix string
+	}{
+		{
+			name:   "higher bcrypt cost",
+			hasher: &model.BcryptHasher{Cost: bcrypt.MinCost + 1},
+			prefix: "$2a$05$",
+		},
+		{
+			name:   "argon2id",
+			hasher: model.NewArgon2idHasher(1024, 1, 1),
+			prefix: "$argon2id$v=19$m=1024,t=1,p=1$",
+		},
+		{
+			name:   "other argon2id parameters",
+			hasher: model.NewArgon2idHasher(2048, 1, 1),
+			prefix: "$argon2id$v=19$m=2048,t=1,p=1$",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s.passwordHasher = tc.hasher
+			testLogin(t, s, u)
+
+			rehashed, err := store.User().Find(u.ID)
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(rehashed.EncryptedPassword.String, tc.prefix))
+			assert.False(t, rehashed.NeedsRehash(tc.hasher))
+			assert.True(t, rehashed.ComparePassword(u.Password))
+		})
+	}
+}
+
+func TestServer_PasswordPolicy(t *testing.T) {
+	blocklist := filepath.Join(t.TempDir(), "blocklist.txt")
+	assert.NoError(t, os.WriteFile(blocklist, []byte("# common passwords\nQwerty123!\n"), 0o600))
+
+	config := NewConfig()
+	config.PasswordMinLength = 8
+	config.PasswordRequireSymbol = true
+	config.PasswordBlocklistFile = blocklist
+	policy, err := newPasswordPolicy(config)
+	assert.NoError(t, err)
+
+	s := newServer(teststore.New(), sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), config)
+	s.passwordPolicy = policy
+
+	testCases := []struct {
+		name          string
+		password      string
+		expectedCode  int
+		expectedRules []string
+	}{
+		{
+			name:          "short without symbol",
+			password:      "Pass123",
+			expectedCode:  http.StatusBadRequest,
+			expectedRules: []string{"min_length", "symbol"},
+		},
+		{
+			name:          "contains email",
+			password:      "Johnny-99",
+			expectedCode:  http.StatusBadRequest,
+			expectedRules: []string{"email"},
+		},
+		{
+			name:          "blocklisted",
+			password:      "qwerty123!",
+			expectedCode:  http.StatusBadRequest,
+			expectedRules: []string{"upper", "blocklist"},
+		},
+		{
+			name:         "valid",
+			password:     "Secret-123",
+			expectedCode: http.StatusCreated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, http.MethodPost, "/users", map[string]string{
+				"email":            "johnny@example.org",
+				"password":         tc.password,
+				"confirm_password": tc.password,
+			}, nil)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+
+			if tc.expectedRules == nil {
+				return
+			}
+
+			res := struct {
+				Error      string                    `json:"error"`
+				Violations []model.PasswordViolation `json:"violations"`
+			}{}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+			assert.Equal(t, errEasyPassword.Error(), res.Error)
+
+			rules := make([]string, len(res.Violations))
+			for i, v := range res.Violations {
+				rules[i] = v.Rule
+				assert.NotEmpty(t, v.Message)
+			}
+			assert.Equal(t, tc.expectedRules, rules)
+		})
+	}
+
+	config.PasswordMaxLength = 4
+	_, err = newPasswordPolicy(config)
+	assert.Error(t, err)
+
+	config.PasswordMaxLength = 0
+	config.PasswordBlocklistFile = filepath.Join(t.TempDir(), "missing.txt")
+	_, err = newPasswordPolicy(config)
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package apiserver
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/http-rest-API/internal/app/model"
+)
+
+// RateLimit is the token bucket that limits the requests of one client to a group of routes.
+// It includes the following fields:
+// - Requests: how many requests are allowed per Period, zero disables the limit.
+// - Period: the time it takes to refill Requests tokens.
+// - Burst: how many requests can be made at once, it defaults to Requests.
+type RateLimit struct {
+	Requests int           `toml:"requests"`
+	Period   time.Duration `toml:"period"`
+	Burst    int           `toml:"burst"`
+}
+
+// RateLimits holds the rate limits of the route groups.
+type RateLimits struct {
+	Public   RateLimit `toml:"public"`
+	Enter    RateLimit `toml:"enter"`
+	Telegram RateLimit `toml:"telegram"`
+	Private  RateLimit `toml:"private"`
+}
+
+// tokenBucket is the number of tokens left to a client at the time it was last updated.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimiter keeps a token bucket for each client of a route group.
+// Buckets that are full again are dropped.
+type rateLimiter struct {
+	mu          sync.Mutex
+	burst       float64
+	rate        float64
+	buckets     map[string]*tokenBucket
+	lastCleanup time.Time
+}
+
+// newRateLimiter returns a rateLimiter with the given limit or nil if the limit is disabled.
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	if limit.Requests <= 0 || limit.Period <= 0 {
+		return nil
+	}
+
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = limit.Requests
+	}
+
+	return &rateLimiter{
+		burst:   float64(burst),
+		rate:    float64(limit.Requests) / limit.Period.Seconds(),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// take removes a token from the bucket of the key at the given time and reports whether there was one.
+// It also returns how many tokens are left and how long it takes to refill the bucket,
+// or to get the next token if there was none.
+func (l *rateLimiter) take(key string, now time.Time) (int, time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fullAfter := time.Duration(l.burst / l.rate * float64(time.Second))
+	if now.Sub(l.lastCleanup) >= fullAfter {
+		for k, b := range l.buckets {
+			if now.Sub(b.updated) >= fullAfter {
+				delete(l.buckets, k)
+			}
+		}
+
+		l.lastCleanup = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{
+			tokens:  l.burst,
+			updated: now,
+		}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return 0, l.wait(1 - b.tokens), false
+	}
+
+	b.tokens--
+	return int(b.tokens), l.wait(l.burst - b.tokens), true
+}
+
+// wait returns how long it takes to refill the given number of tokens.
+func (l *rateLimiter) wait(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// rateLimit limits the requests to the route group by the authenticated user or, if there is none, by the client address.
+// It reports the state of the limit in the RateLimit-* headers.
+func (s *server) rateLimit(limit RateLimit) mux.MiddlewareFunc {
+	l := newRateLimiter(limit)
+
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := "ip:" + clientIP(r)
+			if u, ok := r.Context().Value(ctxKeyUser).(*model.User); ok {
+				key = "user:" + strconv.Itoa(u.ID)
+			}
+
+			remaining, wait, ok := l.take(key, time.Now())
+			reset := strconv.Itoa(int(math.Ceil(wait.Seconds())))
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(int(l.burst)))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+			w.Header().Set("RateLimit-Reset", reset)
+			if !ok {
+				w.Header().Set("Retry-After", reset)
+				s.error(w, r, http.StatusTooManyRequests, errTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
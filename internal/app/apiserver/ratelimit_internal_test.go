@@ -0,0 +1,82 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_take(t *testing.T) {
+	assert.Nil(t, newRateLimiter(RateLimit{}))
+
+	l := newRateLimiter(RateLimit{Requests: 2, Period: time.Minute})
+	now := time.Now()
+
+	remaining, wait, ok := l.take("key", now)
+	assert.True(t, ok)
+	assert.Equal(t, 1, remaining)
+	assert.Equal(t, 30*time.Second, wait)
+
+	remaining, wait, ok = l.take("key", now)
+	assert.True(t, ok)
+	assert.Equal(t, 0, remaining)
+	assert.Equal(t, time.Minute, wait)
+
+	_, wait, ok = l.take("key", now.Add(10*time.Second))
+	assert.False(t, ok)
+	assert.Equal(t, 20*time.Second, wait)
+
+	_, _, ok = l.take("other", now)
+	assert.True(t, ok)
+
+	_, _, ok = l.take("key", now.Add(30*time.Second))
+	assert.True(t, ok)
+}
+
+func TestServer_rateLimit(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	config := NewConfig()
+	config.RateLimits.Public = RateLimit{Requests: 1, Period: time.Hour, Burst: 2}
+	config.RateLimits.Private = RateLimit{Requests: 1, Period: time.Hour}
+	config.RateLimits.Enter = RateLimit{}
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), config)
+
+	cookies := testLogin(t, s, u)
+	rec := testRequest(t, s, http.MethodPost, "/sessions", map[string]string{}, nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "7200", rec.Header().Get("RateLimit-Reset"))
+
+	rec = testRequest(t, s, http.MethodPost, "/users", map[string]string{}, nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "3600", rec.Header().Get("Retry-After"))
+	res := map[string]string{}
+	json.NewDecoder(rec.Body).Decode(&res)
+	assert.Equal(t, errTooManyRequests.Error(), res["error"])
+
+	rec = testRequest(t, s, http.MethodGet, "/enter/login", nil, nil)
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+
+	rec = testRequest(t, s, http.MethodGet, "/private/whoami", nil, cookies)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = testRequest(t, s, http.MethodGet, "/private/whoami", nil, cookies)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	req := httptest.NewRequest(http.MethodPost, "/sessions", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+}
@@ -0,0 +1,66 @@
+package apiserver
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/http-rest-API/internal/app/model"
+)
+
+var (
+	errPermissionDenied  = errors.New("permission denied")
+	errInsufficientScope = errors.New("API key scopes do not include the required permission")
+)
+
+// requirePermission returns a middleware that rejects the users whose roles do not grant the permission.
+// A request made with an API key that has scopes is also rejected if the scopes do not include the permission.
+// It must be used after authenticateUser.
+func (s *server) requirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u := r.Context().Value(ctxKeyUser).(*model.User)
+			roles, err := s.store.Role().FindByUser(u.ID)
+			if err != nil {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			if !model.HasPermission(roles, permission) {
+				s.error(w, r, http.StatusForbidden, errPermissionDenied)
+				return
+			}
+
+			if k, ok := r.Context().Value(ctxKeyAPIKey).(*model.APIKey); ok && len(k.Scopes) > 0 && !hasScope(k.Scopes, permission) {
+				s.error(w, r, http.StatusForbidden, errInsufficientScope)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rejectScopedAPIKeys returns a middleware that rejects requests made with an API key that has scopes.
+// Scopes only grant permissions, so such a key cannot use the routes that require no permission.
+// It must be used after authenticateUser.
+func (s *server) rejectScopedAPIKeys(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if k, ok := r.Context().Value(ctxKeyAPIKey).(*model.APIKey); ok && len(k.Scopes) > 0 {
+			s.error(w, r, http.StatusForbidden, errInsufficientScope)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasScope checks if the scopes of an API key or an OAuth client include the given one.
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
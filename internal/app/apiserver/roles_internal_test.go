@@ -0,0 +1,148 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_requirePermission(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	moderator := model.TestUser(t)
+	moderator.Email.String = "moderator@example.org"
+	store.User().Create(moderator)
+	store.Role().Assign(moderator.ID, model.RoleModerator)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
+
+	protected := s.router.PathPrefix("/protected").Subrouter()
+	protected.Use(s.authenticateUser)
+	protected.Use(s.requirePermission(model.PermissionUsersRead))
+	protected.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		s.respond(w, r, http.StatusOK, nil)
+	})
+
+	scoped := func(scopes []string) string {
+		key := apiKeyPrefix + generateToken()
+		store.APIKey().Create(&model.APIKey{
+			UserID:    moderator.ID,
+			Name:      "scoped",
+			Prefix:    key[:apiKeyPrefixLength],
+			KeyHash:   hashToken(key),
+			Scopes:    scopes,
+			CreatedAt: time.Now(),
+		})
+
+		return key
+	}
+
+	testCases := []struct {
+		name         string
+		cookies      []*http.Cookie
+		apiKey       string
+		expectedCode int
+	}{
+		{
+			name:         "not authenticated",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "without permission",
+			cookies:      testLogin(t, s, u),
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "with permission",
+			cookies:      testLogin(t, s, moderator),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "api key without scopes",
+			apiKey:       scoped(nil),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "api key with the scope",
+			apiKey:       scoped([]string{model.PermissionUsersRead}),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "api key with other scopes",
+			apiKey:       scoped([]string{model.PermissionUsersWrite}),
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/protected", nil)
+			for _, c := range tc.cookies {
+				req.AddCookie(c)
+			}
+			if tc.apiKey != "" {
+				req.Header.Set("X-API-Key", tc.apiKey)
+			}
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_rejectScopedAPIKeys(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
+
+	whoami := func(scopes []string) int {
+		key := apiKeyPrefix + generateToken()
+		store.APIKey().Create(&model.APIKey{
+			UserID:    u.ID,
+			Name:      "key",
+			Prefix:    key[:apiKeyPrefixLength],
+			KeyHash:   hashToken(key),
+			Scopes:    scopes,
+			CreatedAt: time.Now(),
+		})
+
+		rec := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/private/whoami", nil)
+		req.Header.Set("X-API-Key", key)
+		s.ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, whoami(nil))
+	assert.Equal(t, http.StatusForbidden, whoami([]string{model.PermissionUsersRead}))
+}
+
+func TestServer_handleWhoamiRoles(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	store.Role().Assign(u.ID, model.RoleAdmin)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
+
+	rec := testRequest(t, s, http.MethodGet, "/private/whoami", nil, testLogin(t, s, u))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	res := struct {
+		ID    int      `json:"id"`
+		Roles []string `json:"roles"`
+	}{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&res))
+	assert.Equal(t, u.ID, res.ID)
+	assert.Equal(t, []string{model.RoleUser, model.RoleAdmin}, res.Roles)
+}
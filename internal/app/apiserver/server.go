@@ -5,16 +5,22 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
+	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/google/uuid"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
+	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
 	"github.com/http-rest-API/internal/app/model"
 	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/telegram"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,6 +28,7 @@ const (
 	sessionName        = "braendie"
 	ctxKeyUser  ctxKey = iota
 	ctxKeyRequestID
+	ctxKeyAPIKey
 	domainURL = "http://localhost:8080"
 )
 
@@ -40,21 +47,41 @@ type ctxKey int8
 // - logger: a logger for recording server logs, using the logrus library.
 // - store: an interface for working with the data store, providing access to data models.
 // - sessionStore: an interface for working with session storage, enabling the management of user sessions.
+// - mailer: an interface for sending emails to users.
+// - signer: signs the tokens of the links that are sent to users.
+// - resendThrottle: limits how often the verification email is sent to the same user.
+// - passwordPolicy: the rules new passwords must follow.
+// - passwordHasher: hashes new passwords, hashes made by another hasher are replaced on the next login.
+// - background: tracks the work that handlers leave running after the response, such as sending a password reset.
+// - config: the configuration of the server, including the keys for signing access tokens.
 type server struct {
-	router       *mux.Router
-	logger       *logrus.Logger
-	store        store.Store
-	sessionStore sessions.Store
+	router         *mux.Router
+	logger         *logrus.Logger
+	store          store.Store
+	sessionStore   sessions.Store
+	mailer         mailer.Mailer
+	signer         *securecookie.SecureCookie
+	resendThrottle *throttle
+	passwordPolicy *model.PasswordPolicy
+	passwordHasher model.PasswordHasher
+	background     sync.WaitGroup
+	config         *Config
 }
 
-// newServer initializes a new server instance with the given store and session store,
+// newServer initializes a new server instance with the given store, session store, mailer and config,
 // sets up routing and logging middleware, and returns the server instance.
-func newServer(store store.Store, sessionStore sessions.Store) *server {
+func newServer(store store.Store, sessionStore sessions.Store, mailer mailer.Mailer, config *Config) *server {
 	s := &server{
-		router:       mux.NewRouter(),
-		logger:       logrus.New(),
-		store:        store,
-		sessionStore: sessionStore,
+		router:         mux.NewRouter(),
+		logger:         logrus.New(),
+		store:          store,
+		sessionStore:   sessionStore,
+		mailer:         mailer,
+		signer:         securecookie.New([]byte(config.SessionKey), nil).MaxAge(0),
+		resendThrottle: newThrottle(config.EmailResendInterval),
+		passwordPolicy: model.DefaultPasswordPolicy,
+		passwordHasher: model.DefaultPasswordHasher,
+		config:         config,
 	}
 
 	s.configureRouter()
@@ -75,24 +102,110 @@ func (s *server) configureRouter() {
 	s.router.Use(handlers.CORS(handlers.AllowedOrigins([]string{"*"})))
 
 	// Define public routes.
-	s.router.HandleFunc("/users", s.handleUsersCreate()).Methods("POST")
-	s.router.HandleFunc("/sessions", s.handleSessionsCreate()).Methods("POST")
+	public := s.router.NewRoute().Subrouter()
+	public.Use(s.rateLimit(s.config.RateLimits.Public))
+	public.HandleFunc("/users", s.handleUsersCreate()).Methods("POST")
+	public.HandleFunc("/sessions", s.handleSessionsCreate()).Methods("POST")
+	public.HandleFunc("/sessions", s.handleSessionsDelete()).Methods("DELETE")
+	public.HandleFunc("/sessions/2fa", s.handleSessionsTwoFactor()).Methods("POST")
+	public.HandleFunc("/logout", s.handleLogout()).Methods("GET")
+	public.HandleFunc("/tokens", s.handleTokensCreate()).Methods("POST")
+	public.HandleFunc("/tokens/refresh", s.handleTokensRefresh()).Methods("POST")
+	public.HandleFunc("/.well-known/jwks.json", s.handleJWKS()).Methods("GET")
+	public.HandleFunc("/.well-known/openid-configuration", s.handleOpenIDConfiguration()).Methods("GET")
+	public.HandleFunc("/authorize", s.handleAuthorize()).Methods("GET")
+	public.HandleFunc("/authorize/consent", s.handleAuthorizeConsentInfo()).Methods("GET")
+	public.HandleFunc("/authorize/consent", s.handleAuthorizeConsent()).Methods("POST")
+	public.HandleFunc("/token", s.handleOAuthToken()).Methods("POST")
+	public.HandleFunc("/userinfo", s.handleUserinfo()).Methods("GET", "POST")
+	public.HandleFunc("/password/forgot", s.handlePasswordForgot()).Methods("POST")
+	public.HandleFunc("/password/reset", s.handlePasswordReset()).Methods("POST")
+	public.HandleFunc("/email/verify", s.handleEmailVerify()).Methods("GET")
+	public.HandleFunc("/email/confirm", s.handleEmailConfirm()).Methods("GET")
 
 	// Define routes under /enter prefix.
 	enter := s.router.PathPrefix("/enter").Subrouter()
+	enter.Use(s.rateLimit(s.config.RateLimits.Enter))
 	enter.HandleFunc("/register", s.handleRegister()).Methods("GET")
 	enter.HandleFunc("/login", s.handleLogin()).Methods("GET")
+	enter.HandleFunc("/consent", s.handleConsent()).Methods("GET")
+	enter.HandleFunc("/reset-password", s.handleResetPassword()).Methods("GET")
 	enter.HandleFunc("/images", s.handleImage()).Methods("GET")
+	enter.HandleFunc("/oauth/{provider}", s.handleExternalLogin()).Methods("GET")
+	enter.HandleFunc("/oauth/{provider}/callback", s.handleExternalCallback()).Methods("GET")
 
 	// Define routes for Telegram-related actions.
 	telegram := s.router.PathPrefix("/telegram").Subrouter()
+	telegram.Use(s.rateLimit(s.config.RateLimits.Telegram))
 	telegram.HandleFunc("/check", s.handleTelegramCheck()).Methods("POST")
+	telegram.HandleFunc("/webapp", s.handleTelegramWebApp()).Methods("POST")
 
 	// Define private routes that require authorization.
 	private := s.router.PathPrefix("/private").Subrouter()
 	private.Use(s.authenticateUser)
+	private.Use(s.rejectScopedAPIKeys)
+	private.Use(s.rateLimit(s.config.RateLimits.Private))
 	private.HandleFunc("/whoami", s.handleWhoami()).Methods("GET")
-	private.HandleFunc("/main", s.handleMain()).Methods("GET")
+	private.HandleFunc("/email", s.handleEmailChange()).Methods("PUT")
+	private.HandleFunc("/email/resend", s.handleEmailResend()).Methods("POST")
+	private.HandleFunc("/password", s.handlePasswordChange()).Methods("PUT")
+	private.HandleFunc("/sessions", s.handleSessionsList()).Methods("GET")
+	private.HandleFunc("/sessions", s.handleSessionsRevokeOthers()).Methods("DELETE")
+	private.HandleFunc("/sessions/{id:[0-9]+}", s.handleSessionsRevoke()).Methods("DELETE")
+	private.HandleFunc("/identities/telegram", s.handleIdentitiesLinkTelegram()).Methods("POST")
+	private.HandleFunc("/identities/telegram", s.handleIdentitiesUnlinkTelegram()).Methods("DELETE")
+	private.HandleFunc("/identities/email", s.handleIdentitiesLinkEmail()).Methods("POST")
+	private.HandleFunc("/identities/email", s.handleIdentitiesUnlinkEmail()).Methods("DELETE")
+	private.HandleFunc("/2fa/setup", s.handleTwoFactorSetup()).Methods("POST")
+	private.HandleFunc("/2fa/confirm", s.handleTwoFactorConfirm()).Methods("POST")
+	private.HandleFunc("/2fa", s.handleTwoFactorDisable()).Methods("DELETE")
+	private.HandleFunc("/export", s.handleAccountExport()).Methods("GET")
+	private.HandleFunc("/account", s.handleAccountDelete()).Methods("DELETE")
+	private.HandleFunc("/grants", s.handleGrantsList()).Methods("GET")
+	private.HandleFunc("/grants/{client_id}", s.handleGrantsRevoke()).Methods("DELETE")
+	private.HandleFunc("/activity", s.handleActivity()).Methods("GET")
+
+	// Define private routes that also require a verified email.
+	verified := private.NewRoute().Subrouter()
+	verified.Use(s.requireVerifiedEmail)
+	verified.HandleFunc("/main", s.handleMain()).Methods("GET")
+	verified.HandleFunc("/api-keys", s.handleAPIKeysCreate()).Methods("POST")
+	verified.HandleFunc("/api-keys", s.handleAPIKeysList()).Methods("GET")
+	verified.HandleFunc("/api-keys/{id:[0-9]+}", s.handleAPIKeysDelete()).Methods("DELETE")
+
+	// Define admin routes that require the permission to see users.
+	admin := s.router.PathPrefix("/admin").Subrouter()
+	admin.Use(s.authenticateUser)
+	admin.Use(s.rateLimit(s.config.RateLimits.Private))
+	admin.Use(s.requirePermission(model.PermissionUsersRead))
+	admin.HandleFunc("/users", s.handleAdminUsersList()).Methods("GET")
+	admin.HandleFunc("/users/{id:[0-9]+}", s.handleAdminUsersGet()).Methods("GET")
+
+	// Define admin routes that also require the permission to change users.
+	adminWrite := admin.NewRoute().Subrouter()
+	adminWrite.Use(s.requirePermission(model.PermissionUsersWrite))
+	adminWrite.HandleFunc("/users/{id:[0-9]+}/disable", s.handleAdminUsersSetDisabled(true)).Methods("POST")
+	adminWrite.HandleFunc("/users/{id:[0-9]+}/enable", s.handleAdminUsersSetDisabled(false)).Methods("POST")
+	adminWrite.HandleFunc("/users/{id:[0-9]+}/password-reset", s.handleAdminUsersPasswordReset()).Methods("POST")
+	adminWrite.HandleFunc("/users/{id:[0-9]+}", s.handleAdminUsersDelete()).Methods("DELETE")
+
+	// Define admin routes that require the permission to assign roles.
+	adminRoles := admin.NewRoute().Subrouter()
+	adminRoles.Use(s.requirePermission(model.PermissionRolesWrite))
+	adminRoles.HandleFunc("/users/{id:[0-9]+}/roles/{role}", s.handleAdminUsersSetRole(true)).Methods("PUT")
+	adminRoles.HandleFunc("/users/{id:[0-9]+}/roles/{role}", s.handleAdminUsersSetRole(false)).Methods("DELETE")
+
+	// Define admin routes that require the permission to register OAuth clients.
+	adminClients := admin.NewRoute().Subrouter()
+	adminClients.Use(s.requirePermission(model.PermissionClientsWrite))
+	adminClients.HandleFunc("/clients", s.handleAdminClientsCreate()).Methods("POST")
+	adminClients.HandleFunc("/clients", s.handleAdminClientsList()).Methods("GET")
+	adminClients.HandleFunc("/clients/{id:[0-9]+}", s.handleAdminClientsDelete()).Methods("DELETE")
+
+	// Define admin routes that require the permission to read the audit log.
+	adminAudit := admin.NewRoute().Subrouter()
+	adminAudit.Use(s.requirePermission(model.PermissionAuditRead))
+	adminAudit.HandleFunc("/audit-events", s.handleAdminAuditEvents()).Methods("GET")
 }
 
 // setRequestID adds a unique request ID to each incoming request for tracking purposes.
@@ -126,30 +239,79 @@ func (s *server) logRequest(next http.Handler) http.Handler {
 	})
 }
 
-// authenticateUser checks if the user is authenticated by verifying the session.
-// If the session is valid, the user information is added to the request context.
+// authenticateUser checks if the user is authenticated by verifying the API key, the bearer access token or the session.
+// If the credential is valid, the user information (and the used API key) is added to the request context.
 func (s *server) authenticateUser(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		session, err := s.sessionStore.Get(r, sessionName)
+		ctx := r.Context()
+		var u *model.User
+		var err error
+		if key, ok := apiKeyFromRequest(r); ok {
+			var k *model.APIKey
+			u, k, err = s.userFromAPIKey(r, key)
+			ctx = context.WithValue(ctx, ctxKeyAPIKey, k)
+		} else if token, ok := bearerToken(r); ok {
+			u, err = s.userFromAccessToken(token)
+		} else {
+			u, err = s.userFromSession(r)
+		}
+
 		if err != nil {
+			if err == errNotAuthenticated {
+				s.error(w, r, http.StatusUnauthorized, err)
+				return
+			}
+
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
-		id, ok := session.Values["user_id"]
-		if !ok {
-			s.error(w, r, http.StatusUnauthorized, errNotAuthenticated)
+		if u.IsDisabled() {
+			s.error(w, r, http.StatusForbidden, errAccountDisabled)
 			return
 		}
 
-		u, err := s.store.User().Find(id.(int))
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, ctxKeyUser, u)))
+	})
+}
+
+// userFromSession finds the user of the current session.
+// It returns errNotAuthenticated if there is no valid session, such as one that started before all sessions of the user were ended.
+func (s *server) userFromSession(r *http.Request) (*model.User, error) {
+	session, err := s.sessionStore.Get(r, sessionName)
+	if err != nil {
+		return nil, err
+	}
+
+	id, ok := session.Values["user_id"]
+	if !ok {
+		return nil, errNotAuthenticated
+	}
+
+	if sid, ok := session.Values["session_id"].(string); ok {
+		revoked, err := s.store.Revocation().IsRevoked(sid)
 		if err != nil {
-			s.error(w, r, http.StatusUnauthorized, errNotAuthenticated)
-			return
+			return nil, err
 		}
 
-		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKeyUser, u)))
-	})
+		if revoked {
+			return nil, errNotAuthenticated
+		}
+	}
+
+	u, err := s.store.User().Find(id.(int))
+	if err != nil {
+		return nil, errNotAuthenticated
+	}
+
+	if u.SessionsValidAfter.Valid {
+		startedAt, ok := session.Values["started_at"].(int64)
+		if !ok || time.Unix(0, startedAt).Before(u.SessionsValidAfter.Time) {
+			return nil, errNotAuthenticated
+		}
+	}
+
+	return u, nil
 }
 
 // handleMain serves the main page (HTML).
@@ -173,10 +335,36 @@ func (s *server) handleLogin() http.HandlerFunc {
 	}
 }
 
-// handleWhoami responds with information about the currently authenticated user.
+// handleConsent serves the page where the user authorizes an OAuth client (HTML).
+func (s *server) handleConsent() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.sendHtmlFile(w, r, "consent")
+	}
+}
+
+// handleResetPassword serves the page where the user sets a new password by the token of the password reset link (HTML).
+func (s *server) handleResetPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.sendHtmlFile(w, r, "reset-password")
+	}
+}
+
+// handleWhoami responds with information about the currently authenticated user and the names of the user's roles.
 func (s *server) handleWhoami() http.HandlerFunc {
+	type response struct {
+		*model.User
+		Roles []string `json:"roles"`
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
-		s.respond(w, r, http.StatusOK, r.Context().Value(ctxKeyUser).(*model.User))
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		roles, err := s.store.Role().FindByUser(u.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{User: u, Roles: model.RoleNames(roles)})
 	}
 }
 
@@ -201,43 +389,27 @@ func (s *server) handleImage() http.HandlerFunc {
 	}
 }
 
-// handleTelegramCheck checks if a user exists based on their Telegram ID,
-// and either logs them in or creates a new user.
+// handleTelegramCheck verifies the Telegram Login Widget data and finds the user by the Telegram ID,
+// then either logs them in or creates a new user.
 func (s *server) handleTelegramCheck() http.HandlerFunc {
-	type request struct {
-		IDTelegram int `json:"id_telegram"`
-	}
-
 	return func(w http.ResponseWriter, r *http.Request) {
-		req := &request{}
-		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		data := &telegram.LoginData{}
+		if err := json.NewDecoder(r.Body).Decode(data); err != nil {
 			s.error(w, r, http.StatusBadRequest, err)
 			return
 		}
 
-		u, err := s.store.User().FindByIDTelegram(req.IDTelegram)
-		if err != nil {
-			if err == store.ErrRecordNotFound {
-				u := &model.User{
-					IDTelegram: sql.NullInt64{Int64: int64(req.IDTelegram), Valid: true},
-				}
-				if err := s.store.User().Create(u); err != nil {
-					s.error(w, r, http.StatusUnprocessableEntity, err)
-					return
-				}
-
-				s.createSessions(w, r, u)
-				http.Redirect(w, r, domainURL+"/private/main", http.StatusFound)
+		if err := data.Verify(s.config.TelegramBotToken, s.config.TelegramAuthMaxAge, time.Now()); err != nil {
+			if err == telegram.ErrNoBotToken {
+				s.error(w, r, http.StatusInternalServerError, err)
 				return
 			}
 
-			s.error(w, r, http.StatusInternalServerError, err)
-
+			s.error(w, r, http.StatusUnauthorized, err)
+			return
 		}
 
-		s.createSessions(w, r, u)
-		s.respond(w, r, http.StatusFound, u)
-		http.Redirect(w, r, domainURL+"/private/main", http.StatusFound)
+		s.telegramLogin(w, r, data.User())
 	}
 }
 
@@ -261,21 +433,28 @@ func (s *server) handleUsersCreate() http.HandlerFunc {
 			return
 		}
 
-		if !model.CheckPassword(req.Password) {
-			s.error(w, r, http.StatusBadRequest, errEasyPassword)
+		if !s.checkPassword(w, r, req.Password, req.Email) {
 			return
 		}
 
 		u := &model.User{
-			IDTelegram: sql.NullInt64{Valid: false},
-			Email:      sql.NullString{String: req.Email, Valid: req.Email != ""},
-			Password:   req.Password,
+			IDTelegram:     sql.NullInt64{Valid: false},
+			Email:          sql.NullString{String: req.Email, Valid: req.Email != ""},
+			Password:       req.Password,
+			PasswordHasher: s.passwordHasher,
 		}
 		if err := s.store.User().Create(u); err != nil {
+			if err == store.ErrEmailTaken {
+				s.error(w, r, http.StatusConflict, err)
+				return
+			}
+
 			s.error(w, r, http.StatusUnprocessableEntity, err)
 			return
 		}
 
+		s.audit(r, model.AuditRegister, u.ID, 0)
+		s.sendEmailVerificationOrLog(r, u)
 		u.Sanitize()
 		s.respond(w, r, http.StatusCreated, u)
 	}
@@ -296,16 +475,68 @@ func (s *server) handleSessionsCreate() http.HandlerFunc {
 		}
 
 		u, err := s.store.User().FindByEmail(req.Email)
-		if err != nil || !u.ComparePassword(req.Password) {
-			s.error(w, r, http.StatusUnauthorized, errIncorrectEmailOrPassword)
+		if err != nil && err != store.ErrRecordNotFound {
+			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
 
+		if !s.checkLoginAttempts(w, r, req.Email, u) {
+			return
+		}
+
+		if u == nil || !u.ComparePassword(req.Password) {
+			s.failLogin(w, r, req.Email, u, errIncorrectEmailOrPassword)
+			return
+		}
+
+		s.rehashPassword(r, u, req.Password)
+
+		if u.IsDisabled() {
+			s.error(w, r, http.StatusForbidden, errAccountDisabled)
+			return
+		}
+
+		if s.isLoginBlocked(r, u) {
+			s.error(w, r, http.StatusForbidden, errEmailNotVerified)
+			return
+		}
+
+		if u.HasTwoFactor() {
+			s.respondTwoFactorChallenge(w, r, u)
+			return
+		}
+
+		s.resetLoginAttempts(r, u)
+		s.audit(r, model.AuditLogin, u.ID, 0)
 		s.createSessions(w, r, u)
 		s.respond(w, r, http.StatusOK, nil)
 	}
 }
 
+// handleSessionsDelete ends the current session.
+func (s *server) handleSessionsDelete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.logout(w, r); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// handleLogout ends the current session and redirects the browser to the login page.
+func (s *server) handleLogout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.logout(w, r); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		http.Redirect(w, r, domainURL+"/enter/login", http.StatusFound)
+	}
+}
+
 // error calls respond function with error.
 func (s *server) error(w http.ResponseWriter, r *http.Request, code int, err error) {
 	s.respond(w, r, code, map[string]string{"error": err.Error()})
@@ -320,6 +551,23 @@ func (s *server) respond(w http.ResponseWriter, r *http.Request, code int, data
 	}
 }
 
+// userUpdateError responds with the status that fits the error of UserRepository.Update.
+func (s *server) userUpdateError(w http.ResponseWriter, r *http.Request, err error) {
+	if _, ok := err.(validation.Errors); ok {
+		s.error(w, r, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	switch err {
+	case store.ErrEmailTaken, store.ErrTelegramTaken:
+		s.error(w, r, http.StatusConflict, err)
+	case store.ErrRecordNotFound:
+		s.error(w, r, http.StatusNotFound, err)
+	default:
+		s.error(w, r, http.StatusInternalServerError, err)
+	}
+}
+
 // sendHtmlFile gives HTML file which is stored in specified folder.
 func (s *server) sendHtmlFile(w http.ResponseWriter, r *http.Request, htmlName string) {
 	filePath := "D:/GitHubProjects/http-rest-API/internal/app/htmlfiles/" + htmlName + ".html"
@@ -334,15 +582,63 @@ func (s *server) sendHtmlFile(w http.ResponseWriter, r *http.Request, htmlName s
 	http.ServeFile(w, r, filePath)
 }
 
-// createSessions creates a session for the authenticated user.
+// createSessions creates a session for the authenticated user and cancels the pending deletion of the account.
 func (s *server) createSessions(w http.ResponseWriter, r *http.Request, u *model.User) {
-	session := sessions.NewSession(s.sessionStore, sessionName)
-
-	session.Values["user_id"] = u.ID
-	if err := s.sessionStore.Save(r, w, session); err != nil {
+	if err := s.startSession(w, r, u); err != nil {
 		s.error(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
 	s.respond(w, r, http.StatusOK, nil)
 }
+
+// startSession saves a new session for the user and cancels the pending deletion of the account,
+// the caller writes the response.
+func (s *server) startSession(w http.ResponseWriter, r *http.Request, u *model.User) error {
+	s.cancelAccountDeletion(r, u)
+
+	session := sessions.NewSession(s.sessionStore, sessionName)
+
+	session.Values["user_id"] = u.ID
+	session.Values["session_id"] = uuid.New().String()
+	session.Values["started_at"] = time.Now().UnixNano()
+
+	return s.sessionStore.Save(r, w, session)
+}
+
+// logout records the logout of the user of the current session and ends the session.
+func (s *server) logout(w http.ResponseWriter, r *http.Request) error {
+	if u, err := s.userFromSession(r); err == nil {
+		s.audit(r, model.AuditLogout, u.ID, 0)
+	}
+
+	return s.deleteSession(w, r)
+}
+
+// deleteSession revokes the current session and expires its cookie.
+func (s *server) deleteSession(w http.ResponseWriter, r *http.Request) error {
+	session, err := s.sessionStore.Get(r, sessionName)
+	if err != nil {
+		return err
+	}
+
+	if sid, ok := session.Values["session_id"].(string); ok {
+		err := s.store.Revocation().Revoke(sid, time.Now().Add(time.Duration(session.Options.MaxAge)*time.Second))
+		if err != nil && err != store.ErrRecordNotFound {
+			return err
+		}
+	}
+
+	session.Options.MaxAge = -1
+	return s.sessionStore.Save(r, w, session)
+}
+
+// clientIP returns the address of the client without the port.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -2,16 +2,24 @@ package apiserver
 
 import (
 	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
 	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/sessionstore"
 	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/http-rest-API/internal/app/telegram"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -40,7 +48,7 @@ func TestServer_AuthenticateUser(t *testing.T) {
 	}
 
 	secretKey := []byte("secret")
-	s := newServer(store, sessions.NewCookieStore(secretKey))
+	s := newServer(store, sessions.NewCookieStore(secretKey), mailer.TestMailer(t, io.Discard), NewConfig())
 	sc := securecookie.New(secretKey, nil)
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -59,7 +67,7 @@ func TestServer_AuthenticateUser(t *testing.T) {
 }
 
 func TestServer_handleUsersCreate(t *testing.T) {
-	s := newServer(teststore.New(), sessions.NewCookieStore([]byte("secret")))
+	s := newServer(teststore.New(), sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
 	testCases := []struct {
 		name         string
 		payload      interface{}
@@ -75,6 +83,15 @@ func TestServer_handleUsersCreate(t *testing.T) {
 			},
 			expectedCode: http.StatusCreated,
 		},
+		{
+			name: "taken email",
+			payload: map[string]interface{}{
+				"email":            "user@example.org",
+				"password":         "Password123",
+				"confirm_password": "Password123",
+			},
+			expectedCode: http.StatusConflict,
+		},
 		{
 			name: "valid with email password but invalid confirm password",
 			payload: map[string]interface{}{
@@ -124,7 +141,7 @@ func TestServer_handleSessionsCreate(t *testing.T) {
 	u := model.TestUser(t)
 	store := teststore.New()
 	store.User().Create(u)
-	s := newServer(store, sessions.NewCookieStore([]byte("secret")))
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
 	testCases := []struct {
 		name         string
 		payload      interface{}
@@ -174,18 +191,49 @@ func TestServer_handleSessionsCreate(t *testing.T) {
 }
 
 func TestServer_handleTelegramCheck(t *testing.T) {
-	s := newServer(teststore.New(), sessions.NewCookieStore([]byte("secret")))
+	const botToken = "123456:test-bot-token"
+	config := NewConfig()
+	config.TelegramBotToken = botToken
+	config.TelegramAuthMaxAge = time.Hour
+	s := newServer(teststore.New(), sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), config)
+	now := time.Now()
+
 	testCases := []struct {
 		name         string
 		payload      interface{}
 		expectedCode int
 	}{
 		{
-			name: "valid",
+			name:         "valid",
+			payload:      telegram.TestLoginData(t, botToken, 12345678, now),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "valid existing user",
+			payload:      telegram.TestLoginData(t, botToken, 12345678, now),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "tampered",
+			payload: func() *telegram.LoginData {
+				d := telegram.TestLoginData(t, botToken, 12345678, now)
+				d.ID = 87654321
+
+				return d
+			}(),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "expired",
+			payload:      telegram.TestLoginData(t, botToken, 12345678, now.Add(-2*time.Hour)),
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "id only",
 			payload: map[string]int{
 				"id_telegram": 12345678,
 			},
-			expectedCode: http.StatusOK,
+			expectedCode: http.StatusUnauthorized,
 		},
 		{
 			name:         "invalid payload",
@@ -193,10 +241,8 @@ func TestServer_handleTelegramCheck(t *testing.T) {
 			expectedCode: http.StatusBadRequest,
 		},
 		{
-			name: "invalid params",
-			payload: map[string]interface{}{
-				"id_telegram": -1,
-			},
+			name:         "invalid params",
+			payload:      telegram.TestLoginData(t, botToken, -1, now),
 			expectedCode: http.StatusUnprocessableEntity,
 		},
 	}
@@ -212,3 +258,123 @@ func TestServer_handleTelegramCheck(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_handleSessionsDelete(t *testing.T) {
+	u := model.TestUser(t)
+	store := teststore.New()
+	store.User().Create(u)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	testCases := []struct {
+		name         string
+		sessionStore sessions.Store
+		method       string
+		path         string
+		expectedCode int
+	}{
+		{
+			name:         "delete cookie session",
+			sessionStore: sessions.NewCookieStore([]byte("secret")),
+			method:       http.MethodDelete,
+			path:         "/sessions",
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "logout cookie session",
+			sessionStore: sessions.NewCookieStore([]byte("secret")),
+			method:       http.MethodGet,
+			path:         "/logout",
+			expectedCode: http.StatusFound,
+		},
+		{
+			name:         "delete database session",
+			sessionStore: sessionstore.New(store.Session(), []byte("secret")),
+			method:       http.MethodDelete,
+			path:         "/sessions",
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:         "logout database session",
+			sessionStore: sessionstore.New(store.Session(), []byte("secret")),
+			method:       http.MethodGet,
+			path:         "/logout",
+			expectedCode: http.StatusFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newServer(store, tc.sessionStore, mailer.TestMailer(t, io.Discard), NewConfig())
+			req, _ := http.NewRequest(tc.method, tc.path, nil)
+			for _, c := range testLogin(t, s, u) {
+				req.AddCookie(c)
+			}
+
+			rec := httptest.NewRecorder()
+			s.authenticateUser(handler).ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			rec = httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+			cookies := rec.Result().Cookies()
+			if assert.NotEmpty(t, cookies) {
+				assert.True(t, cookies[0].MaxAge < 0)
+			}
+
+			rec = httptest.NewRecorder()
+			s.authenticateUser(handler).ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		})
+	}
+}
+
+// testLogin signs the user in with email and password and returns the session cookies.
+func testLogin(t *testing.T, s *server, u *model.User) []*http.Cookie {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]string{
+		"email":    u.Email.String,
+		"password": u.Password,
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/sessions", b)
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login failed with %d", rec.Code)
+	}
+
+	return rec.Result().Cookies()
+}
+
+var (
+	testKeyOnce sync.Once
+	testKey     *rsa.PrivateKey
+)
+
+// testConfig returns a config with a signing key generated for tests.
+func testConfig(t *testing.T) *Config {
+	t.Helper()
+
+	testKeyOnce.Do(func() {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testKey = key
+	})
+
+	config := NewConfig()
+	config.SigningKeys = []*SigningKey{
+		{
+			KID:        "test",
+			privateKey: testKey,
+		},
+	}
+
+	return config
+}
@@ -0,0 +1,180 @@
+package apiserver
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+var (
+	errSessionRequired = errors.New("other sessions can only be ended from a session, not with an API key or an access token")
+)
+
+// sessionInfo is a session record as it is shown to its owner.
+type sessionInfo struct {
+	ID         int       `json:"id"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+// newSessionInfo returns the session record as it is shown to its owner.
+func newSessionInfo(rec *model.Session, current int) *sessionInfo {
+	return &sessionInfo{
+		ID:         rec.ID,
+		IP:         rec.IP,
+		UserAgent:  rec.UserAgent,
+		CreatedAt:  rec.CreatedAt,
+		LastSeenAt: rec.LastSeenAt,
+		Current:    rec.ID == current,
+	}
+}
+
+// handleSessionsList responds with the active sessions of the current user.
+func (s *server) handleSessionsList() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		current, err := s.currentSessionID(r)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		records, err := s.store.Session().FindByUser(u.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		now := time.Now()
+		list := []*sessionInfo{}
+		for _, rec := range records {
+			if rec.IsExpired(now) {
+				continue
+			}
+
+			list = append(list, newSessionInfo(rec, current))
+		}
+
+		s.respond(w, r, http.StatusOK, list)
+	}
+}
+
+// handleSessionsRevoke ends one session of the current user.
+func (s *server) handleSessionsRevoke() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		id, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		rec, err := s.store.Session().Find(id)
+		if err != nil || rec.UserID != u.ID {
+			s.error(w, r, http.StatusNotFound, store.ErrRecordNotFound)
+			return
+		}
+
+		current, err := s.currentSessionID(r)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if id == current {
+			err = s.deleteSession(w, r)
+		} else {
+			err = s.store.Session().Delete(id)
+		}
+		if err != nil && err != store.ErrRecordNotFound {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// handleSessionsRevokeOthers ends all sessions of the current user except the current one.
+// A request without a session is rejected, it has no session to keep.
+func (s *server) handleSessionsRevokeOthers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !usesSession(r) {
+			s.error(w, r, http.StatusForbidden, errSessionRequired)
+			return
+		}
+
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		if err := s.endOtherSessions(w, r, u); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// endOtherSessions ends all sessions of the user except the one the request is made with, if any.
+// The sessions that are kept only in cookies are ended by the time they had to start after,
+// so the current session is started again after that time.
+func (s *server) endOtherSessions(w http.ResponseWriter, r *http.Request, u *model.User) error {
+	current := 0
+	if usesSession(r) {
+		var err error
+		if current, err = s.currentSessionID(r); err != nil {
+			return err
+		}
+	}
+
+	if err := s.store.User().SetSessionsValidAfter(u.ID, time.Now().Truncate(time.Microsecond)); err != nil {
+		return err
+	}
+
+	if err := s.store.Session().DeleteByUser(u.ID, current); err != nil {
+		return err
+	}
+
+	if !usesSession(r) {
+		return nil
+	}
+
+	session, err := s.sessionStore.Get(r, sessionName)
+	if err != nil {
+		return err
+	}
+
+	session.Values["started_at"] = time.Now().UnixNano()
+	return s.sessionStore.Save(r, w, session)
+}
+
+// usesSession reports whether the request is authenticated with the session cookie
+// and not with an API key or an access token.
+func usesSession(r *http.Request) bool {
+	_, withAPIKey := apiKeyFromRequest(r)
+	_, withToken := bearerToken(r)
+
+	return !withAPIKey && !withToken
+}
+
+// currentSessionID returns the id of the session record used by the request.
+// It returns 0 if the session is not kept as a record, e.g. with the cookie backend.
+func (s *server) currentSessionID(r *http.Request) (int, error) {
+	session, err := s.sessionStore.Get(r, sessionName)
+	if err != nil {
+		return 0, err
+	}
+
+	if session.ID == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(session.ID)
+}
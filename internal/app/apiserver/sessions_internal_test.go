@@ -0,0 +1,193 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/sessionstore"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleSessionsList(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessionstore.New(store.Session(), []byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
+
+	testLogin(t, s, u)
+	cookies := testLogin(t, s, u)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/private/sessions", nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	list := []*sessionInfo{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&list))
+	assert.Len(t, list, 2)
+
+	current := 0
+	for _, si := range list {
+		if si.Current {
+			current++
+		}
+	}
+	assert.Equal(t, 1, current)
+}
+
+func TestServer_handleSessionsRevoke(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	other := model.TestUser(t)
+	other.Email.String = "other@example.org"
+	store.User().Create(other)
+	s := newServer(store, sessionstore.New(store.Session(), []byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
+
+	victim := testLogin(t, s, u)
+	cookies := testLogin(t, s, u)
+	testLogin(t, s, other)
+
+	list, _ := store.Session().FindByUser(u.ID)
+	victimID := list[0].ID
+	for _, rec := range list {
+		if rec.ID < victimID {
+			victimID = rec.ID
+		}
+	}
+	otherList, _ := store.Session().FindByUser(other.ID)
+
+	testCases := []struct {
+		name         string
+		id           int
+		expectedCode int
+	}{
+		{
+			name:         "session of another user",
+			id:           otherList[0].ID,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "unknown session",
+			id:           100,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "own session",
+			id:           victimID,
+			expectedCode: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/private/sessions/%d", tc.id), nil)
+			for _, c := range cookies {
+				req.AddCookie(c)
+			}
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/private/whoami", nil)
+	for _, c := range victim {
+		req.AddCookie(c)
+	}
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestServer_handleSessionsRevokeOthers(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessionstore.New(store.Session(), []byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
+
+	other := testLogin(t, s, u)
+	cookies := testLogin(t, s, u)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/private/sessions", nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	testCases := []struct {
+		name         string
+		cookies      []*http.Cookie
+		expectedCode int
+	}{
+		{
+			name:         "current session",
+			cookies:      cookies,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "other session",
+			cookies:      other,
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/private/whoami", nil)
+			for _, c := range tc.cookies {
+				req.AddCookie(c)
+			}
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_handleSessionsRevokeOthersWithoutSession(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessionstore.New(store.Session(), []byte("secret")), mailer.TestMailer(t, io.Discard), testConfig(t))
+
+	cookies := testLogin(t, s, u)
+	tokens, err := s.issueTokens(u, "family")
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/private/sessions", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = testRequest(t, s, http.MethodGet, "/private/whoami", nil, cookies)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestServer_handleSessionsRevokeOthersWithCookieSessions(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
+
+	other := testLogin(t, s, u)
+	rec := testRequest(t, s, http.MethodDelete, "/private/sessions", nil, testLogin(t, s, u))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	assert.Equal(t, http.StatusUnauthorized, testRequest(t, s, http.MethodGet, "/private/whoami", nil, other).Code)
+	assert.Equal(t, http.StatusOK, testRequest(t, s, http.MethodGet, "/private/whoami", nil, rec.Result().Cookies()).Code)
+}
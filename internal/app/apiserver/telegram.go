@@ -0,0 +1,103 @@
+package apiserver
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/telegram"
+)
+
+// handleTelegramWebApp verifies the init data of the Telegram Mini App and finds the user by the Telegram ID,
+// then either logs them in or creates a new user.
+func (s *server) handleTelegramWebApp() http.HandlerFunc {
+	type request struct {
+		InitData string `json:"init_data"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		data, err := telegram.ParseWebAppInitData(req.InitData, s.config.TelegramBotToken, s.config.TelegramAuthMaxAge, time.Now())
+		if err != nil {
+			if err == telegram.ErrNoBotToken {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			s.error(w, r, http.StatusUnauthorized, err)
+			return
+		}
+
+		s.telegramLogin(w, r, data.User)
+	}
+}
+
+// telegramLogin finds the user by the verified Telegram account or creates a new one,
+// saves the Telegram profile on it and creates a session.
+// A user with the second factor gets a challenge instead, as with the login by password.
+func (s *server) telegramLogin(w http.ResponseWriter, r *http.Request, tu *telegram.User) {
+	u, err := s.store.User().FindByIDTelegram(int(tu.ID))
+	if err != nil {
+		if err != store.ErrRecordNotFound {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		u = &model.User{
+			IDTelegram: sql.NullInt64{Int64: tu.ID, Valid: true},
+		}
+		setTelegramProfile(u, tu)
+		if err := s.store.User().Create(u); err != nil {
+			if err == store.ErrTelegramTaken {
+				s.error(w, r, http.StatusConflict, err)
+				return
+			}
+
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.audit(r, model.AuditRegister, u.ID, 0)
+	} else {
+		if u.IsDisabled() {
+			s.error(w, r, http.StatusForbidden, errAccountDisabled)
+			return
+		}
+
+		setTelegramProfile(u, tu)
+		if err := s.store.User().UpdateTelegramProfile(u); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if u.HasTwoFactor() {
+			s.respondTwoFactorChallenge(w, r, u)
+			return
+		}
+	}
+
+	s.audit(r, model.AuditTelegramLogin, u.ID, 0)
+	s.createSessions(w, r, u)
+}
+
+// setTelegramProfile copies the profile of the Telegram account to the user.
+func setTelegramProfile(u *model.User, tu *telegram.User) {
+	u.TelegramFirstName = nullString(tu.FirstName)
+	u.TelegramLastName = nullString(tu.LastName)
+	u.TelegramUsername = nullString(tu.Username)
+	u.TelegramPhotoURL = nullString(tu.PhotoURL)
+	u.TelegramLanguageCode = nullString(tu.LanguageCode)
+}
+
+// nullString returns the string as a NULL value if it is empty.
+func nullString(str string) sql.NullString {
+	return sql.NullString{String: str, Valid: str != ""}
+}
@@ -0,0 +1,133 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/http-rest-API/internal/app/telegram"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleTelegramWebApp(t *testing.T) {
+	const botToken = "123456:test-bot-token"
+	config := NewConfig()
+	config.TelegramBotToken = botToken
+	config.TelegramAuthMaxAge = time.Hour
+	store := teststore.New()
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), config)
+	now := time.Now()
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		expectedCode int
+	}{
+		{
+			name: "valid",
+			payload: map[string]string{
+				"init_data": telegram.TestWebAppInitData(t, botToken, 12345678, now),
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "valid existing user",
+			payload: map[string]string{
+				"init_data": telegram.TestWebAppInitData(t, botToken, 12345678, now),
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "tampered",
+			payload: map[string]string{
+				"init_data": strings.Replace(telegram.TestWebAppInitData(t, botToken, 12345678, now), "12345678", "87654321", 1),
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "wrong bot token",
+			payload: map[string]string{
+				"init_data": telegram.TestWebAppInitData(t, "654321:other-bot-token", 12345678, now),
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "expired",
+			payload: map[string]string{
+				"init_data": telegram.TestWebAppInitData(t, botToken, 12345678, now.Add(-2*time.Hour)),
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "invalid payload",
+			payload:      "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			json.NewEncoder(b).Encode(tc.payload)
+			req, _ := http.NewRequest(http.MethodPost, "/telegram/webapp", b)
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+
+	u, err := store.User().FindByIDTelegram(12345678)
+	assert.NoError(t, err)
+	assert.Equal(t, "john", u.TelegramUsername.String)
+	assert.Equal(t, "en", u.TelegramLanguageCode.String)
+	assert.False(t, u.TelegramLastName.Valid)
+}
+
+func TestServer_handleTelegramWebAppWithoutBotToken(t *testing.T) {
+	s := newServer(teststore.New(), sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), NewConfig())
+	rec := httptest.NewRecorder()
+	b := &bytes.Buffer{}
+	json.NewEncoder(b).Encode(map[string]string{
+		"init_data": telegram.TestWebAppInitData(t, "123456:test-bot-token", 12345678, time.Now()),
+	})
+	req, _ := http.NewRequest(http.MethodPost, "/telegram/webapp", b)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestServer_handleTelegramWebAppTwoFactor(t *testing.T) {
+	const botToken = "123456:test-bot-token"
+	config := testTwoFactorConfig(t)
+	config.TelegramBotToken = botToken
+	config.TelegramAuthMaxAge = time.Hour
+	s := newServer(teststore.New(), sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), config)
+	payload := map[string]string{
+		"init_data": telegram.TestWebAppInitData(t, botToken, 12345678, time.Now()),
+	}
+
+	rec := testRequest(t, s, http.MethodPost, "/telegram/webapp", payload, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	secret, _ := testEnableTwoFactor(t, s, rec.Result().Cookies())
+
+	rec = testRequest(t, s, http.MethodPost, "/telegram/webapp", payload, nil)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Empty(t, rec.Result().Cookies())
+
+	res := map[string]string{}
+	json.NewDecoder(rec.Body).Decode(&res)
+	code, _ := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+	rec = testRequest(t, s, http.MethodPost, "/sessions/2fa", map[string]string{
+		"challenge": res["challenge"],
+		"code":      code,
+	}, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
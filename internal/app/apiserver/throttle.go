@@ -0,0 +1,41 @@
+package apiserver
+
+import (
+	"sync"
+	"time"
+)
+
+// throttle allows an action for the same key at most once per interval.
+type throttle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     map[string]time.Time
+}
+
+// newThrottle returns a throttle with the given interval.
+func newThrottle(interval time.Duration) *throttle {
+	return &throttle{
+		interval: interval,
+		last:     make(map[string]time.Time),
+	}
+}
+
+// allow reports whether the action for the key is allowed at the given time and records it if so.
+// Otherwise it returns how long to wait before the action is allowed again.
+func (t *throttle) allow(key string, now time.Time) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for k, last := range t.last {
+		if now.Sub(last) >= t.interval {
+			delete(t.last, k)
+		}
+	}
+
+	if last, ok := t.last[key]; ok {
+		return t.interval - now.Sub(last), false
+	}
+
+	t.last[key] = now
+	return 0, true
+}
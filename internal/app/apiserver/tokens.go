@@ -0,0 +1,361 @@
+package apiserver
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/gorilla/securecookie"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	accessTokenType = "at+jwt"
+)
+
+var (
+	errTokensNotConfigured = errors.New("token signing is not configured")
+	errUnknownSigningKey   = errors.New("unknown signing key")
+	errInvalidTokenType    = errors.New("invalid token type")
+	errInvalidRefreshToken = errors.New("invalid refresh token")
+)
+
+// tokenResponse is the response of the endpoints that issue tokens.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// handleTokensCreate issues an access token and a refresh token in exchange for email and password.
+// If no email is given, the current session is used instead.
+func (s *server) handleTokensCreate() http.HandlerFunc {
+	type request struct {
+		Email        string `json:"email"`
+		Password     string `json:"password"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		var u *model.User
+		if req.Email != "" {
+			var err error
+			u, err = s.store.User().FindByEmail(req.Email)
+			if err != nil && err != store.ErrRecordNotFound {
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			if !s.checkLoginAttempts(w, r, req.Email, u) {
+				return
+			}
+
+			if u == nil || !u.ComparePassword(req.Password) {
+				s.failLogin(w, r, req.Email, u, errIncorrectEmailOrPassword)
+				return
+			}
+
+			s.rehashPassword(r, u, req.Password)
+
+			if u.IsDisabled() {
+				s.error(w, r, http.StatusForbidden, errAccountDisabled)
+				return
+			}
+
+			if s.isLoginBlocked(r, u) {
+				s.error(w, r, http.StatusForbidden, errEmailNotVerified)
+				return
+			}
+
+			if u.HasTwoFactor() {
+				if err := s.verifySecondFactor(u, req.Code, req.RecoveryCode); err != nil {
+					if err == errInvalidTwoFactorCode {
+						s.failLogin(w, r, req.Email, u, err)
+						return
+					}
+
+					s.secondFactorError(w, r, err, http.StatusUnauthorized)
+					return
+				}
+			}
+
+			s.resetLoginAttempts(r, u)
+			s.audit(r, model.AuditLogin, u.ID, 0)
+		} else {
+			var err error
+			u, err = s.userFromSession(r)
+			if err != nil {
+				if err == errNotAuthenticated {
+					s.error(w, r, http.StatusUnauthorized, err)
+					return
+				}
+
+				s.error(w, r, http.StatusInternalServerError, err)
+				return
+			}
+
+			if u.IsDisabled() {
+				s.error(w, r, http.StatusForbidden, errAccountDisabled)
+				return
+			}
+		}
+
+		s.cancelAccountDeletion(r, u)
+		res, err := s.issueTokens(u, uuid.New().String())
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, res)
+	}
+}
+
+// handleTokensRefresh exchanges a refresh token for a new access token and a new refresh token.
+// Presenting a refresh token that was already exchanged revokes all tokens of its family.
+func (s *server) handleTokensRefresh() http.HandlerFunc {
+	type request struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		rt, err := s.store.RefreshToken().FindByTokenHash(hashToken(req.RefreshToken))
+		if err != nil {
+			if err == store.ErrRecordNotFound {
+				s.error(w, r, http.StatusUnauthorized, errInvalidRefreshToken)
+				return
+			}
+
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if rt.IsUsed() {
+			s.revokeRefreshTokenFamily(w, r, rt)
+			return
+		}
+
+		if rt.IsExpired(time.Now()) {
+			s.error(w, r, http.StatusUnauthorized, errInvalidRefreshToken)
+			return
+		}
+
+		if err := s.store.RefreshToken().Rotate(rt.ID); err != nil {
+			if err == store.ErrRecordNotFound {
+				s.revokeRefreshTokenFamily(w, r, rt)
+				return
+			}
+
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		u, err := s.store.User().Find(rt.UserID)
+		if err != nil {
+			s.error(w, r, http.StatusUnauthorized, errInvalidRefreshToken)
+			return
+		}
+
+		if u.IsDisabled() {
+			s.error(w, r, http.StatusForbidden, errAccountDisabled)
+			return
+		}
+
+		res, err := s.issueTokens(u, rt.FamilyID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, res)
+	}
+}
+
+// revokeRefreshTokenFamily revokes all tokens of the family of a reused refresh token and rejects the request.
+func (s *server) revokeRefreshTokenFamily(w http.ResponseWriter, r *http.Request, rt *model.RefreshToken) {
+	s.logger.WithFields(logrus.Fields{
+		"request_id": r.Context().Value(ctxKeyRequestID),
+		"user_id":    rt.UserID,
+		"family_id":  rt.FamilyID,
+	}).Warn("refresh token reused, revoking its family")
+
+	if err := s.store.RefreshToken().RevokeFamily(rt.FamilyID); err != nil {
+		s.error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.error(w, r, http.StatusUnauthorized, errInvalidRefreshToken)
+}
+
+// handleJWKS publishes the public keys that verify access tokens.
+func (s *server) handleJWKS() http.HandlerFunc {
+	type response struct {
+		Keys []*jwk `json:"keys"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		res := &response{
+			Keys: []*jwk{},
+		}
+		for _, k := range s.config.SigningKeys {
+			res.Keys = append(res.Keys, k.jwk())
+		}
+
+		s.respond(w, r, http.StatusOK, res)
+	}
+}
+
+// issueTokens creates an access token and a refresh token of the given family for the user.
+func (s *server) issueTokens(u *model.User, familyID string) (*tokenResponse, error) {
+	accessToken, err := s.issueAccessToken(u)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken := generateToken()
+	now := time.Now()
+	if err := s.store.RefreshToken().Create(&model.RefreshToken{
+		UserID:    u.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.config.RefreshTokenTTL),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &tokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.config.AccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// issueAccessToken creates a signed access token for the user.
+func (s *server) issueAccessToken(u *model.User) (string, error) {
+	now := time.Now()
+	claims := &jwt.RegisteredClaims{
+		Issuer:    s.config.TokenIssuer,
+		Subject:   strconv.Itoa(u.ID),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(s.config.AccessTokenTTL)),
+		ID:        uuid.New().String(),
+	}
+
+	return s.signToken(accessTokenType, claims)
+}
+
+// signToken signs the claims with the first configured signing key.
+func (s *server) signToken(typ string, claims jwt.Claims) (string, error) {
+	if len(s.config.SigningKeys) == 0 {
+		return "", errTokensNotConfigured
+	}
+
+	key := s.config.SigningKeys[0]
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.KID
+	token.Header["typ"] = typ
+
+	return token.SignedString(key.privateKey)
+}
+
+// userFromAccessToken verifies the access token and finds the user it was issued for.
+// The access tokens issued to OAuth clients are refused, they only work at /userinfo.
+func (s *server) userFromAccessToken(raw string) (*model.User, error) {
+	claims := &oauthAccessClaims{}
+	if _, err := s.parseToken(raw, accessTokenType, claims); err != nil || claims.ClientID != "" {
+		return nil, errNotAuthenticated
+	}
+
+	id, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, errNotAuthenticated
+	}
+
+	u, err := s.store.User().Find(id)
+	if err != nil {
+		return nil, errNotAuthenticated
+	}
+
+	return u, nil
+}
+
+// parseToken verifies the signature, type, issuer and expiration of the token and fills the claims.
+func (s *server) parseToken(raw string, typ string, claims jwt.Claims) (*jwt.Token, error) {
+	token, err := jwt.ParseWithClaims(
+		raw,
+		claims,
+		s.verificationKey,
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(s.config.TokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if t, _ := token.Header["typ"].(string); t != typ {
+		return nil, errInvalidTokenType
+	}
+
+	return token, nil
+}
+
+// verificationKey returns the public key that matches the "kid" header of the token.
+func (s *server) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	for _, k := range s.config.SigningKeys {
+		if k.KID == kid {
+			return &k.privateKey.PublicKey, nil
+		}
+	}
+
+	return nil, errUnknownSigningKey
+}
+
+// bearerToken returns the token from the "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	if len(h) < 7 || !strings.EqualFold(h[:7], "Bearer ") {
+		return "", false
+	}
+
+	return strings.TrimSpace(h[7:]), true
+}
+
+// generateToken returns a new random opaque token.
+func generateToken() string {
+	return base64.RawURLEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+}
+
+// hashToken returns the hash of an opaque token that is stored instead of the token itself.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,236 @@
+package apiserver
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleTokensCreate(t *testing.T) {
+	u := model.TestUser(t)
+	store := teststore.New()
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), testConfig(t))
+	cookies := testLogin(t, s, u)
+
+	testCases := []struct {
+		name         string
+		payload      interface{}
+		cookies      []*http.Cookie
+		expectedCode int
+	}{
+		{
+			name: "valid password",
+			payload: map[string]string{
+				"email":    u.Email.String,
+				"password": u.Password,
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "valid session",
+			payload:      nil,
+			cookies:      cookies,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "invalid password",
+			payload: map[string]string{
+				"email":    u.Email.String,
+				"password": "invalid",
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "no session",
+			payload:      nil,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "invalid payload",
+			payload:      "invalid",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			b := &bytes.Buffer{}
+			if tc.payload != nil {
+				json.NewEncoder(b).Encode(tc.payload)
+			}
+			req, _ := http.NewRequest(http.MethodPost, "/tokens", b)
+			for _, c := range tc.cookies {
+				req.AddCookie(c)
+			}
+			s.ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_AuthenticateUserWithBearer(t *testing.T) {
+	u := model.TestUser(t)
+	store := teststore.New()
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), testConfig(t))
+
+	valid, err := s.issueAccessToken(u)
+	assert.NoError(t, err)
+
+	expiredServer := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), testConfig(t))
+	expiredServer.config.AccessTokenTTL = -time.Minute
+	expired, _ := expiredServer.issueAccessToken(u)
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	otherServer := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), testConfig(t))
+	otherServer.config.SigningKeys = []*SigningKey{{KID: "test", privateKey: otherKey}}
+	forged, _ := otherServer.issueAccessToken(u)
+
+	testCases := []struct {
+		name         string
+		token        string
+		expectedCode int
+	}{
+		{
+			name:         "valid",
+			token:        valid,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "expired",
+			token:        expired,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "forged",
+			token:        forged,
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:         "garbage",
+			token:        "invalid",
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, u.ID, r.Context().Value(ctxKeyUser).(*model.User).ID)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			s.authenticateUser(handler).ServeHTTP(rec, req)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_SigningKeyRotation(t *testing.T) {
+	u := model.TestUser(t)
+	store := teststore.New()
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), testConfig(t))
+	old, _ := s.issueAccessToken(u)
+
+	newKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	s.config.SigningKeys = append([]*SigningKey{{KID: "new", privateKey: newKey}}, s.config.SigningKeys...)
+	current, _ := s.issueAccessToken(u)
+
+	for _, token := range []string{old, current} {
+		_, err := s.userFromAccessToken(token)
+		assert.NoError(t, err)
+	}
+
+	rec := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	s.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	res := &struct {
+		Keys []*jwk `json:"keys"`
+	}{}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(res))
+	if assert.Len(t, res.Keys, 2) {
+		assert.Equal(t, "new", res.Keys[0].Kid)
+		assert.Equal(t, "test", res.Keys[1].Kid)
+	}
+}
+
+func TestLoadSigningKeys(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	path := filepath.Join(t.TempDir(), "key.pem")
+	b := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	assert.NoError(t, os.WriteFile(path, b, 0600))
+
+	keys := []*SigningKey{{KID: "test", PrivateKeyFile: path}}
+	assert.NoError(t, loadSigningKeys(keys))
+	assert.True(t, key.Equal(keys[0].privateKey))
+
+	assert.Error(t, loadSigningKeys([]*SigningKey{{KID: "test", PrivateKeyFile: filepath.Join(t.TempDir(), "missing.pem")}}))
+	assert.Error(t, loadSigningKeys([]*SigningKey{{PrivateKeyFile: path}}))
+}
+
+func TestServer_handleTokensRefresh(t *testing.T) {
+	u := model.TestUser(t)
+	store := teststore.New()
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), testConfig(t))
+
+	refresh := func(token string) (int, *tokenResponse) {
+		rec := httptest.NewRecorder()
+		b := &bytes.Buffer{}
+		json.NewEncoder(b).Encode(map[string]string{"refresh_token": token})
+		req, _ := http.NewRequest(http.MethodPost, "/tokens/refresh", b)
+		s.ServeHTTP(rec, req)
+		res := &tokenResponse{}
+		json.NewDecoder(rec.Body).Decode(res)
+		return rec.Code, res
+	}
+
+	first, err := s.issueTokens(u, "family")
+	assert.NoError(t, err)
+
+	code, second := refresh(first.RefreshToken)
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotEmpty(t, second.AccessToken)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+
+	code, third := refresh(second.RefreshToken)
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = refresh(first.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = refresh(third.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	code, _ = refresh("invalid")
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	s.config.RefreshTokenTTL = -time.Minute
+	expired, _ := s.issueTokens(u, "other")
+	code, _ = refresh(expired.RefreshToken)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
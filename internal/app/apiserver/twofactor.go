@@ -0,0 +1,440 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"image/png"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/securecookie"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+const (
+	twoFactorChallengeName = "2fa_challenge"
+	recoveryCodeCount      = 10
+	maxTwoFactorAttempts   = 5
+	totpQRCodeSize         = 256
+	// totpPeriod is the length of a time step of TOTP codes in seconds.
+	totpPeriod = 30
+)
+
+var (
+	errTwoFactorEnabled          = errors.New("two-factor authentication is already enabled")
+	errTwoFactorNotEnabled       = errors.New("two-factor authentication is not enabled")
+	errTwoFactorNotSetUp         = errors.New("two-factor authentication is not set up")
+	errTwoFactorCodeRequired     = errors.New("two-factor code or recovery code is required")
+	errInvalidTwoFactorCode      = errors.New("invalid two-factor code")
+	errInvalidTwoFactorChallenge = errors.New("invalid or expired two-factor challenge")
+)
+
+// twoFactorChallenge is the value signed into the challenge that is returned instead of a session
+// when the password is correct but the second factor is still required.
+type twoFactorChallenge struct {
+	UserID    int
+	Nonce     string
+	ExpiresAt int64
+}
+
+// handleTwoFactorSetup generates a new TOTP secret of the current user.
+// The secret is enabled only after the user confirms it with a code.
+func (s *server) handleTwoFactorSetup() http.HandlerFunc {
+	type response struct {
+		Secret string `json:"secret"`
+		URI    string `json:"uri"`
+		QRCode string `json:"qr_code"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		if u.HasTwoFactor() {
+			s.error(w, r, http.StatusConflict, errTwoFactorEnabled)
+			return
+		}
+
+		accountName := u.Email.String
+		if !u.Email.Valid {
+			accountName = "telegram:" + strconv.FormatInt(u.IDTelegram.Int64, 10)
+		}
+
+		key, err := totp.Generate(totp.GenerateOpts{
+			Issuer:      s.config.TwoFactorIssuer,
+			AccountName: accountName,
+		})
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		img, err := key.Image(totpQRCodeSize, totpQRCodeSize)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		b := &bytes.Buffer{}
+		if err := png.Encode(b, img); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.store.User().SetTOTPSecret(u.ID, key.Secret()); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{
+			Secret: key.Secret(),
+			URI:    key.URL(),
+			QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(b.Bytes()),
+		})
+	}
+}
+
+// handleTwoFactorConfirm enables the TOTP secret of the current user once the user enters a valid code
+// and returns the recovery codes, they are shown only this time.
+func (s *server) handleTwoFactorConfirm() http.HandlerFunc {
+	type request struct {
+		Code string `json:"code"`
+	}
+
+	type response struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		if u.HasTwoFactor() {
+			s.error(w, r, http.StatusConflict, errTwoFactorEnabled)
+			return
+		}
+
+		if !u.TOTPSecret.Valid {
+			s.error(w, r, http.StatusBadRequest, errTwoFactorNotSetUp)
+			return
+		}
+
+		if err := s.validateTOTP(u, req.Code); err != nil {
+			s.secondFactorError(w, r, err, http.StatusForbidden)
+			return
+		}
+
+		if err := s.store.User().EnableTOTP(u.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		codes, err := s.generateRecoveryCodes(u.ID)
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusOK, &response{RecoveryCodes: codes})
+	}
+}
+
+// handleTwoFactorDisable disables the second factor of the current user and removes the recovery codes.
+// It requires a valid code or an unused recovery code.
+func (s *server) handleTwoFactorDisable() http.HandlerFunc {
+	type request struct {
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		u := r.Context().Value(ctxKeyUser).(*model.User)
+		if !u.HasTwoFactor() {
+			s.error(w, r, http.StatusConflict, errTwoFactorNotEnabled)
+			return
+		}
+
+		if err := s.verifySecondFactor(u, req.Code, req.RecoveryCode); err != nil {
+			s.secondFactorError(w, r, err, http.StatusForbidden)
+			return
+		}
+
+		if err := s.store.User().DisableTOTP(u.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.store.RecoveryCode().DeleteByUser(u.ID); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(w, r, http.StatusNoContent, nil)
+	}
+}
+
+// handleSessionsTwoFactor completes the login that handleSessionsCreate started with a challenge.
+// A challenge is accepted once and is dropped after too many invalid codes,
+// the invalid codes also count as failed login attempts of the account.
+func (s *server) handleSessionsTwoFactor() http.HandlerFunc {
+	type request struct {
+		Challenge    string `json:"challenge"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &request{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			s.error(w, r, http.StatusBadRequest, err)
+			return
+		}
+
+		c, err := s.parseTwoFactorChallenge(req.Challenge)
+		if err != nil {
+			if err == errInvalidTwoFactorChallenge {
+				s.error(w, r, http.StatusUnauthorized, err)
+				return
+			}
+
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		u, err := s.store.User().Find(c.UserID)
+		if err != nil {
+			if err == store.ErrRecordNotFound {
+				s.error(w, r, http.StatusUnauthorized, errInvalidTwoFactorChallenge)
+				return
+			}
+
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if !u.HasTwoFactor() {
+			s.error(w, r, http.StatusUnauthorized, errInvalidTwoFactorChallenge)
+			return
+		}
+
+		if u.IsDisabled() {
+			s.error(w, r, http.StatusForbidden, errAccountDisabled)
+			return
+		}
+
+		if !s.checkLoginAttempts(w, r, u.Email.String, u) {
+			return
+		}
+
+		attemptsKey := "2fa:" + c.Nonce
+		a, err := s.store.LoginAttempt().Find(attemptsKey)
+		if err != nil && err != store.ErrRecordNotFound {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if a != nil && a.Failures >= maxTwoFactorAttempts {
+			s.error(w, r, http.StatusUnauthorized, errInvalidTwoFactorChallenge)
+			return
+		}
+
+		expiresAt := time.Unix(c.ExpiresAt, 0)
+		if err := s.verifySecondFactor(u, req.Code, req.RecoveryCode); err != nil {
+			if err == errInvalidTwoFactorCode {
+				if _, err := s.store.LoginAttempt().Fail(attemptsKey, time.Now(), s.config.TwoFactorChallengeTTL); err != nil {
+					s.error(w, r, http.StatusInternalServerError, err)
+					return
+				}
+
+				s.failLogin(w, r, u.Email.String, u, err)
+				return
+			}
+
+			s.secondFactorError(w, r, err, http.StatusUnauthorized)
+			return
+		}
+
+		if err := s.store.Revocation().Revoke(c.Nonce, expiresAt); err != nil {
+			if err == store.ErrRecordNotFound {
+				s.error(w, r, http.StatusUnauthorized, errInvalidTwoFactorChallenge)
+				return
+			}
+
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.store.LoginAttempt().Reset(attemptsKey); err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.resetLoginAttempts(r, u)
+		s.audit(r, model.AuditLogin, u.ID, 0)
+		s.createSessions(w, r, u)
+	}
+}
+
+// respondTwoFactorChallenge responds with a challenge that the user completes at handleSessionsTwoFactor
+// with the second factor instead of getting a session.
+func (s *server) respondTwoFactorChallenge(w http.ResponseWriter, r *http.Request, u *model.User) {
+	challenge, err := s.signTwoFactorChallenge(u.ID)
+	if err != nil {
+		s.error(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.respond(w, r, http.StatusAccepted, map[string]string{"challenge": challenge})
+}
+
+// signTwoFactorChallenge returns a challenge for the user who entered a valid password.
+func (s *server) signTwoFactorChallenge(userID int) (string, error) {
+	return s.signer.Encode(twoFactorChallengeName, &twoFactorChallenge{
+		UserID:    userID,
+		Nonce:     uuid.New().String(),
+		ExpiresAt: time.Now().Add(s.config.TwoFactorChallengeTTL).Unix(),
+	})
+}
+
+// parseTwoFactorChallenge verifies the signature and the expiration of the challenge
+// and that it was neither used nor dropped.
+func (s *server) parseTwoFactorChallenge(token string) (*twoFactorChallenge, error) {
+	c := &twoFactorChallenge{}
+	if err := s.signer.Decode(twoFactorChallengeName, token, c); err != nil {
+		return nil, errInvalidTwoFactorChallenge
+	}
+
+	if time.Now().Unix() >= c.ExpiresAt {
+		return nil, errInvalidTwoFactorChallenge
+	}
+
+	revoked, err := s.store.Revocation().IsRevoked(c.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if revoked {
+		return nil, errInvalidTwoFactorChallenge
+	}
+
+	return c, nil
+}
+
+// verifySecondFactor checks the TOTP code of the user or, if it is not given, uses the recovery code.
+// It returns errTwoFactorCodeRequired if neither is given and errInvalidTwoFactorCode if the given one is wrong.
+func (s *server) verifySecondFactor(u *model.User, code string, recoveryCode string) error {
+	if code != "" {
+		return s.validateTOTP(u, code)
+	}
+
+	if recoveryCode == "" {
+		return errTwoFactorCodeRequired
+	}
+
+	if err := s.store.RecoveryCode().Use(u.ID, hashToken(normalizeRecoveryCode(recoveryCode))); err != nil {
+		if err == store.ErrRecordNotFound {
+			return errInvalidTwoFactorCode
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// validateTOTP checks the code against the TOTP secret of the user.
+// The time step of an accepted code is saved, so that neither this code nor an older one is accepted again.
+// It returns errInvalidTwoFactorCode if the code is wrong or was already used.
+func (s *server) validateTOTP(u *model.User, code string) error {
+	if !u.TOTPSecret.Valid {
+		return errInvalidTwoFactorCode
+	}
+
+	step, ok := totpStep(u.TOTPSecret.String, code, time.Now())
+	if !ok {
+		return errInvalidTwoFactorCode
+	}
+
+	if err := s.store.User().UseTOTPStep(u.ID, step); err != nil {
+		if err == store.ErrRecordNotFound {
+			return errInvalidTwoFactorCode
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// totpStep returns the time step the code is valid for with the secret,
+// the steps next to the current one are accepted too, as totp.Validate does.
+func totpStep(secret string, code string, now time.Time) (int64, bool) {
+	opts := totp.ValidateOpts{
+		Period:    totpPeriod,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	}
+
+	for _, skew := range []int64{0, -1, 1} {
+		t := now.Add(time.Duration(skew*totpPeriod) * time.Second)
+		if ok, err := totp.ValidateCustom(code, secret, t, opts); err == nil && ok {
+			return t.Unix() / totpPeriod, true
+		}
+	}
+
+	return 0, false
+}
+
+// secondFactorError responds with the given code to a wrong or missing second factor and with 500 to other errors.
+func (s *server) secondFactorError(w http.ResponseWriter, r *http.Request, err error, code int) {
+	if err == errInvalidTwoFactorCode || err == errTwoFactorCodeRequired {
+		s.error(w, r, code, err)
+		return
+	}
+
+	s.error(w, r, http.StatusInternalServerError, err)
+}
+
+// generateRecoveryCodes replaces the recovery codes of the user with new ones and returns them.
+func (s *server) generateRecoveryCodes(userID int) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	records := make([]*model.RecoveryCode, recoveryCodeCount)
+	now := time.Now()
+	for i := range codes {
+		code := hex.EncodeToString(securecookie.GenerateRandomKey(5))
+		codes[i] = code[:5] + "-" + code[5:]
+		records[i] = &model.RecoveryCode{
+			UserID:    userID,
+			CodeHash:  hashToken(code),
+			CreatedAt: now,
+		}
+	}
+
+	if err := s.store.RecoveryCode().Replace(userID, records); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// normalizeRecoveryCode drops the separators and the case of a recovery code entered by the user.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,271 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleTwoFactorSetup(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), testTwoFactorConfig(t))
+	cookies := testLogin(t, s, u)
+
+	rec := testRequest(t, s, http.MethodPost, "/private/2fa/setup", nil, cookies)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	res := map[string]string{}
+	json.NewDecoder(rec.Body).Decode(&res)
+	assert.NotEmpty(t, res["secret"])
+	assert.True(t, strings.HasPrefix(res["uri"], "otpauth://totp/"))
+	assert.True(t, strings.HasPrefix(res["qr_code"], "data:image/png;base64,"))
+
+	rec = testRequest(t, s, http.MethodPost, "/private/2fa/confirm", map[string]string{"code": "000000"}, cookies)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	code, _ := totp.GenerateCode(res["secret"], time.Now())
+	rec = testRequest(t, s, http.MethodPost, "/private/2fa/confirm", map[string]string{"code": code}, cookies)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	codes := &struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{}
+	json.NewDecoder(rec.Body).Decode(codes)
+	assert.Len(t, codes.RecoveryCodes, recoveryCodeCount)
+
+	rec = testRequest(t, s, http.MethodPost, "/private/2fa/setup", nil, cookies)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = testRequest(t, s, http.MethodPost, "/private/2fa/confirm", map[string]string{"code": code}, cookies)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestServer_handleSessionsTwoFactor(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), testTwoFactorConfig(t))
+	secret, recoveryCodes := testEnableTwoFactor(t, s, testLogin(t, s, u))
+
+	login := func() string {
+		rec := testRequest(t, s, http.MethodPost, "/sessions", map[string]string{
+			"email":    u.Email.String,
+			"password": u.Password,
+		}, nil)
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Empty(t, rec.Result().Cookies())
+
+		res := map[string]string{}
+		json.NewDecoder(rec.Body).Decode(&res)
+
+		return res["challenge"]
+	}
+
+	complete := func(payload map[string]string) *httptest.ResponseRecorder {
+		return testRequest(t, s, http.MethodPost, "/sessions/2fa", payload, nil)
+	}
+
+	challenge := login()
+	assert.Equal(t, http.StatusUnauthorized, complete(map[string]string{"challenge": challenge}).Code)
+	assert.Equal(t, http.StatusUnauthorized, complete(map[string]string{"challenge": challenge, "code": "000000"}).Code)
+	assert.Equal(t, http.StatusUnauthorized, complete(map[string]string{"challenge": "invalid", "recovery_code": recoveryCodes[0]}).Code)
+
+	rec := complete(map[string]string{"challenge": challenge, "recovery_code": strings.ToUpper(recoveryCodes[0])})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, rec.Result().Cookies())
+
+	assert.Equal(t, http.StatusUnauthorized, complete(map[string]string{"challenge": challenge, "recovery_code": recoveryCodes[1]}).Code)
+	assert.Equal(t, http.StatusUnauthorized, complete(map[string]string{"challenge": login(), "recovery_code": recoveryCodes[0]}).Code)
+
+	code, _ := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+	assert.Equal(t, http.StatusOK, complete(map[string]string{"challenge": login(), "code": code}).Code)
+	assert.Equal(t, http.StatusUnauthorized, complete(map[string]string{"challenge": login(), "code": code}).Code)
+
+	challenge = login()
+	for i := 0; i < maxTwoFactorAttempts; i++ {
+		complete(map[string]string{"challenge": challenge, "code": "000000"})
+	}
+	assert.Equal(t, http.StatusUnauthorized, complete(map[string]string{"challenge": challenge, "recovery_code": recoveryCodes[1]}).Code)
+	assert.Equal(t, http.StatusOK, complete(map[string]string{"challenge": login(), "recovery_code": recoveryCodes[1]}).Code)
+}
+
+func TestServer_handleSessionsTwoFactorSharedStore(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), testTwoFactorConfig(t))
+	other := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), testTwoFactorConfig(t))
+	secret, recoveryCodes := testEnableTwoFactor(t, s, testLogin(t, s, u))
+
+	login := func() string {
+		rec := testRequest(t, s, http.MethodPost, "/sessions", map[string]string{
+			"email":    u.Email.String,
+			"password": u.Password,
+		}, nil)
+		res := map[string]string{}
+		json.NewDecoder(rec.Body).Decode(&res)
+
+		return res["challenge"]
+	}
+
+	code, _ := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+	assert.Equal(t, http.StatusOK, testRequest(t, s, http.MethodPost, "/sessions/2fa", map[string]string{"challenge": login(), "code": code}, nil).Code)
+	assert.Equal(t, http.StatusUnauthorized, testRequest(t, other, http.MethodPost, "/sessions/2fa", map[string]string{"challenge": login(), "code": code}, nil).Code)
+
+	challenge := login()
+	for i := 0; i < maxTwoFactorAttempts; i++ {
+		testRequest(t, s, http.MethodPost, "/sessions/2fa", map[string]string{"challenge": challenge, "code": "000000"}, nil)
+	}
+	rec := testRequest(t, other, http.MethodPost, "/sessions/2fa", map[string]string{"challenge": challenge, "recovery_code": recoveryCodes[0]}, nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestServer_handleTokensCreateWithTwoFactor(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	config := testTwoFactorConfig(t)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), config)
+	_, recoveryCodes := testEnableTwoFactor(t, s, testLogin(t, s, u))
+
+	testCases := []struct {
+		name         string
+		payload      map[string]string
+		expectedCode int
+	}{
+		{
+			name: "no second factor",
+			payload: map[string]string{
+				"email":    u.Email.String,
+				"password": u.Password,
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "invalid code",
+			payload: map[string]string{
+				"email":    u.Email.String,
+				"password": u.Password,
+				"code":     "000000",
+			},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name: "recovery code",
+			payload: map[string]string{
+				"email":         u.Email.String,
+				"password":      u.Password,
+				"recovery_code": recoveryCodes[0],
+			},
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := testRequest(t, s, http.MethodPost, "/tokens", tc.payload, nil)
+			assert.Equal(t, tc.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestServer_handleTwoFactorDisable(t *testing.T) {
+	store := teststore.New()
+	u := model.TestUser(t)
+	store.User().Create(u)
+	s := newServer(store, sessions.NewCookieStore([]byte("secret")), mailer.TestMailer(t, io.Discard), testTwoFactorConfig(t))
+	cookies := testLogin(t, s, u)
+
+	rec := testRequest(t, s, http.MethodDelete, "/private/2fa", map[string]string{}, cookies)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	_, recoveryCodes := testEnableTwoFactor(t, s, cookies)
+
+	rec = testRequest(t, s, http.MethodDelete, "/private/2fa", map[string]string{}, cookies)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = testRequest(t, s, http.MethodDelete, "/private/2fa", map[string]string{"code": "000000"}, cookies)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = testRequest(t, s, http.MethodDelete, "/private/2fa", map[string]string{"recovery_code": recoveryCodes[0]}, cookies)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	disabled, err := store.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.False(t, disabled.HasTwoFactor())
+	assert.False(t, disabled.TOTPSecret.Valid)
+	assert.Error(t, store.RecoveryCode().Use(u.ID, hashToken(normalizeRecoveryCode(recoveryCodes[1]))))
+
+	testLogin(t, s, u)
+}
+
+// testTwoFactorConfig returns a config with a key for signing two-factor challenges
+// that neither slows down the login after invalid codes nor limits the rate of requests.
+func testTwoFactorConfig(t *testing.T) *Config {
+	t.Helper()
+
+	config := testConfig(t)
+	config.SessionKey = "secret"
+	config.LoginBackoff = 0
+	config.RateLimits = RateLimits{}
+
+	return config
+}
+
+// testEnableTwoFactor enables the second factor of the logged in user and returns its secret and recovery codes.
+func testEnableTwoFactor(t *testing.T, s *server, cookies []*http.Cookie) (string, []string) {
+	t.Helper()
+
+	rec := testRequest(t, s, http.MethodPost, "/private/2fa/setup", nil, cookies)
+	setup := map[string]string{}
+	json.NewDecoder(rec.Body).Decode(&setup)
+
+	code, err := totp.GenerateCode(setup["secret"], time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec = testRequest(t, s, http.MethodPost, "/private/2fa/confirm", map[string]string{"code": code}, cookies)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("enabling two-factor authentication failed with %d", rec.Code)
+	}
+
+	res := &struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}{}
+	json.NewDecoder(rec.Body).Decode(res)
+
+	return setup["secret"], res.RecoveryCodes
+}
+
+// testRequest sends the request with the JSON payload and the cookies to the server and returns the recorded response.
+func testRequest(t *testing.T, s *server, method string, path string, payload interface{}, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	b := &bytes.Buffer{}
+	if payload != nil {
+		json.NewEncoder(b).Encode(payload)
+	}
+	req, _ := http.NewRequest(method, path, b)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	s.ServeHTTP(rec, req)
+
+	return rec
+}
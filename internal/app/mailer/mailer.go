@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidHeader = errors.New("mail header contains a line break")
+)
+
+// Mailer is an interface that allows you to send emails.
+type Mailer interface {
+	Send(*Message) error
+}
+
+// Message represents a plain text email.
+// It includes the following fields:
+// - To: the address of the recipient.
+// - Subject: the subject of the email.
+// - Body: the plain text of the email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// format returns the message with its headers as it is sent over SMTP.
+func format(from string, m *Message) ([]byte, error) {
+	for _, h := range []string{from, m.To, m.Subject} {
+		if strings.ContainsAny(h, "\r\n") {
+			return nil, ErrInvalidHeader
+		}
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + m.To + "\r\n")
+	b.WriteString("Subject: " + m.Subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return b.Bytes(), nil
+}
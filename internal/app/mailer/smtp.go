@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"net"
+	"net/smtp"
+)
+
+// SMTPMailer sends emails through an SMTP server.
+// It includes the following fields:
+// - addr: the host:port address of the SMTP server.
+// - from: the address the emails are sent from.
+// - auth: the PLAIN authentication, nil if the server does not require it.
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTP returns a new SMTPMailer, the empty username disables authentication.
+func NewSMTP(addr string, from string, username string, password string) *SMTPMailer {
+	m := &SMTPMailer{
+		addr: addr,
+		from: from,
+	}
+
+	if username != "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return m
+}
+
+// Send sends the message to the SMTP server.
+func (m *SMTPMailer) Send(msg *Message) error {
+	b, err := format(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, b)
+}
@@ -0,0 +1,11 @@
+package mailer
+
+import (
+	"io"
+	"testing"
+)
+
+// TestMailer returns a mailer that writes emails to w for testing.
+func TestMailer(t *testing.T, w io.Writer) *WriterMailer {
+	return NewWriter(w, "noreply@example.org")
+}
@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"io"
+	"sync"
+)
+
+// WriterMailer writes emails to a writer instead of sending them, e.g. to stdout or a file during development.
+// It includes the following fields:
+// - from: the address the emails are sent from.
+// - mu: the mutex that keeps concurrent messages from interleaving.
+// - w: the writer the emails are written to.
+type WriterMailer struct {
+	from string
+	mu   sync.Mutex
+	w    io.Writer
+}
+
+// NewWriter returns a new WriterMailer that writes emails to w.
+func NewWriter(w io.Writer, from string) *WriterMailer {
+	return &WriterMailer{
+		from: from,
+		w:    w,
+	}
+}
+
+// Send writes the message followed by an empty line.
+func (m *WriterMailer) Send(msg *Message) error {
+	b, err := format(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err = m.w.Write(append(b, '\r', '\n'))
+	return err
+}
@@ -0,0 +1,35 @@
+package mailer_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/http-rest-API/internal/app/mailer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriterMailer_Send(t *testing.T) {
+	b := &bytes.Buffer{}
+	m := mailer.NewWriter(b, "noreply@example.org")
+
+	assert.NoError(t, m.Send(&mailer.Message{
+		To:      "user@example.org",
+		Subject: "Hello",
+		Body:    "line 1\nline 2",
+	}))
+	assert.Contains(t, b.String(), "From: noreply@example.org\r\n")
+	assert.Contains(t, b.String(), "To: user@example.org\r\n")
+	assert.Contains(t, b.String(), "Subject: Hello\r\n")
+	assert.Contains(t, b.String(), "\r\n\r\nline 1\r\nline 2\r\n")
+}
+
+func TestWriterMailer_SendInvalidHeader(t *testing.T) {
+	b := &bytes.Buffer{}
+	m := mailer.NewWriter(b, "noreply@example.org")
+
+	assert.EqualError(t, m.Send(&mailer.Message{
+		To:      "user@example.org\r\nBcc: attacker@example.org",
+		Subject: "Hello",
+	}), mailer.ErrInvalidHeader.Error())
+	assert.Zero(t, b.Len())
+}
@@ -0,0 +1,49 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// APIKey represents a long-lived credential of a user for machine clients.
+// It includes the following fields:
+// - ID: a unique identifier for the key.
+// - UserID: the identifier of the user the key belongs to.
+// - Name: the name given to the key by the user.
+// - Prefix: the visible beginning of the key that is used to find it.
+// - KeyHash: the hash of the whole key, the key itself is never stored.
+// - Scopes: the permissions the key is limited to, an empty list means no limitation.
+// - CreatedAt: the time when the key was created.
+// - ExpiresAt: an optional time after which the key is no longer valid.
+// - LastUsedAt: the time when the key was used last.
+// - LastUsedIP: the address of the client that used the key last.
+type APIKey struct {
+	ID         int
+	UserID     int
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+	LastUsedIP string
+}
+
+// Validate checks the parameters of the key before it is created.
+func (k *APIKey) Validate() error {
+	return validation.ValidateStruct(
+		k,
+		validation.Field(&k.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&k.Prefix, validation.Required),
+		validation.Field(&k.KeyHash, validation.Required),
+		validation.Field(&k.Scopes, validation.Each(validation.Required, validation.Length(1, 100))),
+	)
+}
+
+// IsExpired checks if the key is no longer valid at the given time.
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt.Valid && !now.Before(k.ExpiresAt.Time)
+}
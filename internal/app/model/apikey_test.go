@@ -0,0 +1,89 @@
+package model_test
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKey_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		k       func() *model.APIKey
+		isValid bool
+	}{
+		{
+			name: "valid",
+			k: func() *model.APIKey {
+				return model.TestAPIKey(t, 1)
+			},
+			isValid: true,
+		},
+		{
+			name: "without scopes",
+			k: func() *model.APIKey {
+				k := model.TestAPIKey(t, 1)
+				k.Scopes = nil
+
+				return k
+			},
+			isValid: true,
+		},
+		{
+			name: "empty name",
+			k: func() *model.APIKey {
+				k := model.TestAPIKey(t, 1)
+				k.Name = ""
+
+				return k
+			},
+			isValid: false,
+		},
+		{
+			name: "long name",
+			k: func() *model.APIKey {
+				k := model.TestAPIKey(t, 1)
+				k.Name = strings.Repeat("a", 101)
+
+				return k
+			},
+			isValid: false,
+		},
+		{
+			name: "empty scope",
+			k: func() *model.APIKey {
+				k := model.TestAPIKey(t, 1)
+				k.Scopes = []string{""}
+
+				return k
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.k().Validate())
+			} else {
+				assert.Error(t, tc.k().Validate())
+			}
+		})
+	}
+}
+
+func TestAPIKey_IsExpired(t *testing.T) {
+	now := time.Now()
+	k := model.TestAPIKey(t, 1)
+	assert.False(t, k.IsExpired(now))
+
+	k.ExpiresAt = sql.NullTime{Time: now.Add(time.Minute), Valid: true}
+	assert.False(t, k.IsExpired(now))
+
+	k.ExpiresAt = sql.NullTime{Time: now, Valid: true}
+	assert.True(t, k.IsExpired(now))
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// The types of audit events.
+const (
+	AuditRegister           = "register"
+	AuditLogin              = "login"
+	AuditLoginFailed        = "login_failed"
+	AuditTelegramLogin      = "telegram_login"
+	AuditExternalLogin      = "external_login"
+	AuditLogout             = "logout"
+	AuditPasswordChange     = "password_change"
+	AuditPasswordReset      = "password_reset"
+	AuditAdminUserDisable   = "admin_user_disable"
+	AuditAdminUserEnable    = "admin_user_enable"
+	AuditAdminPasswordReset = "admin_password_reset"
+	AuditAdminUserDelete    = "admin_user_delete"
+	AuditAdminRoleAssign    = "admin_role_assign"
+	AuditAdminRoleUnassign  = "admin_role_unassign"
+)
+
+// AuditEvent represents a recorded authentication or administration event.
+// It includes the following fields:
+// - ID: a unique identifier for the event.
+// - Type: what happened, one of the Audit constants.
+// - UserID: the identifier of the user the event is about, zero if the user is unknown, such as for a failed login with an unknown email.
+// - ActorID: the identifier of the administrator who acted on the user, zero if the user acted.
+// - IP: the address of the client that made the request.
+// - UserAgent: the user agent of the client that made the request.
+// - RequestID: the identifier of the request in the logs.
+// - CreatedAt: the time when the event happened.
+type AuditEvent struct {
+	ID        int       `json:"id"`
+	Type      string    `json:"type"`
+	UserID    int       `json:"user_id,omitempty"`
+	ActorID   int       `json:"actor_id,omitempty"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	RequestID string    `json:"request_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// ExternalIdentity represents an account of a user at an external OpenID Connect provider that the user logs in with.
+// It includes the following fields:
+// - ID: a unique identifier for the identity.
+// - UserID: the identifier of the user the identity belongs to.
+// - Provider: the name of the provider in the configuration, such as "google".
+// - Subject: the identifier of the account at the provider, the "sub" claim of its ID tokens.
+// - Email: the email the provider gave for the account when it was linked, empty if it gave none.
+// - CreatedAt: the time when the identity was linked.
+type ExternalIdentity struct {
+	ID        int
+	UserID    int
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+// Validate checks the parameters of the identity before it is linked.
+func (i *ExternalIdentity) Validate() error {
+	return validation.ValidateStruct(
+		i,
+		validation.Field(&i.Provider, validation.Required),
+		validation.Field(&i.Subject, validation.Required),
+	)
+}
@@ -0,0 +1,23 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalIdentity_Validate(t *testing.T) {
+	i := model.TestExternalIdentity(t, 1)
+	assert.NoError(t, i.Validate())
+
+	i.Email = ""
+	assert.NoError(t, i.Validate())
+
+	i.Subject = ""
+	assert.Error(t, i.Validate())
+
+	i = model.TestExternalIdentity(t, 1)
+	i.Provider = ""
+	assert.Error(t, i.Validate())
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// LoginAttempt represents the failed password guesses made for an account or from a client address.
+// It includes the following fields:
+// - Key: what the guesses are counted for, such as the email of the account or the address of the client.
+// - Failures: the number of failed guesses in a row.
+// - LastFailedAt: the time of the last failed guess.
+type LoginAttempt struct {
+	Key          string
+	Failures     int
+	LastFailedAt time.Time
+}
+
+// LockedUntil returns the time before which the next guess is refused.
+// The delay starts with backoff and doubles with each failure, once threshold failures are reached it becomes lockout.
+func (a *LoginAttempt) LockedUntil(backoff time.Duration, threshold int, lockout time.Duration) time.Time {
+	if a.Failures <= 0 {
+		return a.LastFailedAt
+	}
+
+	if a.Failures >= threshold {
+		return a.LastFailedAt.Add(lockout)
+	}
+
+	delay := lockout
+	if shift := a.Failures - 1; shift < 32 {
+		if d := backoff << shift; d >= 0 && d < lockout {
+			delay = d
+		}
+	}
+
+	return a.LastFailedAt.Add(delay)
+}
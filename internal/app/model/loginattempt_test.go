@@ -0,0 +1,61 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAttempt_LockedUntil(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name     string
+		failures int
+		expected time.Duration
+	}{
+		{
+			name:     "no failures",
+			failures: 0,
+			expected: 0,
+		},
+		{
+			name:     "first failure",
+			failures: 1,
+			expected: time.Second,
+		},
+		{
+			name:     "backoff",
+			failures: 4,
+			expected: 8 * time.Second,
+		},
+		{
+			name:     "backoff longer than lockout",
+			failures: 9,
+			expected: 4 * time.Minute,
+		},
+		{
+			name:     "threshold",
+			failures: 10,
+			expected: 4 * time.Minute,
+		},
+		{
+			name:     "above threshold",
+			failures: 100,
+			expected: 4 * time.Minute,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &model.LoginAttempt{
+				Key:          "key",
+				Failures:     tc.failures,
+				LastFailedAt: now,
+			}
+			assert.Equal(t, now.Add(tc.expected), a.LockedUntil(time.Second, 10, 4*time.Minute))
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// OAuthClient represents an application that signs users in with their accounts of this server.
+// It includes the following fields:
+// - ID: a unique identifier for the client.
+// - ClientID: the public identifier the client sends in OAuth 2.0 requests.
+// - SecretHash: the hash of the client secret, empty for public clients that cannot keep a secret.
+// - Name: the name of the application that is shown to users on the consent page.
+// - RedirectURIs: the addresses the authorization codes may be sent to.
+// - CreatedAt: the time when the client was registered.
+type OAuthClient struct {
+	ID           int
+	ClientID     string
+	SecretHash   string
+	Name         string
+	RedirectURIs []string
+	CreatedAt    time.Time
+}
+
+// Validate checks the parameters of the client before it is registered.
+func (c *OAuthClient) Validate() error {
+	return validation.ValidateStruct(
+		c,
+		validation.Field(&c.ClientID, validation.Required),
+		validation.Field(&c.Name, validation.Required, validation.Length(1, 100)),
+		validation.Field(&c.RedirectURIs, validation.Required, validation.Each(validation.Required, validation.By(redirectURI))),
+	)
+}
+
+// IsPublic checks if the client has no secret and must prove the authorization with PKCE alone.
+func (c *OAuthClient) IsPublic() bool {
+	return c.SecretHash == ""
+}
+
+// HasRedirectURI checks if the address is one of the registered redirect URIs of the client.
+func (c *OAuthClient) HasRedirectURI(uri string) bool {
+	for _, u := range c.RedirectURIs {
+		if u == uri {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthClient_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		c       func() *model.OAuthClient
+		isValid bool
+	}{
+		{
+			name: "valid",
+			c: func() *model.OAuthClient {
+				return model.TestOAuthClient(t)
+			},
+			isValid: true,
+		},
+		{
+			name: "public",
+			c: func() *model.OAuthClient {
+				c := model.TestOAuthClient(t)
+				c.SecretHash = ""
+
+				return c
+			},
+			isValid: true,
+		},
+		{
+			name: "empty name",
+			c: func() *model.OAuthClient {
+				c := model.TestOAuthClient(t)
+				c.Name = ""
+
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "without redirect uris",
+			c: func() *model.OAuthClient {
+				c := model.TestOAuthClient(t)
+				c.RedirectURIs = nil
+
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "relative redirect uri",
+			c: func() *model.OAuthClient {
+				c := model.TestOAuthClient(t)
+				c.RedirectURIs = []string{"/callback"}
+
+				return c
+			},
+			isValid: false,
+		},
+		{
+			name: "redirect uri with fragment",
+			c: func() *model.OAuthClient {
+				c := model.TestOAuthClient(t)
+				c.RedirectURIs = []string{"https://app.example.org/callback#done"}
+
+				return c
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.c().Validate())
+			} else {
+				assert.Error(t, tc.c().Validate())
+			}
+		})
+	}
+}
+
+func TestOAuthClient_HasRedirectURI(t *testing.T) {
+	c := model.TestOAuthClient(t)
+	assert.True(t, c.HasRedirectURI("https://app.example.org/callback"))
+	assert.False(t, c.HasRedirectURI("https://app.example.org/callback/"))
+	assert.False(t, c.HasRedirectURI("https://evil.example.org/callback"))
+	assert.False(t, c.IsPublic())
+}
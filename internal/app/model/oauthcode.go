@@ -0,0 +1,51 @@
+package model
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"time"
+)
+
+// OAuthCode represents a single-use authorization code that a client exchanges for tokens.
+// It includes the following fields:
+// - ID: a unique identifier for the code.
+// - CodeHash: the hash of the code, the code itself is only sent to the client.
+// - ClientID: the client the code was issued to.
+// - UserID: the identifier of the user who authorized the client.
+// - RedirectURI: the address the code was sent to, the client must present it again.
+// - Scopes: the scopes the user authorized.
+// - Nonce: the value the client asked to put into the ID token.
+// - CodeChallenge: the S256 PKCE challenge the code verifier must match.
+// - CreatedAt: the time when the code was issued.
+// - ExpiresAt: the time after which the code is no longer valid.
+type OAuthCode struct {
+	ID            int
+	CodeHash      string
+	ClientID      string
+	UserID        int
+	RedirectURI   string
+	Scopes        []string
+	Nonce         string
+	CodeChallenge string
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+}
+
+// IsExpired checks if the code is no longer valid at the given time.
+func (c *OAuthCode) IsExpired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+// VerifyCodeVerifier checks if the PKCE code verifier matches the S256 challenge of the code.
+// RFC 7636 requires the verifier to be 43 to 128 characters long.
+func (c *OAuthCode) VerifyCodeVerifier(verifier string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return subtle.ConstantTimeCompare([]byte(challenge), []byte(c.CodeChallenge)) == 1
+}
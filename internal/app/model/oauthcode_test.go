@@ -0,0 +1,25 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthCode_VerifyCodeVerifier(t *testing.T) {
+	c := model.TestOAuthCode(t, "client", 1)
+	assert.True(t, c.VerifyCodeVerifier("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+	assert.False(t, c.VerifyCodeVerifier("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXK"))
+	assert.False(t, c.VerifyCodeVerifier("short"))
+	assert.False(t, c.VerifyCodeVerifier(strings.Repeat("a", 129)))
+}
+
+func TestOAuthCode_IsExpired(t *testing.T) {
+	c := model.TestOAuthCode(t, "client", 1)
+	assert.False(t, c.IsExpired(c.CreatedAt))
+	assert.True(t, c.IsExpired(c.ExpiresAt))
+	assert.True(t, c.IsExpired(c.ExpiresAt.Add(time.Second)))
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// The scopes that clients can ask for. ScopeOpenID is required, the other scopes release the claims about the user.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// OAuthGrant represents the consent of a user to give a client the claims of the scopes.
+// It includes the following fields:
+// - ID: a unique identifier for the grant.
+// - UserID: the identifier of the user who gave the consent.
+// - ClientID: the client the consent was given to.
+// - Scopes: the scopes the user agreed to.
+// - CreatedAt: the time when the user first gave the consent.
+type OAuthGrant struct {
+	ID        int
+	UserID    int
+	ClientID  string
+	Scopes    []string
+	CreatedAt time.Time
+}
+
+// Covers checks if the user agreed to all the scopes.
+func (g *OAuthGrant) Covers(scopes []string) bool {
+	for _, s := range scopes {
+		found := false
+		for _, granted := range g.Scopes {
+			if granted == s {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
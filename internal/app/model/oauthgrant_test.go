@@ -0,0 +1,16 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthGrant_Covers(t *testing.T) {
+	g := model.TestOAuthGrant(t, "client", 1)
+	assert.True(t, g.Covers([]string{model.ScopeOpenID}))
+	assert.True(t, g.Covers([]string{model.ScopeEmail, model.ScopeOpenID}))
+	assert.False(t, g.Covers([]string{model.ScopeOpenID, model.ScopeProfile}))
+	assert.True(t, g.Covers(nil))
+}
@@ -0,0 +1,193 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultPasswordHasher hashes the passwords of the users that have no PasswordHasher,
+// the server uses the hasher selected in its config instead.
+// Hashes made by another scheme or with other parameters are replaced on the next login, see NeedsRehash.
+var DefaultPasswordHasher PasswordHasher = &BcryptHasher{Cost: bcrypt.DefaultCost}
+
+// PasswordHasher hashes passwords into strings that start with the prefix of their scheme,
+// so that a stored hash can be verified after the default scheme has changed.
+type PasswordHasher interface {
+	// Hash returns the hash of the password with a new salt.
+	Hash(password string) (string, error)
+	// Verify checks the password against a hash of the same scheme, the parameters are read from the hash.
+	Verify(hash string, password string) bool
+	// NeedsRehash checks if the hash was made by another scheme or with other parameters than the hasher's.
+	NeedsRehash(hash string) bool
+}
+
+// BcryptHasher hashes passwords with bcrypt, the hashes start with "$2".
+// It includes the following fields:
+// - Cost: the bcrypt cost of new hashes.
+type BcryptHasher struct {
+	Cost int
+}
+
+// Hash returns the bcrypt hash of the password.
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// Verify checks the password against the bcrypt hash.
+func (h *BcryptHasher) Verify(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NeedsRehash checks if the hash is not a bcrypt hash with the cost of the hasher.
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+// argon2idPrefix starts the hashes of Argon2idHasher.
+const argon2idPrefix = "$argon2id$"
+
+var (
+	errInvalidArgon2idHash = errors.New("invalid argon2id hash")
+)
+
+// Argon2idHasher hashes passwords with Argon2id into the PHC string format
+// "$argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<key>".
+// It includes the following fields:
+// - Memory: the memory of new hashes in KiB.
+// - Iterations: the number of passes over the memory of new hashes.
+// - Parallelism: the number of threads of new hashes.
+// - SaltLength: the length of the random salt in bytes.
+// - KeyLength: the length of the derived key in bytes.
+type Argon2idHasher struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// NewArgon2idHasher returns an Argon2idHasher with the given parameters, a 16 byte salt and a 32 byte key.
+func NewArgon2idHasher(memory uint32, iterations uint32, parallelism uint8) *Argon2idHasher {
+	return &Argon2idHasher{
+		Memory:      memory,
+		Iterations:  iterations,
+		Parallelism: parallelism,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// argon2idHash is a parsed Argon2id hash.
+type argon2idHash struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+// Hash returns the Argon2id hash of the password.
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf(
+		"%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix,
+		argon2.Version,
+		h.Memory,
+		h.Iterations,
+		h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify checks the password against the Argon2id hash.
+func (h *Argon2idHasher) Verify(hash string, password string) bool {
+	p, err := parseArgon2idHash(hash)
+	if err != nil {
+		return false
+	}
+
+	key := argon2.IDKey([]byte(password), p.salt, p.iterations, p.memory, p.parallelism, uint32(len(p.key)))
+
+	return subtle.ConstantTimeCompare(key, p.key) == 1
+}
+
+// NeedsRehash checks if the hash is not an Argon2id hash with the parameters of the hasher.
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	p, err := parseArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+
+	return p.memory != h.Memory ||
+		p.iterations != h.Iterations ||
+		p.parallelism != h.Parallelism ||
+		uint32(len(p.salt)) != h.SaltLength ||
+		uint32(len(p.key)) != h.KeyLength
+}
+
+// parseArgon2idHash reads the parameters, the salt and the key from the PHC string of an Argon2id hash.
+func parseArgon2idHash(hash string) (*argon2idHash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errInvalidArgon2idHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, err
+	}
+
+	if version != argon2.Version {
+		return nil, errInvalidArgon2idHash
+	}
+
+	p := &argon2idHash{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, err
+	}
+
+	if p.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, err
+	}
+
+	if len(p.key) == 0 {
+		return nil, errInvalidArgon2idHash
+	}
+
+	return p, nil
+}
+
+// passwordHasherFor returns the hasher that verifies the hash by the prefix of its scheme.
+func passwordHasherFor(hash string) PasswordHasher {
+	if strings.HasPrefix(hash, argon2idPrefix) {
+		return &Argon2idHasher{}
+	}
+
+	return &BcryptHasher{}
+}
@@ -0,0 +1,71 @@
+package model_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var phcRe = regexp.MustCompile(`^\$argon2id\$v=19\$m=1024,t=2,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`)
+
+func TestBcryptHasher(t *testing.T) {
+	h := &model.BcryptHasher{Cost: bcrypt.MinCost}
+	hash, err := h.Hash("password")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\$2[aby]\$04\$`, hash)
+	assert.True(t, h.Verify(hash, "password"))
+	assert.False(t, h.Verify(hash, "invalid"))
+	assert.False(t, h.NeedsRehash(hash))
+	assert.True(t, (&model.BcryptHasher{Cost: bcrypt.MinCost + 1}).NeedsRehash(hash))
+	assert.True(t, h.NeedsRehash("$argon2id$v=19$m=1024,t=2,p=1$c2FsdA$a2V5"))
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	h := model.NewArgon2idHasher(1024, 2, 1)
+	hash, err := h.Hash("password")
+	assert.NoError(t, err)
+	assert.Regexp(t, phcRe, hash)
+	assert.True(t, h.Verify(hash, "password"))
+	assert.False(t, h.Verify(hash, "invalid"))
+	assert.False(t, h.NeedsRehash(hash))
+
+	other, _ := h.Hash("password")
+	assert.NotEqual(t, hash, other)
+
+	assert.True(t, model.NewArgon2idHasher(2048, 2, 1).NeedsRehash(hash))
+	assert.True(t, model.NewArgon2idHasher(1024, 3, 1).NeedsRehash(hash))
+	assert.True(t, model.NewArgon2idHasher(1024, 2, 2).NeedsRehash(hash))
+	assert.True(t, h.NeedsRehash("$2a$04$invalid"))
+
+	testCases := []string{
+		"",
+		"$argon2id$v=19$m=1024,t=2,p=1$c2FsdA",
+		"$argon2id$v=16$m=1024,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=2,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=2,p=1$!$a2V5",
+		"$argon2id$v=19$m=1024,t=2,p=1$c2FsdA$",
+		"$argon2i$v=19$m=1024,t=2,p=1$c2FsdA$a2V5",
+	}
+
+	for _, tc := range testCases {
+		assert.False(t, h.Verify(tc, "password"), tc)
+		assert.True(t, h.NeedsRehash(tc), tc)
+	}
+}
+
+func TestUser_ComparePasswordSchemes(t *testing.T) {
+	for _, h := range []model.PasswordHasher{
+		&model.BcryptHasher{Cost: bcrypt.MinCost},
+		model.NewArgon2idHasher(1024, 1, 1),
+	} {
+		u := model.TestUser(t)
+		u.PasswordHasher = h
+		assert.NoError(t, u.BeforeCreate())
+
+		assert.True(t, u.ComparePassword("password"))
+		assert.False(t, u.ComparePassword("invalid"))
+	}
+}
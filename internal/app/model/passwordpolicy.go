@@ -0,0 +1,142 @@
+package model
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultPasswordPolicy is the policy of new passwords of the default config.
+var DefaultPasswordPolicy = &PasswordPolicy{
+	MinLength:            6,
+	MaxLength:            30,
+	RequireUpper:         true,
+	RequireLower:         true,
+	RequireDigit:         true,
+	ForbidEmailLocalPart: true,
+}
+
+// minEmailLocalPartLength is the shortest local part of an email that a password must not contain,
+// shorter ones would forbid too many passwords.
+const minEmailLocalPartLength = 3
+
+// PasswordPolicy holds the rules a new password must follow.
+// It includes the following fields:
+// - MinLength, MaxLength: the length of the password in characters, zero MaxLength means no limit.
+// - RequireUpper, RequireLower, RequireDigit, RequireSymbol: the character classes the password must contain.
+// - ForbidEmailLocalPart: whether the password must not contain the part of the user's email before "@".
+// - Blocklist: the lowercase passwords that are known to be breached or common.
+type PasswordPolicy struct {
+	MinLength            int
+	MaxLength            int
+	RequireUpper         bool
+	RequireLower         bool
+	RequireDigit         bool
+	RequireSymbol        bool
+	ForbidEmailLocalPart bool
+	Blocklist            map[string]struct{}
+}
+
+// PasswordViolation is a rule of the password policy that the password breaks.
+// It includes the following fields:
+// - Rule: the name of the rule.
+// - Message: the description of the rule that can be shown to the user.
+type PasswordViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// PasswordPolicyError lists all rules of the password policy that the password breaks.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+// Error joins the messages of the violations.
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Check checks the new password of the user with the email against the policy.
+// It returns *PasswordPolicyError with all broken rules if the password breaks the policy.
+func (p *PasswordPolicy) Check(password string, email string) error {
+	var violations []PasswordViolation
+	violate := func(rule string, message string) {
+		violations = append(violations, PasswordViolation{Rule: rule, Message: message})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violate("min_length", fmt.Sprintf("password must be at least %d characters long", p.MinLength))
+	}
+
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violate("max_length", fmt.Sprintf("password must be at most %d characters long", p.MaxLength))
+	}
+
+	if p.RequireUpper && !strings.ContainsFunc(password, unicode.IsUpper) {
+		violate("upper", "password must contain an uppercase letter")
+	}
+
+	if p.RequireLower && !strings.ContainsFunc(password, unicode.IsLower) {
+		violate("lower", "password must contain a lowercase letter")
+	}
+
+	if p.RequireDigit && !strings.ContainsFunc(password, unicode.IsDigit) {
+		violate("digit", "password must contain a digit")
+	}
+
+	if p.RequireSymbol && !strings.ContainsFunc(password, isSymbol) {
+		violate("symbol", "password must contain a symbol")
+	}
+
+	lower := strings.ToLower(password)
+	if p.ForbidEmailLocalPart {
+		local, _, _ := strings.Cut(strings.ToLower(email), "@")
+		if utf8.RuneCountInString(local) >= minEmailLocalPartLength && strings.Contains(lower, local) {
+			violate("email", "password must not contain the email")
+		}
+	}
+
+	if _, ok := p.Blocklist[lower]; ok {
+		violate("blocklist", "password is too common or was exposed in a data breach")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+
+	return nil
+}
+
+// LoadBlocklist adds the passwords read from r to the blocklist of the policy.
+// It expects one password per line, empty lines and lines starting with "#" are skipped.
+func (p *PasswordPolicy) LoadBlocklist(r io.Reader) error {
+	if p.Blocklist == nil {
+		p.Blocklist = make(map[string]struct{})
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		p.Blocklist[strings.ToLower(line)] = struct{}{}
+	}
+
+	return scanner.Err()
+}
+
+// isSymbol checks if the rune is neither a letter, a digit nor a space.
+func isSymbol(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+}
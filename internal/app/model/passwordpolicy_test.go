@@ -0,0 +1,98 @@
+package model_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	p := &model.PasswordPolicy{
+		MinLength:            8,
+		MaxLength:            16,
+		RequireUpper:         true,
+		RequireLower:         true,
+		RequireDigit:         true,
+		RequireSymbol:        true,
+		ForbidEmailLocalPart: true,
+	}
+	assert.NoError(t, p.LoadBlocklist(strings.NewReader("# common passwords\n\nPassword1!\n")))
+
+	testCases := []struct {
+		name     string
+		password string
+		email    string
+		rules    []string
+	}{
+		{
+			name:     "valid",
+			password: "Secret12!",
+			email:    "user@example.org",
+		},
+		{
+			name:     "empty",
+			password: "",
+			email:    "user@example.org",
+			rules:    []string{"min_length", "upper", "lower", "digit", "symbol"},
+		},
+		{
+			name:     "too long",
+			password: "Secret12!Secret12!",
+			email:    "user@example.org",
+			rules:    []string{"max_length"},
+		},
+		{
+			name:     "no symbol and no digit",
+			password: "SecretSecret",
+			email:    "user@example.org",
+			rules:    []string{"digit", "symbol"},
+		},
+		{
+			name:     "contains email",
+			password: "Johnny12!",
+			email:    "John@example.org",
+			rules:    []string{"email"},
+		},
+		{
+			name:     "short local part",
+			password: "Secret12!",
+			email:    "se@example.org",
+		},
+		{
+			name:     "blocklisted",
+			password: "pASSWORD1!",
+			email:    "user@example.org",
+			rules:    []string{"blocklist"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := p.Check(tc.password, tc.email)
+			if tc.rules == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			if assert.IsType(t, &model.PasswordPolicyError{}, err) {
+				var rules []string
+				for _, v := range err.(*model.PasswordPolicyError).Violations {
+					assert.NotEmpty(t, v.Message)
+					rules = append(rules, v.Rule)
+				}
+				assert.Equal(t, tc.rules, rules)
+			}
+		})
+	}
+}
+
+func TestDefaultPasswordPolicy(t *testing.T) {
+	p := model.DefaultPasswordPolicy
+	assert.NoError(t, p.Check("Password123", "user@example.org"))
+	assert.Error(t, p.Check("password", "user@example.org"))
+	assert.Error(t, p.Check("PASSWORD123", "user@example.org"))
+	assert.Error(t, p.Check("Passwordabc", "user@example.org"))
+	assert.Error(t, p.Check("Pa1", "user@example.org"))
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PasswordReset represents a single-use token that allows the user to set a new password.
+// It includes the following fields:
+// - ID: a unique identifier for the password reset.
+// - UserID: the identifier of the user whose password can be reset.
+// - TokenHash: the hash of the token, the token itself is only sent to the user.
+// - CreatedAt: the time when the token was issued.
+// - ExpiresAt: the time after which the token is no longer valid.
+// - UsedAt: the time when the token was used to set a new password.
+type PasswordReset struct {
+	ID        int
+	UserID    int
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+}
+
+// IsUsed checks if the token was already used.
+func (p *PasswordReset) IsUsed() bool {
+	return p.UsedAt.Valid
+}
+
+// IsExpired checks if the token is no longer valid at the given time.
+func (p *PasswordReset) IsExpired(now time.Time) bool {
+	return !now.Before(p.ExpiresAt)
+}
@@ -0,0 +1,29 @@
+package model_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordReset_IsUsed(t *testing.T) {
+	p := model.TestPasswordReset(t, 1)
+	assert.False(t, p.IsUsed())
+
+	p.UsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	assert.True(t, p.IsUsed())
+}
+
+func TestPasswordReset_IsExpired(t *testing.T) {
+	now := time.Now()
+	p := model.TestPasswordReset(t, 1)
+
+	p.ExpiresAt = now.Add(time.Minute)
+	assert.False(t, p.IsExpired(now))
+
+	p.ExpiresAt = now
+	assert.True(t, p.IsExpired(now))
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// RecoveryCode represents a one-time code that replaces the second factor when the user has no access to it.
+// It includes the following fields:
+// - ID: a unique identifier for the recovery code.
+// - UserID: the identifier of the user the code belongs to.
+// - CodeHash: the hash of the code, the code itself is shown to the user only once.
+// - CreatedAt: the time when the code was generated.
+// - UsedAt: the time when the code was used.
+type RecoveryCode struct {
+	ID        int
+	UserID    int
+	CodeHash  string
+	CreatedAt time.Time
+	UsedAt    sql.NullTime
+}
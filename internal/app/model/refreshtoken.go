@@ -0,0 +1,37 @@
+package model
+
+import (
+	"database/sql"
+	"time"
+)
+
+// RefreshToken represents an opaque token that can be exchanged for a new access token once.
+// It includes the following fields:
+// - ID: a unique identifier for the refresh token.
+// - UserID: the identifier of the user the token was issued for.
+// - FamilyID: the identifier shared by all tokens that were rotated from the same login.
+// - TokenHash: the hash of the token, the token itself is never stored.
+// - CreatedAt: the time when the token was issued.
+// - ExpiresAt: the time after which the token is no longer valid.
+// - RotatedAt: the time when the token was exchanged for a new one.
+// - RevokedAt: the time when the token was revoked.
+type RefreshToken struct {
+	ID        int
+	UserID    int
+	FamilyID  string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RotatedAt sql.NullTime
+	RevokedAt sql.NullTime
+}
+
+// IsUsed checks if the token was already rotated or revoked.
+func (t *RefreshToken) IsUsed() bool {
+	return t.RotatedAt.Valid || t.RevokedAt.Valid
+}
+
+// IsExpired checks if the token is no longer valid at the given time.
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,33 @@
+package model_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshToken_IsUsed(t *testing.T) {
+	rt := model.TestRefreshToken(t, 1)
+	assert.False(t, rt.IsUsed())
+
+	rt.RotatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	assert.True(t, rt.IsUsed())
+
+	rt = model.TestRefreshToken(t, 1)
+	rt.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	assert.True(t, rt.IsUsed())
+}
+
+func TestRefreshToken_IsExpired(t *testing.T) {
+	now := time.Now()
+	rt := model.TestRefreshToken(t, 1)
+
+	rt.ExpiresAt = now.Add(time.Minute)
+	assert.False(t, rt.IsExpired(now))
+
+	rt.ExpiresAt = now
+	assert.True(t, rt.IsExpired(now))
+}
@@ -0,0 +1,60 @@
+package model
+
+// The roles that the migrations create. Every user has RoleUser, the other roles are assigned.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// The permissions that the migrations grant to the roles.
+const (
+	PermissionUsersRead    = "users:read"
+	PermissionUsersWrite   = "users:write"
+	PermissionRolesWrite   = "roles:write"
+	PermissionClientsWrite = "clients:write"
+	PermissionAuditRead    = "audit:read"
+)
+
+// Role represents a named set of permissions that can be assigned to users.
+// It includes the following fields:
+// - ID: a unique identifier for the role.
+// - Name: the name of the role, such as "admin".
+// - Permissions: the names of the permissions the role grants.
+type Role struct {
+	ID          int
+	Name        string
+	Permissions []string
+}
+
+// HasPermission checks if the role grants the permission.
+func (r *Role) HasPermission(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RoleNames returns the names of the roles.
+func RoleNames(roles []*Role) []string {
+	names := make([]string, len(roles))
+	for i, r := range roles {
+		names[i] = r.Name
+	}
+
+	return names
+}
+
+// HasPermission checks if any of the roles grants the permission.
+func HasPermission(roles []*Role, permission string) bool {
+	for _, r := range roles {
+		if r.HasPermission(permission) {
+			return true
+		}
+	}
+
+	return false
+}
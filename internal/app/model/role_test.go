@@ -0,0 +1,20 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasPermission(t *testing.T) {
+	roles := []*model.Role{
+		{Name: model.RoleUser},
+		{Name: model.RoleModerator, Permissions: []string{model.PermissionUsersRead}},
+	}
+
+	assert.Equal(t, []string{model.RoleUser, model.RoleModerator}, model.RoleNames(roles))
+	assert.True(t, model.HasPermission(roles, model.PermissionUsersRead))
+	assert.False(t, model.HasPermission(roles, model.PermissionUsersWrite))
+	assert.False(t, model.HasPermission(nil, model.PermissionUsersRead))
+}
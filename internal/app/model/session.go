@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Session represents a server-side session record.
+// It includes the following fields:
+// - ID: a unique identifier for the session record.
+// - UserID: the identifier of the user the session belongs to.
+// - TokenHash: the hash of the opaque token stored in the session cookie.
+// - Data: the encoded session values.
+// - IP: the address of the client that used the session last.
+// - UserAgent: the user agent of the client that used the session last.
+// - CreatedAt: the time when the session was created.
+// - LastSeenAt: the time when the session was used last.
+// - ExpiresAt: the time after which the session is no longer valid.
+type Session struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	TokenHash  string    `json:"-"`
+	Data       string    `json:"-"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// IsExpired checks if the session is no longer valid at the given time.
+func (s *Session) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,20 @@
+package model_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSession_IsExpired(t *testing.T) {
+	now := time.Now()
+	s := model.TestSession(t, 1)
+
+	s.ExpiresAt = now.Add(time.Minute)
+	assert.False(t, s.IsExpired(now))
+
+	s.ExpiresAt = now
+	assert.True(t, s.IsExpired(now))
+}
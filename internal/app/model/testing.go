@@ -3,6 +3,7 @@ package model
 import (
 	"database/sql"
 	"testing"
+	"time"
 )
 
 // TestUser returns a test model with email and password for testing.
@@ -22,3 +23,122 @@ func TestUserWithTelegram(t *testing.T) *User {
 		Password:    "password",
 	}
 }
+
+// TestSession returns a test session that belongs to the user with the given id.
+func TestSession(t *testing.T, userID int) *Session {
+	now := time.Now()
+	return &Session{
+		UserID:     userID,
+		TokenHash:  "tokenhash",
+		Data:       "data",
+		IP:         "127.0.0.1",
+		UserAgent:  "test",
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	}
+}
+
+// TestRefreshToken returns a test refresh token that belongs to the user with the given id.
+func TestRefreshToken(t *testing.T, userID int) *RefreshToken {
+	now := time.Now()
+	return &RefreshToken{
+		UserID:    userID,
+		FamilyID:  "family",
+		TokenHash: "tokenhash",
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+}
+
+// TestAPIKey returns a test API key that belongs to the user with the given id.
+func TestAPIKey(t *testing.T, userID int) *APIKey {
+	return &APIKey{
+		UserID:    userID,
+		Name:      "ci",
+		Prefix:    "brk_prefix",
+		KeyHash:   "keyhash",
+		Scopes:    []string{"users:read"},
+		CreatedAt: time.Now(),
+	}
+}
+
+// TestPasswordReset returns a test password reset that belongs to the user with the given id.
+func TestPasswordReset(t *testing.T, userID int) *PasswordReset {
+	now := time.Now()
+	return &PasswordReset{
+		UserID:    userID,
+		TokenHash: "tokenhash",
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}
+}
+
+// TestRecoveryCode returns a test recovery code that belongs to the user with the given id.
+func TestRecoveryCode(t *testing.T, userID int) *RecoveryCode {
+	return &RecoveryCode{
+		UserID:    userID,
+		CodeHash:  "codehash",
+		CreatedAt: time.Now(),
+	}
+}
+
+// TestOAuthClient returns a test confidential OAuth client.
+func TestOAuthClient(t *testing.T) *OAuthClient {
+	return &OAuthClient{
+		ClientID:     "client",
+		SecretHash:   "secrethash",
+		Name:         "Test app",
+		RedirectURIs: []string{"https://app.example.org/callback"},
+		CreatedAt:    time.Now(),
+	}
+}
+
+// TestOAuthCode returns a test authorization code that the user with the given id gave to the client.
+func TestOAuthCode(t *testing.T, clientID string, userID int) *OAuthCode {
+	now := time.Now()
+	return &OAuthCode{
+		CodeHash:      "codehash",
+		ClientID:      clientID,
+		UserID:        userID,
+		RedirectURI:   "https://app.example.org/callback",
+		Scopes:        []string{ScopeOpenID, ScopeEmail},
+		Nonce:         "nonce",
+		CodeChallenge: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(time.Minute),
+	}
+}
+
+// TestOAuthGrant returns a test consent that the user with the given id gave to the client.
+func TestOAuthGrant(t *testing.T, clientID string, userID int) *OAuthGrant {
+	return &OAuthGrant{
+		UserID:    userID,
+		ClientID:  clientID,
+		Scopes:    []string{ScopeOpenID, ScopeEmail},
+		CreatedAt: time.Now(),
+	}
+}
+
+// TestExternalIdentity returns a test identity at an external provider that belongs to the user with the given id.
+func TestExternalIdentity(t *testing.T, userID int) *ExternalIdentity {
+	return &ExternalIdentity{
+		UserID:    userID,
+		Provider:  "google",
+		Subject:   "110169484474386276334",
+		Email:     "user@example.org",
+		CreatedAt: time.Now(),
+	}
+}
+
+// TestAuditEvent returns a test login event of the user with the given id.
+func TestAuditEvent(t *testing.T, userID int) *AuditEvent {
+	return &AuditEvent{
+		Type:      AuditLogin,
+		UserID:    userID,
+		IP:        "192.0.2.1",
+		UserAgent: "Mozilla/5.0",
+		RequestID: "request",
+		CreatedAt: time.Now(),
+	}
+}
@@ -2,11 +2,10 @@ package model
 
 import (
 	"database/sql"
-	"strings"
+	"time"
 
 	validation "github.com/go-ozzo/ozzo-validation"
 	"github.com/go-ozzo/ozzo-validation/is"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // User represents a user in the system.
@@ -16,32 +15,102 @@ import (
 // - Email: an optional field storing the user's email address.
 // - Password: the user's plaintext password (only used during creation, omitted in JSON responses).
 // - EncryptedPassword: stores the user's encrypted password.
+// - PasswordHasher: hashes Password into EncryptedPassword, DefaultPasswordHasher if it is not set.
+// - EmailVerifiedAt: the time when the user confirmed the email, unset until then.
+// - PendingEmail: the new email that replaces Email once the user confirms it.
+// - TOTPSecret: the secret of the TOTP second factor, it is set on enrollment before the factor is enabled.
+// - TOTPEnabledAt: the time when the TOTP second factor was enabled, unset if it is disabled.
+// - TOTPLastStep: the time step of the last accepted TOTP code, no code of this or an earlier step is accepted again.
+// - CreatedAt: the time when the user registered.
+// - DisabledAt: the time when an administrator disabled the user, unset if the user is active.
+// - DeletionScheduledAt: the time when the account is deleted as the user asked, unset if no deletion is pending.
+// - SessionsValidAfter: the time when all sessions of the user were ended, sessions started before it are not accepted.
+// - TelegramFirstName, TelegramLastName, TelegramUsername, TelegramPhotoURL, TelegramLanguageCode:
+// the profile of the linked Telegram account as Telegram passed it on the last login.
 type User struct {
-	ID                int            `json:"id"`
-	IDTelegram        sql.NullInt64  `json:"id_telegram"`
-	Email             sql.NullString `json:"email"`
-	Password          string         `json:"password,omitempty"`
-	EncryptedPassword sql.NullString `json:"-"`
+	ID                   int            `json:"id"`
+	IDTelegram           sql.NullInt64  `json:"id_telegram"`
+	Email                sql.NullString `json:"email"`
+	Password             string         `json:"password,omitempty"`
+	EncryptedPassword    sql.NullString `json:"-"`
+	PasswordHasher       PasswordHasher `json:"-"`
+	EmailVerifiedAt      sql.NullTime   `json:"email_verified_at"`
+	PendingEmail         sql.NullString `json:"pending_email"`
+	TOTPSecret           sql.NullString `json:"-"`
+	TOTPEnabledAt        sql.NullTime   `json:"totp_enabled_at"`
+	TOTPLastStep         sql.NullInt64  `json:"-"`
+	CreatedAt            time.Time      `json:"created_at"`
+	DisabledAt           sql.NullTime   `json:"disabled_at"`
+	DeletionScheduledAt  sql.NullTime   `json:"deletion_scheduled_at"`
+	SessionsValidAfter   sql.NullTime   `json:"-"`
+	TelegramFirstName    sql.NullString `json:"telegram_first_name"`
+	TelegramLastName     sql.NullString `json:"telegram_last_name"`
+	TelegramUsername     sql.NullString `json:"telegram_username"`
+	TelegramPhotoURL     sql.NullString `json:"telegram_photo_url"`
+	TelegramLanguageCode sql.NullString `json:"telegram_language_code"`
 }
 
 // Validate checks all parameters in the User struct for successful registration.
 // It uses go-ozzo/ozzo-validation library.
+// The rules of the password are checked by the PasswordPolicy of the server before.
 func (u *User) Validate() error {
 	return validation.ValidateStruct(
 		u,
 		validation.Field(&u.IDTelegram, validation.By(validationIf(!u.Email.Valid, validation.Required)), validation.By(validationIf(!u.Email.Valid, validation.Min(0)))),
 		validation.Field(&u.Email, validation.By(validationIf(!u.IDTelegram.Valid, validation.Required)), validation.By(validationIf(!u.IDTelegram.Valid, is.Email))),
-		validation.Field(&u.Password,
-			validation.By(validationIf(u.EncryptedPassword.String == "" && u.Email.Valid, validation.Required)),
-			validation.Length(6, 30),
-		),
+		validation.Field(&u.Password, validation.By(validationIf(u.EncryptedPassword.String == "" && u.Email.Valid, validation.Required))),
+	)
+}
+
+// ValidateEmailLogin checks the email and the password that are added to an existing account.
+func (u *User) ValidateEmailLogin() error {
+	return validation.ValidateStruct(
+		u,
+		validation.Field(&u.Email, validation.Required, is.Email),
+		validation.Field(&u.Password, validation.Required),
+	)
+}
+
+// ValidatePassword checks that the new password of an existing user is given,
+// its rules are checked by the PasswordPolicy of the server before.
+func (u *User) ValidatePassword() error {
+	return validation.ValidateStruct(
+		u,
+		validation.Field(&u.Password, validation.Required),
+	)
+}
+
+// ValidateExternal checks the user who registers with an external provider,
+// such a user has no password and may have no email.
+func (u *User) ValidateExternal() error {
+	return validation.ValidateStruct(
+		u,
+		validation.Field(&u.Email, validation.By(validationIf(u.Email.Valid, is.Email))),
+	)
+}
+
+// ValidateUpdate checks the fields of an existing user that are saved on update.
+// No login method and no password are required, the user may log in with an external provider only.
+func (u *User) ValidateUpdate() error {
+	return validation.ValidateStruct(
+		u,
+		validation.Field(&u.IDTelegram, validation.By(validationIf(u.IDTelegram.Valid, validation.Min(0)))),
+		validation.Field(&u.Email, validation.By(validationIf(u.Email.Valid, is.Email))),
+	)
+}
+
+// ValidatePendingEmail checks the new email that the user wants to change to.
+func (u *User) ValidatePendingEmail() error {
+	return validation.ValidateStruct(
+		u,
+		validation.Field(&u.PendingEmail, validation.Required, is.Email),
 	)
 }
 
 // BeforeCreate creates an encrypted password for the user.
 func (u *User) BeforeCreate() error {
 	if len(u.Password) > 0 {
-		enc, err := encryptedString(u.Password)
+		enc, err := u.passwordHasher().Hash(u.Password)
 		if err != nil {
 			return err
 		}
@@ -57,29 +126,37 @@ func (u *User) Sanitize() {
 	u.Password = ""
 }
 
+// NeedsEmailVerification checks if the user has an email that was not confirmed yet.
+func (u *User) NeedsEmailVerification() bool {
+	return u.Email.Valid && !u.EmailVerifiedAt.Valid
+}
+
+// HasTwoFactor checks if the user must confirm the login with a second factor.
+func (u *User) HasTwoFactor() bool {
+	return u.TOTPEnabledAt.Valid
+}
+
+// IsDisabled checks if the user was disabled by an administrator and may not log in.
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt.Valid
+}
+
 // ComparePassword checks if entered password matches with existing password.
+// The hash is verified by the scheme it was made with.
 func (u *User) ComparePassword(password string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(u.EncryptedPassword.String), []byte(password)) == nil
+	return passwordHasherFor(u.EncryptedPassword.String).Verify(u.EncryptedPassword.String, password)
 }
 
-// ecnryptedString generates a new encrypted string for the password.
-func encryptedString(s string) (string, error) {
-	b, err := bcrypt.GenerateFromPassword([]byte(s), bcrypt.MinCost)
-	if err != nil {
-		return "", err
-	}
-
-	return string(b), nil
+// NeedsRehash checks if the password of the user was not hashed by the hasher with its current parameters.
+func (u *User) NeedsRehash(h PasswordHasher) bool {
+	return u.EncryptedPassword.Valid && h.NeedsRehash(u.EncryptedPassword.String)
 }
 
-// CheckPassword checks if entered password meets the requirements.
-func CheckPassword(password string) bool {
-	if len(password) < 6 {
-		return false
-	} else if password == strings.ToUpper(strings.ToLower(password)) || password == strings.ToLower(strings.ToUpper(password)) {
-		return false
-	} else if !strings.ContainsAny(password, "1234567890") {
-		return false
+// passwordHasher returns the hasher of the password of the user.
+func (u *User) passwordHasher() PasswordHasher {
+	if u.PasswordHasher != nil {
+		return u.PasswordHasher
 	}
-	return true
+
+	return DefaultPasswordHasher
 }
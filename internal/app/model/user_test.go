@@ -1,10 +1,13 @@
 package model_test
 
 import (
+	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/http-rest-API/internal/app/model"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestUser_Validate(t *testing.T) {
@@ -96,3 +99,161 @@ func TestUser_BeforeCreate(t *testing.T) {
 	assert.NoError(t, u.BeforeCreate())
 	assert.NotEmpty(t, u.EncryptedPassword)
 }
+
+func TestUser_ValidateEmailLogin(t *testing.T) {
+	testCases := []struct {
+		name    string
+		u       func() *model.User
+		isValid bool
+	}{
+		{
+			name: "valid",
+			u: func() *model.User {
+				return model.TestUser(t)
+			},
+			isValid: true,
+		},
+		{
+			name: "valid with telegram",
+			u: func() *model.User {
+				u := model.TestUserWithTelegram(t)
+				u.Email = sql.NullString{String: "user@example.org", Valid: true}
+
+				return u
+			},
+			isValid: true,
+		},
+		{
+			name: "no email",
+			u: func() *model.User {
+				return model.TestUserWithTelegram(t)
+			},
+			isValid: false,
+		},
+		{
+			name: "invalid email with telegram",
+			u: func() *model.User {
+				u := model.TestUserWithTelegram(t)
+				u.Email = sql.NullString{String: "invalid", Valid: true}
+
+				return u
+			},
+			isValid: false,
+		},
+		{
+			name: "empty password",
+			u: func() *model.User {
+				u := model.TestUser(t)
+				u.Password = ""
+				u.EncryptedPassword = sql.NullString{String: "encryptedpassword", Valid: true}
+
+				return u
+			},
+			isValid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isValid {
+				assert.NoError(t, tc.u().ValidateEmailLogin())
+			} else {
+				assert.Error(t, tc.u().ValidateEmailLogin())
+			}
+		})
+	}
+}
+
+func TestUser_ValidatePassword(t *testing.T) {
+	u := model.TestUser(t)
+	assert.NoError(t, u.ValidatePassword())
+
+	u.Password = ""
+	assert.Error(t, u.ValidatePassword())
+}
+
+func TestUser_NeedsEmailVerification(t *testing.T) {
+	u := model.TestUser(t)
+	assert.True(t, u.NeedsEmailVerification())
+
+	u.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	assert.False(t, u.NeedsEmailVerification())
+
+	assert.False(t, model.TestUserWithTelegram(t).NeedsEmailVerification())
+}
+
+func TestUser_ValidatePendingEmail(t *testing.T) {
+	u := model.TestUser(t)
+	assert.Error(t, u.ValidatePendingEmail())
+
+	u.PendingEmail = sql.NullString{String: "invalid", Valid: true}
+	assert.Error(t, u.ValidatePendingEmail())
+
+	u.PendingEmail = sql.NullString{String: "new@example.org", Valid: true}
+	assert.NoError(t, u.ValidatePendingEmail())
+}
+
+func TestUser_ValidateExternal(t *testing.T) {
+	u := &model.User{}
+	assert.NoError(t, u.ValidateExternal())
+
+	u.Email = sql.NullString{String: "invalid", Valid: true}
+	assert.Error(t, u.ValidateExternal())
+
+	u.Email = sql.NullString{String: "user@example.org", Valid: true}
+	assert.NoError(t, u.ValidateExternal())
+}
+
+func TestUser_ValidateUpdate(t *testing.T) {
+	u := &model.User{}
+	assert.NoError(t, u.ValidateUpdate())
+
+	u.Email = sql.NullString{String: "invalid", Valid: true}
+	assert.Error(t, u.ValidateUpdate())
+
+	u.Email = sql.NullString{}
+	u.IDTelegram = sql.NullInt64{Int64: -1, Valid: true}
+	assert.Error(t, u.ValidateUpdate())
+
+	u.IDTelegram = sql.NullInt64{}
+	u.EncryptedPassword = sql.NullString{}
+	assert.NoError(t, u.ValidateUpdate())
+}
+
+func TestUser_HasTwoFactor(t *testing.T) {
+	u := model.TestUser(t)
+	assert.False(t, u.HasTwoFactor())
+
+	u.TOTPSecret = sql.NullString{String: "secret", Valid: true}
+	assert.False(t, u.HasTwoFactor())
+
+	u.TOTPEnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	assert.True(t, u.HasTwoFactor())
+}
+
+func TestUser_IsDisabled(t *testing.T) {
+	u := model.TestUser(t)
+	assert.False(t, u.IsDisabled())
+
+	u.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	assert.True(t, u.IsDisabled())
+}
+
+func TestUser_NeedsRehash(t *testing.T) {
+	bcryptHasher := &model.BcryptHasher{Cost: bcrypt.MinCost}
+	argon2idHasher := model.NewArgon2idHasher(1024, 1, 1)
+	u := model.TestUser(t)
+	u.PasswordHasher = bcryptHasher
+	assert.False(t, u.NeedsRehash(bcryptHasher))
+	assert.NoError(t, u.BeforeCreate())
+	assert.False(t, u.NeedsRehash(bcryptHasher))
+	assert.True(t, u.NeedsRehash(&model.BcryptHasher{Cost: bcrypt.MinCost + 1}))
+	assert.True(t, u.NeedsRehash(argon2idHasher))
+	assert.True(t, u.ComparePassword("password"))
+
+	u.PasswordHasher = argon2idHasher
+	assert.NoError(t, u.BeforeCreate())
+	assert.False(t, u.NeedsRehash(argon2idHasher))
+	assert.True(t, u.NeedsRehash(bcryptHasher))
+	assert.True(t, u.ComparePassword("password"))
+}
@@ -1,6 +1,9 @@
 package model
 
 import (
+	"errors"
+	"net/url"
+
 	validation "github.com/go-ozzo/ozzo-validation"
 )
 
@@ -14,3 +17,14 @@ func validationIf(cond bool, validationRule validation.Rule) validation.RuleFunc
 		return nil
 	}
 }
+
+// redirectURI checks that the value is an absolute URL without a fragment, as OAuth 2.0 requires of redirect URIs.
+func redirectURI(value interface{}) error {
+	s, _ := value.(string)
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Fragment != "" {
+		return errors.New("must be an absolute URL without a fragment")
+	}
+
+	return nil
+}
@@ -0,0 +1,22 @@
+package store
+
+import "time"
+
+// AuditEventFilter selects and pages the events returned by AuditEventRepository.List, the newest first.
+// It includes the following fields:
+// - UserID: the user the events must be about, zero means any user.
+// - ActorID: the administrator who must have made the events, zero means any events.
+// - Type: the type the events must have, empty means any type.
+// - IP: the client address the events must come from, empty means any address.
+// - After, Before: the range the time of the events must be in, zero means no bound.
+// - Limit, Offset: the page of the sorted events, zero Limit means all events.
+type AuditEventFilter struct {
+	UserID  int
+	ActorID int
+	Type    string
+	IP      string
+	After   time.Time
+	Before  time.Time
+	Limit   int
+	Offset  int
+}
@@ -3,5 +3,9 @@ package store
 import "errors"
 
 var (
-	ErrRecordNotFound = errors.New("record not found")
+	ErrRecordNotFound  = errors.New("record not found")
+	ErrEmailTaken      = errors.New("email is already used by another account")
+	ErrTelegramTaken   = errors.New("telegram account is already linked to another account")
+	ErrLastLoginMethod = errors.New("the last login method of the account cannot be unlinked")
+	ErrExternalTaken   = errors.New("external account is already linked to another account")
 )
@@ -1,6 +1,10 @@
 package store
 
-import "github.com/http-rest-API/internal/app/model"
+import (
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+)
 
 //UserRepository is a interface that allows you to use functions for working with database or map.
 type UserRepository interface {
@@ -8,4 +12,125 @@ type UserRepository interface {
 	Find(int) (*model.User, error)
 	FindByEmail(string) (*model.User, error)
 	FindByIDTelegram(int) (*model.User, error)
+	FindByExternalIdentity(provider string, subject string) (*model.User, error)
+	CreateWithExternalIdentity(*model.User, *model.ExternalIdentity) error
+	UpdateTelegramProfile(*model.User) error
+	LinkTelegram(*model.User) error
+	UnlinkTelegram(int) error
+	LinkEmail(*model.User) error
+	UnlinkEmail(int) error
+	VerifyEmail(id int, email string) error
+	SetPendingEmail(*model.User) error
+	ConfirmEmail(id int, email string) error
+	UpdatePassword(*model.User) error
+	ResetPassword(u *model.User, resetID int) error
+	ReplaceEncryptedPassword(id int, old string, new string) error
+	SetTOTPSecret(id int, secret string) error
+	EnableTOTP(int) error
+	DisableTOTP(int) error
+	UseTOTPStep(id int, step int64) error
+	SetSessionsValidAfter(id int, t time.Time) error
+	List(*UserFilter) ([]*model.User, int, error)
+	Update(*model.User) error
+	Delete(int) error
+	Anonymize(int) error
+}
+
+// SessionRepository is an interface that allows you to use functions for working with session records.
+type SessionRepository interface {
+	Create(*model.Session) error
+	Find(int) (*model.Session, error)
+	FindByTokenHash(string) (*model.Session, error)
+	FindByUser(int) ([]*model.Session, error)
+	Update(*model.Session) error
+	Delete(int) error
+	DeleteByUser(userID int, exceptID int) error
+}
+
+// RefreshTokenRepository is an interface that allows you to use functions for working with refresh tokens.
+type RefreshTokenRepository interface {
+	Create(*model.RefreshToken) error
+	FindByTokenHash(string) (*model.RefreshToken, error)
+	Rotate(int) error
+	RevokeFamily(string) error
+	RevokeByUser(int) error
+}
+
+// APIKeyRepository is an interface that allows you to use functions for working with API keys.
+type APIKeyRepository interface {
+	Create(*model.APIKey) error
+	Find(int) (*model.APIKey, error)
+	FindByPrefix(string) (*model.APIKey, error)
+	FindByUser(int) ([]*model.APIKey, error)
+	UpdateLastUsed(*model.APIKey) error
+	Delete(int) error
+}
+
+// PasswordResetRepository is an interface that allows you to use functions for working with password resets.
+type PasswordResetRepository interface {
+	Create(*model.PasswordReset) error
+	FindByTokenHash(string) (*model.PasswordReset, error)
+	Use(int) error
+}
+
+// RecoveryCodeRepository is an interface that allows you to use functions for working with recovery codes.
+type RecoveryCodeRepository interface {
+	Replace(userID int, codes []*model.RecoveryCode) error
+	Use(userID int, codeHash string) error
+	DeleteByUser(int) error
+}
+
+// LoginAttemptRepository is an interface that allows you to use functions for working with failed login attempts.
+type LoginAttemptRepository interface {
+	Find(key string) (*model.LoginAttempt, error)
+	Fail(key string, now time.Time, window time.Duration) (*model.LoginAttempt, error)
+	Reset(key string) error
+}
+
+// RoleRepository is an interface that allows you to use functions for working with roles of users.
+type RoleRepository interface {
+	FindByUser(int) ([]*model.Role, error)
+	Assign(userID int, role string) error
+	Unassign(userID int, role string) error
+}
+
+// OAuthClientRepository is an interface that allows you to use functions for working with OAuth clients.
+type OAuthClientRepository interface {
+	Create(*model.OAuthClient) error
+	FindByClientID(string) (*model.OAuthClient, error)
+	FindAll() ([]*model.OAuthClient, error)
+	Delete(int) error
+}
+
+// OAuthCodeRepository is an interface that allows you to use functions for working with authorization codes.
+type OAuthCodeRepository interface {
+	Create(*model.OAuthCode) error
+	Consume(codeHash string) (*model.OAuthCode, error)
+}
+
+// OAuthGrantRepository is an interface that allows you to use functions for working with the consents of users to clients.
+type OAuthGrantRepository interface {
+	Find(userID int, clientID string) (*model.OAuthGrant, error)
+	FindByUser(int) ([]*model.OAuthGrant, error)
+	Save(*model.OAuthGrant) error
+	Delete(userID int, clientID string) error
+}
+
+// ExternalIdentityRepository is an interface that allows you to use functions for working with the accounts of users at external providers.
+type ExternalIdentityRepository interface {
+	Create(*model.ExternalIdentity) error
+	FindByUser(int) ([]*model.ExternalIdentity, error)
+}
+
+// AuditEventRepository is an interface that allows you to use functions for working with audit events.
+type AuditEventRepository interface {
+	Create(*model.AuditEvent) error
+	List(*AuditEventFilter) ([]*model.AuditEvent, int, error)
+}
+
+// RevocationRepository is an interface that allows you to use functions for working with revoked identifiers,
+// such as ended sessions and used two-factor challenges.
+type RevocationRepository interface {
+	Revoke(id string, until time.Time) error
+	IsRevoked(id string) (bool, error)
 }
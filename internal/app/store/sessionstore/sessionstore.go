@@ -0,0 +1,233 @@
+package sessionstore
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+const (
+	defaultMaxAge = 86400 * 30
+	touchInterval = time.Minute
+)
+
+var (
+	errNoUserID = errors.New("session has no user_id")
+)
+
+// Store is a sessions.Store that keeps session records in a store.SessionRepository.
+// The cookie holds only an opaque token, the record is looked up by the hash of this token,
+// so deleting the record revokes the session.
+// It includes the following fields:
+// - Codecs: the codecs used for signing the cookie and encoding session values.
+// - Options: the default options of new sessions.
+// - repository: the repository of session records.
+type Store struct {
+	Codecs     []securecookie.Codec
+	Options    *sessions.Options
+	repository store.SessionRepository
+}
+
+// New returns a new Store that keeps session records in the given repository.
+// See securecookie.CodecsFromPairs for the meaning of keyPairs.
+func New(repository store.SessionRepository, keyPairs ...[]byte) *Store {
+	s := &Store{
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:   "/",
+			MaxAge: defaultMaxAge,
+		},
+		repository: repository,
+	}
+
+	s.MaxAge(s.Options.MaxAge)
+	return s
+}
+
+// MaxAge sets the maximum age for the store and the underlying cookie implementation.
+func (s *Store) MaxAge(age int) {
+	s.Options.MaxAge = age
+	for _, codec := range s.Codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(age)
+		}
+	}
+}
+
+// Get returns a session for the given name after adding it to the registry.
+func (s *Store) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns a session for the given name without adding it to the registry.
+// A missing, revoked or expired record results in a new empty session.
+func (s *Store) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+
+	var token string
+	if err := securecookie.DecodeMulti(name, c.Value, &token, s.Codecs...); err != nil {
+		return session, err
+	}
+
+	rec, err := s.repository.FindByTokenHash(hashToken(token))
+	if err != nil {
+		if err == store.ErrRecordNotFound {
+			return session, nil
+		}
+
+		return session, err
+	}
+
+	now := time.Now()
+	if rec.IsExpired(now) {
+		if err := s.repository.Delete(rec.ID); err != nil && err != store.ErrRecordNotFound {
+			return session, err
+		}
+
+		return session, nil
+	}
+
+	if err := securecookie.DecodeMulti(name, rec.Data, &session.Values, s.Codecs...); err != nil {
+		return session, err
+	}
+
+	if now.Sub(rec.LastSeenAt) >= touchInterval {
+		rec.LastSeenAt = now
+		rec.IP = remoteIP(r)
+		rec.UserAgent = r.UserAgent()
+		if err := s.repository.Update(rec); err != nil {
+			return session, err
+		}
+	}
+
+	session.ID = strconv.Itoa(rec.ID)
+	session.IsNew = false
+	return session, nil
+}
+
+// Save writes the session record and sets the cookie with its token.
+// If the Options.MaxAge of the session is < 0 then the record is deleted and the cookie is expired.
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.delete(session); err != nil {
+				return err
+			}
+		}
+
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	userID, ok := session.Values["user_id"].(int)
+	if !ok {
+		return errNoUserID
+	}
+
+	data, err := securecookie.EncodeMulti(session.Name(), session.Values, s.Codecs...)
+	if err != nil {
+		return err
+	}
+
+	if session.ID != "" {
+		return s.update(r, session, data)
+	}
+
+	maxAge := session.Options.MaxAge
+	if maxAge == 0 {
+		maxAge = s.Options.MaxAge
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+	now := time.Now()
+	rec := &model.Session{
+		UserID:     userID,
+		TokenHash:  hashToken(token),
+		Data:       data,
+		IP:         remoteIP(r),
+		UserAgent:  r.UserAgent(),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Duration(maxAge) * time.Second),
+	}
+	if err := s.repository.Create(rec); err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), token, s.Codecs...)
+	if err != nil {
+		return err
+	}
+
+	session.ID = strconv.Itoa(rec.ID)
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// update writes new values of an existing session record, the cookie is left as it is.
+func (s *Store) update(r *http.Request, session *sessions.Session, data string) error {
+	id, err := strconv.Atoi(session.ID)
+	if err != nil {
+		return err
+	}
+
+	rec, err := s.repository.Find(id)
+	if err != nil {
+		return err
+	}
+
+	rec.Data = data
+	rec.IP = remoteIP(r)
+	rec.UserAgent = r.UserAgent()
+	rec.LastSeenAt = time.Now()
+
+	return s.repository.Update(rec)
+}
+
+// delete removes the session record, a record that is already gone is not an error.
+func (s *Store) delete(session *sessions.Session) error {
+	id, err := strconv.Atoi(session.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repository.Delete(id); err != nil && err != store.ErrRecordNotFound {
+		return err
+	}
+
+	return nil
+}
+
+// hashToken returns the hash of the session token that is stored instead of the token itself.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// remoteIP returns the address of the client without the port.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
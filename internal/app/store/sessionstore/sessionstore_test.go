@@ -0,0 +1,105 @@
+package sessionstore_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store/sessionstore"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+const sessionName = "test"
+
+func TestStore_SaveAndGet(t *testing.T) {
+	repository := teststore.New().Session()
+	s := sessionstore.New(repository, []byte("secret"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	session, err := s.New(req, sessionName)
+	assert.NoError(t, err)
+	assert.True(t, session.IsNew)
+
+	session.Values["user_id"] = 1
+	rec := httptest.NewRecorder()
+	assert.NoError(t, s.Save(req, rec, session))
+	assert.NotEmpty(t, session.ID)
+
+	req, _ = http.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+
+	session, err = s.New(req, sessionName)
+	assert.NoError(t, err)
+	assert.False(t, session.IsNew)
+	assert.Equal(t, 1, session.Values["user_id"])
+}
+
+func TestStore_SaveWithoutUser(t *testing.T) {
+	s := sessionstore.New(teststore.New().Session(), []byte("secret"))
+
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	session, _ := s.New(req, sessionName)
+	assert.Error(t, s.Save(req, httptest.NewRecorder(), session))
+}
+
+func TestStore_New(t *testing.T) {
+	repository := teststore.New().Session()
+	s := sessionstore.New(repository, []byte("secret"))
+
+	testCases := []struct {
+		name   string
+		change func(t *testing.T, rec *model.Session)
+		isNew  bool
+	}{
+		{
+			name:   "valid",
+			change: func(t *testing.T, rec *model.Session) {},
+			isNew:  false,
+		},
+		{
+			name: "revoked",
+			change: func(t *testing.T, rec *model.Session) {
+				assert.NoError(t, repository.Delete(rec.ID))
+			},
+			isNew: true,
+		},
+		{
+			name: "expired",
+			change: func(t *testing.T, rec *model.Session) {
+				rec.ExpiresAt = time.Now().Add(-time.Minute)
+				assert.NoError(t, repository.Update(rec))
+			},
+			isNew: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/", nil)
+			session, _ := s.New(req, sessionName)
+			session.Values["user_id"] = 1
+			w := httptest.NewRecorder()
+			assert.NoError(t, s.Save(req, w, session))
+
+			id, _ := strconv.Atoi(session.ID)
+			rec, err := repository.Find(id)
+			assert.NoError(t, err)
+			tc.change(t, rec)
+
+			req, _ = http.NewRequest(http.MethodGet, "/", nil)
+			for _, c := range w.Result().Cookies() {
+				req.AddCookie(c)
+			}
+
+			session, err = s.New(req, sessionName)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.isNew, session.IsNew)
+		})
+	}
+}
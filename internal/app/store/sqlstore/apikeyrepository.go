@@ -0,0 +1,133 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/lib/pq"
+)
+
+type APIKeyRepository struct {
+	store *Store
+}
+
+// Create adds a new API key into database (it validates before adding).
+func (r *APIKeyRepository) Create(k *model.APIKey) error {
+	if err := k.Validate(); err != nil {
+		return err
+	}
+
+	if k.Scopes == nil {
+		k.Scopes = []string{}
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, created_at, expires_at) VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		k.UserID,
+		k.Name,
+		k.Prefix,
+		k.KeyHash,
+		pq.Array(k.Scopes),
+		k.CreatedAt,
+		k.ExpiresAt,
+	).Scan(&k.ID)
+}
+
+// Find finds the API key in database by using its id.
+func (r *APIKeyRepository) Find(id int) (*model.APIKey, error) {
+	return r.findBy("id", id)
+}
+
+// FindByPrefix finds the API key in database by using its visible prefix.
+func (r *APIKeyRepository) FindByPrefix(prefix string) (*model.APIKey, error) {
+	return r.findBy("prefix", prefix)
+}
+
+// FindByUser finds all API keys of the user, the newest first.
+func (r *APIKeyRepository) FindByUser(userID int) ([]*model.APIKey, error) {
+	rows, err := r.store.db.Query(
+		"SELECT id, user_id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at, last_used_ip FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*model.APIKey{}
+	for rows.Next() {
+		k := &model.APIKey{}
+		if err := rows.Scan(
+			&k.ID,
+			&k.UserID,
+			&k.Name,
+			&k.Prefix,
+			&k.KeyHash,
+			pq.Array(&k.Scopes),
+			&k.CreatedAt,
+			&k.ExpiresAt,
+			&k.LastUsedAt,
+			&k.LastUsedIP,
+		); err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+// UpdateLastUsed saves the time and the address of the last use of the API key.
+func (r *APIKeyRepository) UpdateLastUsed(k *model.APIKey) error {
+	res, err := r.store.db.Exec(
+		"UPDATE api_keys SET last_used_at = $2, last_used_ip = $3 WHERE id = $1",
+		k.ID,
+		k.LastUsedAt,
+		k.LastUsedIP,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// Delete removes the API key from database.
+func (r *APIKeyRepository) Delete(id int) error {
+	res, err := r.store.db.Exec("DELETE FROM api_keys WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// findBy finds the API key in database by the value of the given column.
+func (r *APIKeyRepository) findBy(column string, value interface{}) (*model.APIKey, error) {
+	k := &model.APIKey{}
+	if err := r.store.db.QueryRow(
+		"SELECT id, user_id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at, last_used_ip FROM api_keys WHERE "+column+" = $1",
+		value,
+	).Scan(
+		&k.ID,
+		&k.UserID,
+		&k.Name,
+		&k.Prefix,
+		&k.KeyHash,
+		pq.Array(&k.Scopes),
+		&k.CreatedAt,
+		&k.ExpiresAt,
+		&k.LastUsedAt,
+		&k.LastUsedIP,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return k, nil
+}
@@ -0,0 +1,98 @@
+package sqlstore_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("api_keys", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	k := model.TestAPIKey(t, u.ID)
+	assert.NoError(t, s.APIKey().Create(k))
+	assert.NotZero(t, k.ID)
+}
+
+func TestAPIKeyRepository_FindByPrefix(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("api_keys", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	k := model.TestAPIKey(t, u.ID)
+	_, err := s.APIKey().FindByPrefix(k.Prefix)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	s.APIKey().Create(k)
+	k2, err := s.APIKey().FindByPrefix(k.Prefix)
+	assert.NoError(t, err)
+	assert.Equal(t, k.Scopes, k2.Scopes)
+}
+
+func TestAPIKeyRepository_FindByUser(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("api_keys", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	k1 := model.TestAPIKey(t, u.ID)
+	k1.Prefix = "brk_prefix1"
+	s.APIKey().Create(k1)
+	k2 := model.TestAPIKey(t, u.ID)
+	k2.Prefix = "brk_prefix2"
+	s.APIKey().Create(k2)
+
+	keys, err := s.APIKey().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+}
+
+func TestAPIKeyRepository_UpdateLastUsed(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("api_keys", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	k := model.TestAPIKey(t, u.ID)
+	s.APIKey().Create(k)
+	k.LastUsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	k.LastUsedIP = "127.0.0.1"
+	assert.NoError(t, s.APIKey().UpdateLastUsed(k))
+
+	k2, err := s.APIKey().Find(k.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", k2.LastUsedIP)
+}
+
+func TestAPIKeyRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("api_keys", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	k := model.TestAPIKey(t, u.ID)
+	s.APIKey().Create(k)
+	assert.NoError(t, s.APIKey().Delete(k.ID))
+
+	_, err := s.APIKey().Find(k.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
@@ -0,0 +1,104 @@
+package sqlstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+type AuditEventRepository struct {
+	store *Store
+}
+
+// Create adds the event into database, a zero user or actor is kept as NULL.
+func (r *AuditEventRepository) Create(e *model.AuditEvent) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO audit_events (type, user_id, actor_id, ip, user_agent, request_id, created_at) VALUES($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5, $6, $7) RETURNING id",
+		e.Type,
+		e.UserID,
+		e.ActorID,
+		e.IP,
+		e.UserAgent,
+		e.RequestID,
+		e.CreatedAt,
+	).Scan(&e.ID)
+}
+
+// List finds the page of the events selected by the filter, the newest first, and the number of all selected events.
+func (r *AuditEventRepository) List(f *store.AuditEventFilter) ([]*model.AuditEvent, int, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.UserID != 0 {
+		where("user_id = $%d", f.UserID)
+	}
+
+	if f.ActorID != 0 {
+		where("actor_id = $%d", f.ActorID)
+	}
+
+	if f.Type != "" {
+		where("type = $%d", f.Type)
+	}
+
+	if f.IP != "" {
+		where("ip = $%d", f.IP)
+	}
+
+	if !f.After.IsZero() {
+		where("created_at >= $%d", f.After)
+	}
+
+	if !f.Before.IsZero() {
+		where("created_at < $%d", f.Before)
+	}
+
+	clause := ""
+	if len(conditions) > 0 {
+		clause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.store.db.QueryRow("SELECT count(*) FROM audit_events"+clause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT id, type, COALESCE(user_id, 0), COALESCE(actor_id, 0), ip, user_agent, request_id, created_at FROM audit_events" + clause + " ORDER BY created_at DESC, id DESC"
+	if f.Limit > 0 {
+		args = append(args, f.Limit, f.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := r.store.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events := []*model.AuditEvent{}
+	for rows.Next() {
+		e := &model.AuditEvent{}
+		if err := rows.Scan(
+			&e.ID,
+			&e.Type,
+			&e.UserID,
+			&e.ActorID,
+			&e.IP,
+			&e.UserAgent,
+			&e.RequestID,
+			&e.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+
+		events = append(events, e)
+	}
+
+	return events, total, rows.Err()
+}
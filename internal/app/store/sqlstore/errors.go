@@ -0,0 +1,37 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolation = "23505"
+)
+
+// checkAffected returns store.ErrRecordNotFound if the statement did not change any row.
+func checkAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// isUniqueViolation reports whether the statement failed because of the given unique constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code == uniqueViolation && pqErr.Constraint == constraint
+}
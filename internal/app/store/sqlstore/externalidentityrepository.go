@@ -0,0 +1,63 @@
+package sqlstore
+
+import (
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+type ExternalIdentityRepository struct {
+	store *Store
+}
+
+// Create links the account at the external provider to the user in database (it validates before adding).
+// It returns store.ErrExternalTaken if the account is linked to a user already.
+func (r *ExternalIdentityRepository) Create(i *model.ExternalIdentity) error {
+	if err := i.Validate(); err != nil {
+		return err
+	}
+
+	err := r.store.db.QueryRow(
+		"INSERT INTO external_identities (user_id, provider, subject, email, created_at) VALUES($1, $2, $3, $4, $5) RETURNING id",
+		i.UserID,
+		i.Provider,
+		i.Subject,
+		i.Email,
+		i.CreatedAt,
+	).Scan(&i.ID)
+	if isUniqueViolation(err, "external_identities_provider_subject_key") {
+		return store.ErrExternalTaken
+	}
+
+	return err
+}
+
+// FindByUser finds all accounts at external providers linked to the user, the oldest first.
+func (r *ExternalIdentityRepository) FindByUser(userID int) ([]*model.ExternalIdentity, error) {
+	rows, err := r.store.db.Query(
+		"SELECT id, user_id, provider, subject, email, created_at FROM external_identities WHERE user_id = $1 ORDER BY id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []*model.ExternalIdentity{}
+	for rows.Next() {
+		i := &model.ExternalIdentity{}
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Provider,
+			&i.Subject,
+			&i.Email,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		identities = append(identities, i)
+	}
+
+	return identities, rows.Err()
+}
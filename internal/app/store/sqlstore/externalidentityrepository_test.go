@@ -0,0 +1,62 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalIdentityRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("external_identities", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	i := model.TestExternalIdentity(t, u.ID)
+	i.Subject = ""
+	assert.Error(t, s.ExternalIdentity().Create(i))
+
+	i = model.TestExternalIdentity(t, u.ID)
+	assert.NoError(t, s.ExternalIdentity().Create(i))
+	assert.NotZero(t, i.ID)
+	assert.EqualError(t, s.ExternalIdentity().Create(model.TestExternalIdentity(t, u.ID)), store.ErrExternalTaken.Error())
+
+	found, err := s.User().FindByExternalIdentity(i.Provider, i.Subject)
+	assert.NoError(t, err)
+	assert.Equal(t, u.ID, found.ID)
+}
+
+func TestExternalIdentityRepository_FindByUser(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("external_identities", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	identities, err := s.ExternalIdentity().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, identities)
+
+	i1 := model.TestExternalIdentity(t, u.ID)
+	s.ExternalIdentity().Create(i1)
+	i2 := model.TestExternalIdentity(t, u.ID)
+	i2.Provider = "gitlab"
+	s.ExternalIdentity().Create(i2)
+
+	identities, err = s.ExternalIdentity().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, identities, 2)
+	assert.Equal(t, i1.ID, identities[0].ID)
+	assert.Equal(t, "gitlab", identities[1].Provider)
+
+	assert.NoError(t, s.User().Anonymize(u.ID))
+	identities, err = s.ExternalIdentity().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, identities)
+}
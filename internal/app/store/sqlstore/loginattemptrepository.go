@@ -0,0 +1,65 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+type LoginAttemptRepository struct {
+	store *Store
+}
+
+// Find finds the failed login attempts in database by using their key.
+func (r *LoginAttemptRepository) Find(key string) (*model.LoginAttempt, error) {
+	a := &model.LoginAttempt{}
+	if err := r.store.db.QueryRow(
+		"SELECT key, failures, last_failed_at FROM login_attempts WHERE key = $1",
+		key,
+	).Scan(
+		&a.Key,
+		&a.Failures,
+		&a.LastFailedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Fail records a failed login attempt for the key and returns all failures in a row.
+// The count starts again if the previous failure is older than the window.
+func (r *LoginAttemptRepository) Fail(key string, now time.Time, window time.Duration) (*model.LoginAttempt, error) {
+	a := &model.LoginAttempt{}
+	if err := r.store.db.QueryRow(
+		"INSERT INTO login_attempts (key, failures, last_failed_at) VALUES($1, 1, $2) "+
+			"ON CONFLICT (key) DO UPDATE SET failures = CASE WHEN login_attempts.last_failed_at < $3 THEN 1 ELSE login_attempts.failures + 1 END, last_failed_at = $2 "+
+			"RETURNING key, failures, last_failed_at",
+		key,
+		now,
+		now.Add(-window),
+	).Scan(
+		&a.Key,
+		&a.Failures,
+		&a.LastFailedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Reset removes the failed login attempts of the key.
+func (r *LoginAttemptRepository) Reset(key string) error {
+	_, err := r.store.db.Exec(
+		"DELETE FROM login_attempts WHERE key = $1",
+		key,
+	)
+	return err
+}
@@ -0,0 +1,57 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAttemptRepository_Fail(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("login_attempts")
+
+	s := sqlstore.New(db)
+	now := time.Now().Truncate(time.Second)
+
+	a, err := s.LoginAttempt().Fail("user@example.org", now, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, a.Failures)
+
+	a, err = s.LoginAttempt().Fail("user@example.org", now.Add(time.Second), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, a.Failures)
+	assert.True(t, now.Add(time.Second).Equal(a.LastFailedAt))
+
+	a, err = s.LoginAttempt().Fail("user@example.org", now.Add(time.Hour), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, a.Failures)
+}
+
+func TestLoginAttemptRepository_Find(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("login_attempts")
+
+	s := sqlstore.New(db)
+	_, err := s.LoginAttempt().Find("user@example.org")
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	s.LoginAttempt().Fail("user@example.org", time.Now(), time.Minute)
+	a, err := s.LoginAttempt().Find("user@example.org")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, a.Failures)
+}
+
+func TestLoginAttemptRepository_Reset(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("login_attempts")
+
+	s := sqlstore.New(db)
+	s.LoginAttempt().Fail("user@example.org", time.Now(), time.Minute)
+	assert.NoError(t, s.LoginAttempt().Reset("user@example.org"))
+
+	_, err := s.LoginAttempt().Find("user@example.org")
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
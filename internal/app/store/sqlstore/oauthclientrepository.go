@@ -0,0 +1,93 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/lib/pq"
+)
+
+type OAuthClientRepository struct {
+	store *Store
+}
+
+// Create adds a new OAuth client into database (it validates before adding).
+func (r *OAuthClientRepository) Create(c *model.OAuthClient) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO oauth_clients (client_id, secret_hash, name, redirect_uris, created_at) VALUES($1, $2, $3, $4, $5) RETURNING id",
+		c.ClientID,
+		c.SecretHash,
+		c.Name,
+		pq.Array(c.RedirectURIs),
+		c.CreatedAt,
+	).Scan(&c.ID)
+}
+
+// FindByClientID finds the OAuth client in database by using its public identifier.
+func (r *OAuthClientRepository) FindByClientID(clientID string) (*model.OAuthClient, error) {
+	c := &model.OAuthClient{}
+	if err := r.store.db.QueryRow(
+		"SELECT id, client_id, secret_hash, name, redirect_uris, created_at FROM oauth_clients WHERE client_id = $1",
+		clientID,
+	).Scan(
+		&c.ID,
+		&c.ClientID,
+		&c.SecretHash,
+		&c.Name,
+		pq.Array(&c.RedirectURIs),
+		&c.CreatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// FindAll finds all OAuth clients in the order they were registered.
+func (r *OAuthClientRepository) FindAll() ([]*model.OAuthClient, error) {
+	rows, err := r.store.db.Query(
+		"SELECT id, client_id, secret_hash, name, redirect_uris, created_at FROM oauth_clients ORDER BY id",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []*model.OAuthClient{}
+	for rows.Next() {
+		c := &model.OAuthClient{}
+		if err := rows.Scan(
+			&c.ID,
+			&c.ClientID,
+			&c.SecretHash,
+			&c.Name,
+			pq.Array(&c.RedirectURIs),
+			&c.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		clients = append(clients, c)
+	}
+
+	return clients, rows.Err()
+}
+
+// Delete removes the OAuth client from database with its codes and grants.
+func (r *OAuthClientRepository) Delete(id int) error {
+	res, err := r.store.db.Exec("DELETE FROM oauth_clients WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
@@ -0,0 +1,69 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthClientRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("oauth_clients")
+
+	s := sqlstore.New(db)
+	c := model.TestOAuthClient(t)
+	assert.NoError(t, s.OAuthClient().Create(c))
+	assert.NotZero(t, c.ID)
+
+	c = model.TestOAuthClient(t)
+	c.RedirectURIs = nil
+	assert.Error(t, s.OAuthClient().Create(c))
+}
+
+func TestOAuthClientRepository_FindByClientID(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("oauth_clients")
+
+	s := sqlstore.New(db)
+	c := model.TestOAuthClient(t)
+	_, err := s.OAuthClient().FindByClientID(c.ClientID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	s.OAuthClient().Create(c)
+	c2, err := s.OAuthClient().FindByClientID(c.ClientID)
+	assert.NoError(t, err)
+	assert.Equal(t, c.ID, c2.ID)
+	assert.Equal(t, c.RedirectURIs, c2.RedirectURIs)
+}
+
+func TestOAuthClientRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("oauth_grants", "oauth_codes", "oauth_clients", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestOAuthClient(t)
+	s.OAuthClient().Create(c)
+	s.OAuthCode().Create(model.TestOAuthCode(t, c.ClientID, u.ID))
+	s.OAuthGrant().Save(model.TestOAuthGrant(t, c.ClientID, u.ID))
+	c2 := model.TestOAuthClient(t)
+	c2.ClientID = "other"
+	s.OAuthClient().Create(c2)
+
+	assert.NoError(t, s.OAuthClient().Delete(c.ID))
+	assert.EqualError(t, s.OAuthClient().Delete(c.ID), store.ErrRecordNotFound.Error())
+
+	clients, err := s.OAuthClient().FindAll()
+	assert.NoError(t, err)
+	assert.Len(t, clients, 1)
+	assert.Equal(t, c2.ID, clients[0].ID)
+
+	_, err = s.OAuthCode().Consume("codehash")
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	_, err = s.OAuthGrant().Find(u.ID, c.ClientID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
@@ -0,0 +1,63 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/lib/pq"
+)
+
+type OAuthCodeRepository struct {
+	store *Store
+}
+
+// Create adds a new authorization code into database.
+func (r *OAuthCodeRepository) Create(c *model.OAuthCode) error {
+	if c.Scopes == nil {
+		c.Scopes = []string{}
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO oauth_codes (code_hash, client_id, user_id, redirect_uri, scopes, nonce, code_challenge, created_at, expires_at) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id",
+		c.CodeHash,
+		c.ClientID,
+		c.UserID,
+		c.RedirectURI,
+		pq.Array(c.Scopes),
+		c.Nonce,
+		c.CodeChallenge,
+		c.CreatedAt,
+		c.ExpiresAt,
+	).Scan(&c.ID)
+}
+
+// Consume removes the authorization code from database by using the hash of the code and returns it.
+// It returns store.ErrRecordNotFound if there is no such code,
+// so only one of concurrent requests can exchange the same code.
+func (r *OAuthCodeRepository) Consume(codeHash string) (*model.OAuthCode, error) {
+	c := &model.OAuthCode{}
+	if err := r.store.db.QueryRow(
+		"DELETE FROM oauth_codes WHERE code_hash = $1 RETURNING id, code_hash, client_id, user_id, redirect_uri, scopes, nonce, code_challenge, created_at, expires_at",
+		codeHash,
+	).Scan(
+		&c.ID,
+		&c.CodeHash,
+		&c.ClientID,
+		&c.UserID,
+		&c.RedirectURI,
+		pq.Array(&c.Scopes),
+		&c.Nonce,
+		&c.CodeChallenge,
+		&c.CreatedAt,
+		&c.ExpiresAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return c, nil
+}
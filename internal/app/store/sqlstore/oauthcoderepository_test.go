@@ -0,0 +1,34 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthCodeRepository_Consume(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("oauth_codes", "oauth_clients", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	client := model.TestOAuthClient(t)
+	s.OAuthClient().Create(client)
+
+	c := model.TestOAuthCode(t, client.ClientID, u.ID)
+	assert.NoError(t, s.OAuthCode().Create(c))
+	assert.NotZero(t, c.ID)
+
+	c2, err := s.OAuthCode().Consume(c.CodeHash)
+	assert.NoError(t, err)
+	assert.Equal(t, c.ID, c2.ID)
+	assert.Equal(t, c.Scopes, c2.Scopes)
+	assert.Equal(t, c.CodeChallenge, c2.CodeChallenge)
+
+	_, err = s.OAuthCode().Consume(c.CodeHash)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
@@ -0,0 +1,96 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/lib/pq"
+)
+
+type OAuthGrantRepository struct {
+	store *Store
+}
+
+// Find finds the consent of the user to the client in database.
+func (r *OAuthGrantRepository) Find(userID int, clientID string) (*model.OAuthGrant, error) {
+	g := &model.OAuthGrant{}
+	if err := r.store.db.QueryRow(
+		"SELECT id, user_id, client_id, scopes, created_at FROM oauth_grants WHERE user_id = $1 AND client_id = $2",
+		userID,
+		clientID,
+	).Scan(
+		&g.ID,
+		&g.UserID,
+		&g.ClientID,
+		pq.Array(&g.Scopes),
+		&g.CreatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// FindByUser finds all consents of the user, the oldest first.
+func (r *OAuthGrantRepository) FindByUser(userID int) ([]*model.OAuthGrant, error) {
+	rows, err := r.store.db.Query(
+		"SELECT id, user_id, client_id, scopes, created_at FROM oauth_grants WHERE user_id = $1 ORDER BY id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []*model.OAuthGrant{}
+	for rows.Next() {
+		g := &model.OAuthGrant{}
+		if err := rows.Scan(
+			&g.ID,
+			&g.UserID,
+			&g.ClientID,
+			pq.Array(&g.Scopes),
+			&g.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		grants = append(grants, g)
+	}
+
+	return grants, rows.Err()
+}
+
+// Save adds the consent of the user to the client into database, or replaces the scopes of the existing one.
+func (r *OAuthGrantRepository) Save(g *model.OAuthGrant) error {
+	if g.Scopes == nil {
+		g.Scopes = []string{}
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO oauth_grants (user_id, client_id, scopes, created_at) VALUES($1, $2, $3, $4) ON CONFLICT (user_id, client_id) DO UPDATE SET scopes = EXCLUDED.scopes RETURNING id, created_at",
+		g.UserID,
+		g.ClientID,
+		pq.Array(g.Scopes),
+		g.CreatedAt,
+	).Scan(&g.ID, &g.CreatedAt)
+}
+
+// Delete removes the consent of the user to the client from database.
+func (r *OAuthGrantRepository) Delete(userID int, clientID string) error {
+	res, err := r.store.db.Exec(
+		"DELETE FROM oauth_grants WHERE user_id = $1 AND client_id = $2",
+		userID,
+		clientID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
@@ -0,0 +1,56 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthGrantRepository_Save(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("oauth_grants", "oauth_clients", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestOAuthClient(t)
+	s.OAuthClient().Create(c)
+
+	_, err := s.OAuthGrant().Find(u.ID, c.ClientID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	g := model.TestOAuthGrant(t, c.ClientID, u.ID)
+	assert.NoError(t, s.OAuthGrant().Save(g))
+	assert.NotZero(t, g.ID)
+
+	g2 := model.TestOAuthGrant(t, c.ClientID, u.ID)
+	g2.Scopes = []string{model.ScopeOpenID, model.ScopeProfile}
+	assert.NoError(t, s.OAuthGrant().Save(g2))
+	assert.Equal(t, g.ID, g2.ID)
+
+	found, err := s.OAuthGrant().Find(u.ID, c.ClientID)
+	assert.NoError(t, err)
+	assert.Equal(t, g2.Scopes, found.Scopes)
+
+	grants, err := s.OAuthGrant().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, grants, 1)
+}
+
+func TestOAuthGrantRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("oauth_grants", "oauth_clients", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+	c := model.TestOAuthClient(t)
+	s.OAuthClient().Create(c)
+	s.OAuthGrant().Save(model.TestOAuthGrant(t, c.ClientID, u.ID))
+
+	assert.NoError(t, s.OAuthGrant().Delete(u.ID, c.ClientID))
+	assert.EqualError(t, s.OAuthGrant().Delete(u.ID, c.ClientID), store.ErrRecordNotFound.Error())
+}
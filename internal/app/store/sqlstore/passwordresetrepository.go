@@ -0,0 +1,62 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+type PasswordResetRepository struct {
+	store *Store
+}
+
+// Create adds a new password reset into database.
+func (r *PasswordResetRepository) Create(p *model.PasswordReset) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO password_resets (user_id, token_hash, created_at, expires_at) VALUES($1, $2, $3, $4) RETURNING id",
+		p.UserID,
+		p.TokenHash,
+		p.CreatedAt,
+		p.ExpiresAt,
+	).Scan(&p.ID)
+}
+
+// FindByTokenHash finds the password reset in database by using the hash of its token.
+func (r *PasswordResetRepository) FindByTokenHash(tokenHash string) (*model.PasswordReset, error) {
+	p := &model.PasswordReset{}
+	if err := r.store.db.QueryRow(
+		"SELECT id, user_id, token_hash, created_at, expires_at, used_at FROM password_resets WHERE token_hash = $1",
+		tokenHash,
+	).Scan(
+		&p.ID,
+		&p.UserID,
+		&p.TokenHash,
+		&p.CreatedAt,
+		&p.ExpiresAt,
+		&p.UsedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Use marks the password reset as used.
+// It returns store.ErrRecordNotFound if the reset was already used,
+// so only one of concurrent requests can use the same token.
+func (r *PasswordResetRepository) Use(id int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE password_resets SET used_at = now() WHERE id = $1 AND used_at IS NULL",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
@@ -0,0 +1,59 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResetRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("password_resets", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	p := model.TestPasswordReset(t, u.ID)
+	assert.NoError(t, s.PasswordReset().Create(p))
+	assert.NotZero(t, p.ID)
+}
+
+func TestPasswordResetRepository_FindByTokenHash(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("password_resets", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	p := model.TestPasswordReset(t, u.ID)
+	_, err := s.PasswordReset().FindByTokenHash(p.TokenHash)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	s.PasswordReset().Create(p)
+	p2, err := s.PasswordReset().FindByTokenHash(p.TokenHash)
+	assert.NoError(t, err)
+	assert.Equal(t, p.ID, p2.ID)
+}
+
+func TestPasswordResetRepository_Use(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("password_resets", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	p := model.TestPasswordReset(t, u.ID)
+	s.PasswordReset().Create(p)
+	assert.NoError(t, s.PasswordReset().Use(p.ID))
+	assert.EqualError(t, s.PasswordReset().Use(p.ID), store.ErrRecordNotFound.Error())
+
+	p, err := s.PasswordReset().FindByTokenHash(p.TokenHash)
+	assert.NoError(t, err)
+	assert.True(t, p.IsUsed())
+}
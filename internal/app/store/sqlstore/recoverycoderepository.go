@@ -0,0 +1,60 @@
+package sqlstore
+
+import (
+	"github.com/http-rest-API/internal/app/model"
+)
+
+type RecoveryCodeRepository struct {
+	store *Store
+}
+
+// Replace removes all recovery codes of the user and adds the new ones in one transaction.
+func (r *RecoveryCodeRepository) Replace(userID int, codes []*model.RecoveryCode) error {
+	tx, err := r.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+
+	for _, c := range codes {
+		c.UserID = userID
+		if err := tx.QueryRow(
+			"INSERT INTO recovery_codes (user_id, code_hash, created_at) VALUES($1, $2, $3) RETURNING id",
+			c.UserID,
+			c.CodeHash,
+			c.CreatedAt,
+		).Scan(&c.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Use marks the unused recovery code of the user as used.
+// It returns store.ErrRecordNotFound if the user has no such unused code.
+func (r *RecoveryCodeRepository) Use(userID int, codeHash string) error {
+	res, err := r.store.db.Exec(
+		"UPDATE recovery_codes SET used_at = now() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL",
+		userID,
+		codeHash,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// DeleteByUser removes all recovery codes of the user.
+func (r *RecoveryCodeRepository) DeleteByUser(userID int) error {
+	_, err := r.store.db.Exec(
+		"DELETE FROM recovery_codes WHERE user_id = $1",
+		userID,
+	)
+	return err
+}
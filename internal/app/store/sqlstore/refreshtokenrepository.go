@@ -0,0 +1,83 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+type RefreshTokenRepository struct {
+	store *Store
+}
+
+// Create adds a new refresh token into database.
+func (r *RefreshTokenRepository) Create(t *model.RefreshToken) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO refresh_tokens (user_id, family_id, token_hash, created_at, expires_at) VALUES($1, $2, $3, $4, $5) RETURNING id",
+		t.UserID,
+		t.FamilyID,
+		t.TokenHash,
+		t.CreatedAt,
+		t.ExpiresAt,
+	).Scan(&t.ID)
+}
+
+// FindByTokenHash finds the refresh token in database by using its hash.
+func (r *RefreshTokenRepository) FindByTokenHash(tokenHash string) (*model.RefreshToken, error) {
+	t := &model.RefreshToken{}
+	if err := r.store.db.QueryRow(
+		"SELECT id, user_id, family_id, token_hash, created_at, expires_at, rotated_at, revoked_at FROM refresh_tokens WHERE token_hash = $1",
+		tokenHash,
+	).Scan(
+		&t.ID,
+		&t.UserID,
+		&t.FamilyID,
+		&t.TokenHash,
+		&t.CreatedAt,
+		&t.ExpiresAt,
+		&t.RotatedAt,
+		&t.RevokedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Rotate marks the refresh token as exchanged.
+// It returns store.ErrRecordNotFound if the token was already rotated or revoked,
+// so only one of concurrent requests can rotate the same token.
+func (r *RefreshTokenRepository) Rotate(id int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE refresh_tokens SET rotated_at = now() WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// RevokeFamily revokes all refresh tokens that were rotated from the same login.
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	_, err := r.store.db.Exec(
+		"UPDATE refresh_tokens SET revoked_at = now() WHERE family_id = $1 AND revoked_at IS NULL",
+		familyID,
+	)
+	return err
+}
+
+// RevokeByUser revokes all refresh tokens of the user.
+func (r *RefreshTokenRepository) RevokeByUser(userID int) error {
+	_, err := r.store.db.Exec(
+		"UPDATE refresh_tokens SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL",
+		userID,
+	)
+	return err
+}
@@ -0,0 +1,112 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("refresh_tokens", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	rt := model.TestRefreshToken(t, u.ID)
+	assert.NoError(t, s.RefreshToken().Create(rt))
+	assert.NotZero(t, rt.ID)
+}
+
+func TestRefreshTokenRepository_FindByTokenHash(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("refresh_tokens", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	rt := model.TestRefreshToken(t, u.ID)
+	_, err := s.RefreshToken().FindByTokenHash(rt.TokenHash)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	s.RefreshToken().Create(rt)
+	rt2, err := s.RefreshToken().FindByTokenHash(rt.TokenHash)
+	assert.NoError(t, err)
+	assert.Equal(t, rt.ID, rt2.ID)
+}
+
+func TestRefreshTokenRepository_Rotate(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("refresh_tokens", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	rt := model.TestRefreshToken(t, u.ID)
+	s.RefreshToken().Create(rt)
+	assert.NoError(t, s.RefreshToken().Rotate(rt.ID))
+	assert.EqualError(t, s.RefreshToken().Rotate(rt.ID), store.ErrRecordNotFound.Error())
+}
+
+func TestRefreshTokenRepository_RevokeFamily(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("refresh_tokens", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	rt1 := model.TestRefreshToken(t, u.ID)
+	rt1.TokenHash = "tokenhash1"
+	s.RefreshToken().Create(rt1)
+	rt2 := model.TestRefreshToken(t, u.ID)
+	rt2.TokenHash = "tokenhash2"
+	s.RefreshToken().Create(rt2)
+
+	assert.NoError(t, s.RefreshToken().RevokeFamily(rt1.FamilyID))
+	for _, hash := range []string{"tokenhash1", "tokenhash2"} {
+		rt, err := s.RefreshToken().FindByTokenHash(hash)
+		assert.NoError(t, err)
+		assert.True(t, rt.IsUsed())
+	}
+}
+
+func TestRefreshTokenRepository_RevokeByUser(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("refresh_tokens", "users")
+
+	s := sqlstore.New(db)
+	u1 := model.TestUser(t)
+	s.User().Create(u1)
+	u2 := model.TestUser(t)
+	u2.Email.String = "other@example.org"
+	s.User().Create(u2)
+
+	rt1 := model.TestRefreshToken(t, u1.ID)
+	rt1.TokenHash = "tokenhash1"
+	s.RefreshToken().Create(rt1)
+	rt2 := model.TestRefreshToken(t, u1.ID)
+	rt2.TokenHash = "tokenhash2"
+	rt2.FamilyID = "other"
+	s.RefreshToken().Create(rt2)
+	rt3 := model.TestRefreshToken(t, u2.ID)
+	rt3.TokenHash = "tokenhash3"
+	s.RefreshToken().Create(rt3)
+
+	assert.NoError(t, s.RefreshToken().RevokeByUser(u1.ID))
+	for _, hash := range []string{"tokenhash1", "tokenhash2"} {
+		rt, err := s.RefreshToken().FindByTokenHash(hash)
+		assert.NoError(t, err)
+		assert.True(t, rt.IsUsed())
+	}
+
+	rt, err := s.RefreshToken().FindByTokenHash("tokenhash3")
+	assert.NoError(t, err)
+	assert.False(t, rt.IsUsed())
+}
@@ -0,0 +1,39 @@
+package sqlstore
+
+import (
+	"time"
+)
+
+type RevocationRepository struct {
+	store *Store
+}
+
+// Revoke records in database that the identifier is revoked until the given time and drops the records that are no longer needed.
+// It returns store.ErrRecordNotFound if the identifier was already revoked.
+func (r *RevocationRepository) Revoke(id string, until time.Time) error {
+	if _, err := r.store.db.Exec("DELETE FROM revocations WHERE expires_at < now()"); err != nil {
+		return err
+	}
+
+	res, err := r.store.db.Exec(
+		"INSERT INTO revocations (id, expires_at) VALUES($1, $2) ON CONFLICT (id) DO NOTHING",
+		id,
+		until,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// IsRevoked reports whether the identifier was revoked.
+func (r *RevocationRepository) IsRevoked(id string) (bool, error) {
+	var revoked bool
+	err := r.store.db.QueryRow(
+		"SELECT EXISTS(SELECT 1 FROM revocations WHERE id = $1)",
+		id,
+	).Scan(&revoked)
+
+	return revoked, err
+}
@@ -0,0 +1,40 @@
+package sqlstore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevocationRepository_Revoke(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("revocations")
+
+	s := sqlstore.New(db)
+	assert.NoError(t, s.Revocation().Revoke("id", time.Now().Add(time.Hour)))
+	assert.EqualError(t, s.Revocation().Revoke("id", time.Now().Add(time.Hour)), store.ErrRecordNotFound.Error())
+
+	assert.NoError(t, s.Revocation().Revoke("expired", time.Now().Add(-time.Hour)))
+	assert.NoError(t, s.Revocation().Revoke("other", time.Now().Add(time.Hour)))
+	revoked, err := s.Revocation().IsRevoked("expired")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestRevocationRepository_IsRevoked(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("revocations")
+
+	s := sqlstore.New(db)
+	revoked, err := s.Revocation().IsRevoked("id")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	s.Revocation().Revoke("id", time.Now().Add(time.Hour))
+	revoked, err = s.Revocation().IsRevoked("id")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+}
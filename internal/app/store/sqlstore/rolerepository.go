@@ -0,0 +1,86 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/lib/pq"
+)
+
+type RoleRepository struct {
+	store *Store
+}
+
+// FindByUser finds the roles of the user with their permissions, including model.RoleUser that every user has.
+func (r *RoleRepository) FindByUser(userID int) ([]*model.Role, error) {
+	rows, err := r.store.db.Query(
+		`SELECT r.id, r.name, COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		WHERE r.name = $2 OR r.id IN (SELECT role_id FROM user_roles WHERE user_id = $1)
+		GROUP BY r.id, r.name
+		ORDER BY r.id`,
+		userID,
+		model.RoleUser,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []*model.Role{}
+	for rows.Next() {
+		role := &model.Role{}
+		if err := rows.Scan(
+			&role.ID,
+			&role.Name,
+			pq.Array(&role.Permissions),
+		); err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+// Assign gives the role to the user, assigning a role the user already has is not an error.
+// It returns store.ErrRecordNotFound if there is no such role.
+func (r *RoleRepository) Assign(userID int, role string) error {
+	var roleID int
+	if err := r.store.db.QueryRow(
+		"SELECT id FROM roles WHERE name = $1",
+		role,
+	).Scan(&roleID); err != nil {
+		if err == sql.ErrNoRows {
+			return store.ErrRecordNotFound
+		}
+
+		return err
+	}
+
+	_, err := r.store.db.Exec(
+		"INSERT INTO user_roles (user_id, role_id) VALUES($1, $2) ON CONFLICT DO NOTHING",
+		userID,
+		roleID,
+	)
+	return err
+}
+
+// Unassign takes the role from the user.
+// It returns store.ErrRecordNotFound if the user does not have the assigned role.
+func (r *RoleRepository) Unassign(userID int, role string) error {
+	res, err := r.store.db.Exec(
+		"DELETE FROM user_roles WHERE user_id = $1 AND role_id = (SELECT id FROM roles WHERE name = $2)",
+		userID,
+		role,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
@@ -0,0 +1,63 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleRepository_FindByUser(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("user_roles", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	roles, err := s.Role().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{model.RoleUser}, model.RoleNames(roles))
+
+	s.Role().Assign(u.ID, model.RoleAdmin)
+	roles, err = s.Role().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{model.RoleUser, model.RoleAdmin}, model.RoleNames(roles))
+	assert.True(t, model.HasPermission(roles, model.PermissionUsersWrite))
+}
+
+func TestRoleRepository_Assign(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("user_roles", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	assert.NoError(t, s.Role().Assign(u.ID, model.RoleModerator))
+	assert.NoError(t, s.Role().Assign(u.ID, model.RoleModerator))
+	assert.EqualError(t, s.Role().Assign(u.ID, "unknown"), store.ErrRecordNotFound.Error())
+
+	roles, err := s.Role().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{model.RoleUser, model.RoleModerator}, model.RoleNames(roles))
+}
+
+func TestRoleRepository_Unassign(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("user_roles", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	assert.EqualError(t, s.Role().Unassign(u.ID, model.RoleAdmin), store.ErrRecordNotFound.Error())
+	s.Role().Assign(u.ID, model.RoleAdmin)
+	assert.NoError(t, s.Role().Unassign(u.ID, model.RoleAdmin))
+
+	roles, err := s.Role().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{model.RoleUser}, model.RoleNames(roles))
+}
@@ -0,0 +1,132 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+type SessionRepository struct {
+	store *Store
+}
+
+// Create adds a new session record into database.
+func (r *SessionRepository) Create(s *model.Session) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO sessions (user_id, token_hash, data, ip, user_agent, created_at, last_seen_at, expires_at) VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		s.UserID,
+		s.TokenHash,
+		s.Data,
+		s.IP,
+		s.UserAgent,
+		s.CreatedAt,
+		s.LastSeenAt,
+		s.ExpiresAt,
+	).Scan(&s.ID)
+}
+
+// Find finds the session record in database by using its id.
+func (r *SessionRepository) Find(id int) (*model.Session, error) {
+	return r.findBy("id", id)
+}
+
+// FindByTokenHash finds the session record in database by using the hash of its token.
+func (r *SessionRepository) FindByTokenHash(tokenHash string) (*model.Session, error) {
+	return r.findBy("token_hash", tokenHash)
+}
+
+// FindByUser finds all session records of the user, the most recently used first.
+func (r *SessionRepository) FindByUser(userID int) ([]*model.Session, error) {
+	rows, err := r.store.db.Query(
+		"SELECT id, user_id, token_hash, data, ip, user_agent, created_at, last_seen_at, expires_at FROM sessions WHERE user_id = $1 ORDER BY last_seen_at DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*model.Session{}
+	for rows.Next() {
+		s := &model.Session{}
+		if err := rows.Scan(
+			&s.ID,
+			&s.UserID,
+			&s.TokenHash,
+			&s.Data,
+			&s.IP,
+			&s.UserAgent,
+			&s.CreatedAt,
+			&s.LastSeenAt,
+			&s.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
+
+// Update saves the data, client information and times of the session record.
+func (r *SessionRepository) Update(s *model.Session) error {
+	res, err := r.store.db.Exec(
+		"UPDATE sessions SET data = $2, ip = $3, user_agent = $4, last_seen_at = $5, expires_at = $6 WHERE id = $1",
+		s.ID,
+		s.Data,
+		s.IP,
+		s.UserAgent,
+		s.LastSeenAt,
+		s.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// Delete removes the session record from database.
+func (r *SessionRepository) Delete(id int) error {
+	res, err := r.store.db.Exec("DELETE FROM sessions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// DeleteByUser removes all session records of the user except the one with exceptID.
+func (r *SessionRepository) DeleteByUser(userID int, exceptID int) error {
+	_, err := r.store.db.Exec("DELETE FROM sessions WHERE user_id = $1 AND id <> $2", userID, exceptID)
+	return err
+}
+
+// findBy finds the session record in database by the value of the given column.
+func (r *SessionRepository) findBy(column string, value interface{}) (*model.Session, error) {
+	s := &model.Session{}
+	if err := r.store.db.QueryRow(
+		"SELECT id, user_id, token_hash, data, ip, user_agent, created_at, last_seen_at, expires_at FROM sessions WHERE "+column+" = $1",
+		value,
+	).Scan(
+		&s.ID,
+		&s.UserID,
+		&s.TokenHash,
+		&s.Data,
+		&s.IP,
+		&s.UserAgent,
+		&s.CreatedAt,
+		&s.LastSeenAt,
+		&s.ExpiresAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,116 @@
+package sqlstore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/sqlstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionRepository_Create(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("sessions", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	ss := model.TestSession(t, u.ID)
+	assert.NoError(t, s.Session().Create(ss))
+	assert.NotZero(t, ss.ID)
+}
+
+func TestSessionRepository_FindByTokenHash(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("sessions", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	ss := model.TestSession(t, u.ID)
+	_, err := s.Session().FindByTokenHash(ss.TokenHash)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	s.Session().Create(ss)
+	ss2, err := s.Session().FindByTokenHash(ss.TokenHash)
+	assert.NoError(t, err)
+	assert.Equal(t, ss.ID, ss2.ID)
+}
+
+func TestSessionRepository_Update(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("sessions", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	ss := model.TestSession(t, u.ID)
+	s.Session().Create(ss)
+	ss.Data = "updated"
+	assert.NoError(t, s.Session().Update(ss))
+
+	ss2, err := s.Session().Find(ss.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "updated", ss2.Data)
+}
+
+func TestSessionRepository_Delete(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("sessions", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	ss := model.TestSession(t, u.ID)
+	s.Session().Create(ss)
+	assert.NoError(t, s.Session().Delete(ss.ID))
+
+	_, err := s.Session().Find(ss.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
+
+func TestSessionRepository_FindByUser(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("sessions", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	ss1 := model.TestSession(t, u.ID)
+	ss1.TokenHash = "tokenhash1"
+	s.Session().Create(ss1)
+	ss2 := model.TestSession(t, u.ID)
+	ss2.TokenHash = "tokenhash2"
+	s.Session().Create(ss2)
+
+	sessions, err := s.Session().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+}
+
+func TestSessionRepository_DeleteByUser(t *testing.T) {
+	db, teardown := sqlstore.TestDB(t, databaseURL)
+	defer teardown("sessions", "users")
+
+	s := sqlstore.New(db)
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	ss1 := model.TestSession(t, u.ID)
+	ss1.TokenHash = "tokenhash1"
+	s.Session().Create(ss1)
+	ss2 := model.TestSession(t, u.ID)
+	ss2.TokenHash = "tokenhash2"
+	s.Session().Create(ss2)
+
+	assert.NoError(t, s.Session().DeleteByUser(u.ID, ss1.ID))
+	sessions, err := s.Session().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+}
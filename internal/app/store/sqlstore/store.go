@@ -10,9 +10,35 @@ import (
 // Store is a storage that includes the following fields:
 // - db: the database that uses for storing information about users.
 // - userRepository: the interface for calling function.
+// - sessionRepository: the repository of session records.
+// - refreshTokenRepository: the repository of refresh tokens.
+// - apiKeyRepository: the repository of API keys.
+// - passwordResetRepository: the repository of password resets.
+// - recoveryCodeRepository: the repository of recovery codes.
+// - loginAttemptRepository: the repository of failed login attempts.
+// - roleRepository: the repository of roles and their assignments to users.
+// - oauthClientRepository: the repository of OAuth clients.
+// - oauthCodeRepository: the repository of authorization codes.
+// - oauthGrantRepository: the repository of the consents of users to OAuth clients.
+// - externalIdentityRepository: the repository of the accounts of users at external providers.
+// - auditEventRepository: the repository of audit events.
+// - revocationRepository: the repository of revoked identifiers.
 type Store struct {
-	db             *sql.DB
-	userRepository *UserRepository
+	db                         *sql.DB
+	userRepository             *UserRepository
+	sessionRepository          *SessionRepository
+	refreshTokenRepository     *RefreshTokenRepository
+	apiKeyRepository           *APIKeyRepository
+	passwordResetRepository    *PasswordResetRepository
+	recoveryCodeRepository     *RecoveryCodeRepository
+	loginAttemptRepository     *LoginAttemptRepository
+	roleRepository             *RoleRepository
+	oauthClientRepository      *OAuthClientRepository
+	oauthCodeRepository        *OAuthCodeRepository
+	oauthGrantRepository       *OAuthGrantRepository
+	externalIdentityRepository *ExternalIdentityRepository
+	auditEventRepository       *AuditEventRepository
+	revocationRepository       *RevocationRepository
 }
 
 // New returns new store with specified database.
@@ -34,3 +60,172 @@ func (s *Store) User() store.UserRepository {
 
 	return s.userRepository
 }
+
+// Session uses for calling SessionRepository.
+func (s *Store) Session() store.SessionRepository {
+	if s.sessionRepository != nil {
+		return s.sessionRepository
+	}
+
+	s.sessionRepository = &SessionRepository{
+		store: s,
+	}
+
+	return s.sessionRepository
+}
+
+// RefreshToken uses for calling RefreshTokenRepository.
+func (s *Store) RefreshToken() store.RefreshTokenRepository {
+	if s.refreshTokenRepository != nil {
+		return s.refreshTokenRepository
+	}
+
+	s.refreshTokenRepository = &RefreshTokenRepository{
+		store: s,
+	}
+
+	return s.refreshTokenRepository
+}
+
+// APIKey uses for calling APIKeyRepository.
+func (s *Store) APIKey() store.APIKeyRepository {
+	if s.apiKeyRepository != nil {
+		return s.apiKeyRepository
+	}
+
+	s.apiKeyRepository = &APIKeyRepository{
+		store: s,
+	}
+
+	return s.apiKeyRepository
+}
+
+// PasswordReset uses for calling PasswordResetRepository.
+func (s *Store) PasswordReset() store.PasswordResetRepository {
+	if s.passwordResetRepository != nil {
+		return s.passwordResetRepository
+	}
+
+	s.passwordResetRepository = &PasswordResetRepository{
+		store: s,
+	}
+
+	return s.passwordResetRepository
+}
+
+// RecoveryCode uses for calling RecoveryCodeRepository.
+func (s *Store) RecoveryCode() store.RecoveryCodeRepository {
+	if s.recoveryCodeRepository != nil {
+		return s.recoveryCodeRepository
+	}
+
+	s.recoveryCodeRepository = &RecoveryCodeRepository{
+		store: s,
+	}
+
+	return s.recoveryCodeRepository
+}
+
+// LoginAttempt uses for calling LoginAttemptRepository.
+func (s *Store) LoginAttempt() store.LoginAttemptRepository {
+	if s.loginAttemptRepository != nil {
+		return s.loginAttemptRepository
+	}
+
+	s.loginAttemptRepository = &LoginAttemptRepository{
+		store: s,
+	}
+
+	return s.loginAttemptRepository
+}
+
+// Role uses for calling RoleRepository.
+func (s *Store) Role() store.RoleRepository {
+	if s.roleRepository != nil {
+		return s.roleRepository
+	}
+
+	s.roleRepository = &RoleRepository{
+		store: s,
+	}
+
+	return s.roleRepository
+}
+
+// OAuthClient uses for calling OAuthClientRepository.
+func (s *Store) OAuthClient() store.OAuthClientRepository {
+	if s.oauthClientRepository != nil {
+		return s.oauthClientRepository
+	}
+
+	s.oauthClientRepository = &OAuthClientRepository{
+		store: s,
+	}
+
+	return s.oauthClientRepository
+}
+
+// OAuthCode uses for calling OAuthCodeRepository.
+func (s *Store) OAuthCode() store.OAuthCodeRepository {
+	if s.oauthCodeRepository != nil {
+		return s.oauthCodeRepository
+	}
+
+	s.oauthCodeRepository = &OAuthCodeRepository{
+		store: s,
+	}
+
+	return s.oauthCodeRepository
+}
+
+// OAuthGrant uses for calling OAuthGrantRepository.
+func (s *Store) OAuthGrant() store.OAuthGrantRepository {
+	if s.oauthGrantRepository != nil {
+		return s.oauthGrantRepository
+	}
+
+	s.oauthGrantRepository = &OAuthGrantRepository{
+		store: s,
+	}
+
+	return s.oauthGrantRepository
+}
+
+// ExternalIdentity uses for calling ExternalIdentityRepository.
+func (s *Store) ExternalIdentity() store.ExternalIdentityRepository {
+	if s.externalIdentityRepository != nil {
+		return s.externalIdentityRepository
+	}
+
+	s.externalIdentityRepository = &ExternalIdentityRepository{
+		store: s,
+	}
+
+	return s.externalIdentityRepository
+}
+
+// AuditEvent uses for calling AuditEventRepository.
+func (s *Store) AuditEvent() store.AuditEventRepository {
+	if s.auditEventRepository != nil {
+		return s.auditEventRepository
+	}
+
+	s.auditEventRepository = &AuditEventRepository{
+		store: s,
+	}
+
+	return s.auditEventRepository
+}
+
+// Revocation uses for calling RevocationRepository.
+func (s *Store) Revocation() store.RevocationRepository {
+	if s.revocationRepository != nil {
+		return s.revocationRepository
+	}
+
+	s.revocationRepository = &RevocationRepository{
+		store: s,
+	}
+
+	return s.revocationRepository
+}
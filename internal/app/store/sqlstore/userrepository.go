@@ -2,16 +2,30 @@ package sqlstore
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/http-rest-API/internal/app/model"
 	"github.com/http-rest-API/internal/app/store"
 )
 
+// userSortColumns are the columns of the users table by the fields of store.UserFilter.Sort.
+var userSortColumns = map[string]string{
+	store.UserSortID:        "id",
+	store.UserSortEmail:     "email",
+	store.UserSortCreatedAt: "created_at",
+}
+
+// userColumns are the columns of the users table in the order scanUser reads them.
+const userColumns = "id, id_telegram, email, encrypted_password, email_verified_at, pending_email, totp_secret, totp_enabled_at, totp_last_step, created_at, disabled_at, deletion_scheduled_at, sessions_valid_after, telegram_first_name, telegram_last_name, telegram_username, telegram_photo_url, telegram_language_code"
+
 type UserRepository struct {
 	store *Store
 }
 
 // Create adds a new user into database (it validates before adding).
+// It returns store.ErrEmailTaken or store.ErrTelegramTaken if the email or the Telegram account belongs to another user.
 func (r *UserRepository) Create(u *model.User) error {
 	if err := u.Validate(); err != nil {
 		return err
@@ -20,26 +34,51 @@ func (r *UserRepository) Create(u *model.User) error {
 	if err := u.BeforeCreate(); err != nil {
 		return err
 	}
-	return r.store.db.QueryRow(
-		"INSERT INTO users (id_telegram, email, encrypted_password) VALUES($1, $2, $3) RETURNING id",
+	err := r.store.db.QueryRow(
+		"INSERT INTO users (id_telegram, email, encrypted_password, telegram_first_name, telegram_last_name, telegram_username, telegram_photo_url, telegram_language_code) VALUES($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at",
 		u.IDTelegram,
 		u.Email,
 		u.EncryptedPassword,
-	).Scan(&u.ID)
+		u.TelegramFirstName,
+		u.TelegramLastName,
+		u.TelegramUsername,
+		u.TelegramPhotoURL,
+		u.TelegramLanguageCode,
+	).Scan(&u.ID, &u.CreatedAt)
+	if isUniqueViolation(err, "users_email_key") {
+		return store.ErrEmailTaken
+	}
+
+	if isUniqueViolation(err, "users_id_telegram_key") {
+		return store.ErrTelegramTaken
+	}
+
+	return err
 }
 
 // Find finds the user in database by using his id.
 func (r *UserRepository) Find(id int) (*model.User, error) {
-	u := &model.User{}
-	if err := r.store.db.QueryRow(
-		"SELECT id, id_telegram, email, encrypted_password FROM users WHERE id = $1",
-		id,
-	).Scan(
-		&u.ID,
-		&u.IDTelegram,
-		&u.Email,
-		&u.EncryptedPassword,
-	); err != nil {
+	return r.findBy("id", id)
+}
+
+// Find finds the user in database by using his email.
+func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
+	return r.findBy("email", email)
+}
+
+// Find finds the user in database by using his telegram id.
+func (r *UserRepository) FindByIDTelegram(idTelegram int) (*model.User, error) {
+	return r.findBy("id_telegram", idTelegram)
+}
+
+// FindByExternalIdentity finds the user in database by the subject of the linked account at the external provider.
+func (r *UserRepository) FindByExternalIdentity(provider string, subject string) (*model.User, error) {
+	u, err := scanUser(r.store.db.QueryRow(
+		"SELECT "+userColumns+" FROM users WHERE id = (SELECT user_id FROM external_identities WHERE provider = $1 AND subject = $2)",
+		provider,
+		subject,
+	))
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, store.ErrRecordNotFound
 		}
@@ -50,18 +89,569 @@ func (r *UserRepository) Find(id int) (*model.User, error) {
 	return u, nil
 }
 
-// Find finds the user in database by using his email.
-func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
-	u := &model.User{}
-	if err := r.store.db.QueryRow(
-		"SELECT id, id_telegram, email, encrypted_password FROM users WHERE email = $1",
+// CreateWithExternalIdentity adds a new user who registers with the account at the external provider into database,
+// the user gets no password and may get no email.
+// It returns store.ErrEmailTaken or store.ErrExternalTaken if the email or the account belongs to another user.
+func (r *UserRepository) CreateWithExternalIdentity(u *model.User, i *model.ExternalIdentity) error {
+	if err := u.ValidateExternal(); err != nil {
+		return err
+	}
+
+	if err := i.Validate(); err != nil {
+		return err
+	}
+
+	tx, err := r.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
+		"INSERT INTO users (email, email_verified_at) VALUES($1, $2) RETURNING id, created_at",
+		u.Email,
+		u.EmailVerifiedAt,
+	).Scan(&u.ID, &u.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err, "users_email_key") {
+			return store.ErrEmailTaken
+		}
+
+		return err
+	}
+
+	i.UserID = u.ID
+	err = tx.QueryRow(
+		"INSERT INTO external_identities (user_id, provider, subject, email, created_at) VALUES($1, $2, $3, $4, $5) RETURNING id",
+		i.UserID,
+		i.Provider,
+		i.Subject,
+		i.Email,
+		i.CreatedAt,
+	).Scan(&i.ID)
+	if err != nil {
+		if isUniqueViolation(err, "external_identities_provider_subject_key") {
+			return store.ErrExternalTaken
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateTelegramProfile saves the profile of the linked Telegram account.
+func (r *UserRepository) UpdateTelegramProfile(u *model.User) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET telegram_first_name = $2, telegram_last_name = $3, telegram_username = $4, telegram_photo_url = $5, telegram_language_code = $6 WHERE id = $1",
+		u.ID,
+		u.TelegramFirstName,
+		u.TelegramLastName,
+		u.TelegramUsername,
+		u.TelegramPhotoURL,
+		u.TelegramLanguageCode,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// LinkTelegram attaches the Telegram account with its profile to the user.
+// It returns store.ErrTelegramTaken if the Telegram account belongs to another user.
+func (r *UserRepository) LinkTelegram(u *model.User) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET id_telegram = $2, telegram_first_name = $3, telegram_last_name = $4, telegram_username = $5, telegram_photo_url = $6, telegram_language_code = $7 WHERE id = $1",
+		u.ID,
+		u.IDTelegram,
+		u.TelegramFirstName,
+		u.TelegramLastName,
+		u.TelegramUsername,
+		u.TelegramPhotoURL,
+		u.TelegramLanguageCode,
+	)
+	if err != nil {
+		if isUniqueViolation(err, "users_id_telegram_key") {
+			return store.ErrTelegramTaken
+		}
+
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// UnlinkTelegram detaches the Telegram account from the user.
+// It returns store.ErrLastLoginMethod if the user has no email or external account to log in with.
+func (r *UserRepository) UnlinkTelegram(id int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET id_telegram = NULL, telegram_first_name = NULL, telegram_last_name = NULL, telegram_username = NULL, telegram_photo_url = NULL, telegram_language_code = NULL WHERE id = $1 AND (email IS NOT NULL OR EXISTS (SELECT 1 FROM external_identities WHERE user_id = users.id))",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return r.checkUnlinked(res, id)
+}
+
+// LinkEmail adds the email and the password to the user (it validates before adding).
+// It returns store.ErrEmailTaken if the email belongs to another user.
+func (r *UserRepository) LinkEmail(u *model.User) error {
+	if err := u.ValidateEmailLogin(); err != nil {
+		return err
+	}
+
+	if err := u.BeforeCreate(); err != nil {
+		return err
+	}
+
+	res, err := r.store.db.Exec(
+		"UPDATE users SET email = $2, encrypted_password = $3, email_verified_at = NULL, pending_email = NULL WHERE id = $1",
+		u.ID,
+		u.Email,
+		u.EncryptedPassword,
+	)
+	if err != nil {
+		if isUniqueViolation(err, "users_email_key") {
+			return store.ErrEmailTaken
+		}
+
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// UnlinkEmail removes the email and the password from the user.
+// It returns store.ErrLastLoginMethod if the user has no Telegram or external account to log in with.
+func (r *UserRepository) UnlinkEmail(id int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET email = NULL, encrypted_password = NULL, email_verified_at = NULL, pending_email = NULL WHERE id = $1 AND (id_telegram IS NOT NULL OR EXISTS (SELECT 1 FROM external_identities WHERE user_id = users.id))",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return r.checkUnlinked(res, id)
+}
+
+// VerifyEmail marks the email of the user as confirmed.
+// It returns store.ErrRecordNotFound if the user no longer has this email.
+func (r *UserRepository) VerifyEmail(id int, email string) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET email_verified_at = now() WHERE id = $1 AND email = $2",
+		id,
 		email,
-	).Scan(
-		&u.ID,
-		&u.IDTelegram,
-		&u.Email,
-		&u.EncryptedPassword,
-	); err != nil {
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// SetPendingEmail stores the new email that replaces the current one once the user confirms it (it validates before saving).
+func (r *UserRepository) SetPendingEmail(u *model.User) error {
+	if err := u.ValidatePendingEmail(); err != nil {
+		return err
+	}
+
+	res, err := r.store.db.Exec(
+		"UPDATE users SET pending_email = $2 WHERE id = $1",
+		u.ID,
+		u.PendingEmail,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// ConfirmEmail replaces the email of the user with the pending one and marks it as verified.
+// It returns store.ErrRecordNotFound if the pending email of the user is no longer the given one
+// and store.ErrEmailTaken if the email was taken by another user in the meantime.
+func (r *UserRepository) ConfirmEmail(id int, email string) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET email = pending_email, pending_email = NULL, email_verified_at = now() WHERE id = $1 AND pending_email = $2",
+		id,
+		email,
+	)
+	if err != nil {
+		if isUniqueViolation(err, "users_email_key") {
+			return store.ErrEmailTaken
+		}
+
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// UpdatePassword sets the new password of the user (it validates before saving).
+func (r *UserRepository) UpdatePassword(u *model.User) error {
+	if err := u.ValidatePassword(); err != nil {
+		return err
+	}
+
+	if err := u.BeforeCreate(); err != nil {
+		return err
+	}
+
+	res, err := r.store.db.Exec(
+		"UPDATE users SET encrypted_password = $2 WHERE id = $1",
+		u.ID,
+		u.EncryptedPassword,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// ResetPassword sets the new password of the user and marks the password reset as used in one transaction
+// (it validates before saving).
+// It returns store.ErrRecordNotFound if the reset was already used, then the password is left as it is.
+func (r *UserRepository) ResetPassword(u *model.User, resetID int) error {
+	if err := u.ValidatePassword(); err != nil {
+		return err
+	}
+
+	if err := u.BeforeCreate(); err != nil {
+		return err
+	}
+
+	tx, err := r.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"UPDATE password_resets SET used_at = now() WHERE id = $1 AND user_id = $2 AND used_at IS NULL",
+		resetID,
+		u.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		return err
+	}
+
+	res, err = tx.Exec(
+		"UPDATE users SET encrypted_password = $2 WHERE id = $1",
+		u.ID,
+		u.EncryptedPassword,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReplaceEncryptedPassword sets the new hash of the password if the user still has the old one.
+// It returns store.ErrRecordNotFound if the password was changed in the meantime.
+func (r *UserRepository) ReplaceEncryptedPassword(id int, old string, new string) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET encrypted_password = $3 WHERE id = $1 AND encrypted_password = $2",
+		id,
+		old,
+		new,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// SetTOTPSecret stores the secret of the TOTP second factor that is not enabled until EnableTOTP.
+func (r *UserRepository) SetTOTPSecret(id int, secret string) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET totp_secret = $2, totp_enabled_at = NULL WHERE id = $1",
+		id,
+		secret,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// EnableTOTP enables the TOTP second factor with the stored secret.
+// It returns store.ErrRecordNotFound if the user has no TOTP secret.
+func (r *UserRepository) EnableTOTP(id int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET totp_enabled_at = now() WHERE id = $1 AND totp_secret IS NOT NULL",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// DisableTOTP disables the TOTP second factor and removes its secret.
+func (r *UserRepository) DisableTOTP(id int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL WHERE id = $1",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// UseTOTPStep records that a TOTP code of the time step was accepted for the user.
+// It returns store.ErrRecordNotFound if a code of this or a later step was accepted before.
+func (r *UserRepository) UseTOTPStep(id int, step int64) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET totp_last_step = $2 WHERE id = $1 AND (totp_last_step IS NULL OR totp_last_step < $2)",
+		id,
+		step,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// SetSessionsValidAfter makes the sessions of the user that started before the time invalid.
+func (r *UserRepository) SetSessionsValidAfter(id int, t time.Time) error {
+	res, err := r.store.db.Exec(
+		"UPDATE users SET sessions_valid_after = $2 WHERE id = $1",
+		id,
+		t,
+	)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// List finds the users selected by the filter in the order and the page it asks for.
+// It also returns the number of all selected users regardless of the page.
+func (r *UserRepository) List(f *store.UserFilter) ([]*model.User, int, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.Email != "" {
+		where("email ILIKE $%d", "%"+escapeLike(f.Email)+"%")
+	}
+
+	if f.Telegram != nil {
+		if *f.Telegram {
+			conditions = append(conditions, "id_telegram IS NOT NULL")
+		} else {
+			conditions = append(conditions, "id_telegram IS NULL")
+		}
+	}
+
+	if !f.CreatedAfter.IsZero() {
+		where("created_at >= $%d", f.CreatedAfter)
+	}
+
+	if !f.CreatedBefore.IsZero() {
+		where("created_at < $%d", f.CreatedBefore)
+	}
+
+	if !f.DeletionScheduledBefore.IsZero() {
+		where("deletion_scheduled_at < $%d", f.DeletionScheduledBefore)
+	}
+
+	switch f.Status {
+	case store.UserStatusActive:
+		conditions = append(conditions, "disabled_at IS NULL")
+	case store.UserStatusDisabled:
+		conditions = append(conditions, "disabled_at IS NOT NULL")
+	}
+
+	clause := ""
+	if len(conditions) > 0 {
+		clause = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.store.db.QueryRow("SELECT count(*) FROM users"+clause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := userSortColumns[f.Sort]
+	if !ok {
+		column = "id"
+	}
+
+	direction := "ASC"
+	if f.Desc {
+		direction = "DESC"
+	}
+
+	query := "SELECT " + userColumns + " FROM users" + clause + " ORDER BY " + column + " " + direction + " NULLS LAST, id " + direction
+	if f.Limit > 0 {
+		args = append(args, f.Limit, f.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := r.store.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []*model.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		users = append(users, u)
+	}
+
+	return users, total, rows.Err()
+}
+
+// Update saves the email, the Telegram ID, the email verification, the pending email, the disabled time
+// and the scheduled deletion of the user (it validates before saving).
+// It returns store.ErrEmailTaken or store.ErrTelegramTaken if the email or the Telegram account belongs to another user.
+func (r *UserRepository) Update(u *model.User) error {
+	if err := u.ValidateUpdate(); err != nil {
+		return err
+	}
+
+	res, err := r.store.db.Exec(
+		"UPDATE users SET id_telegram = $2, email = $3, email_verified_at = $4, pending_email = $5, disabled_at = $6, deletion_scheduled_at = $7 WHERE id = $1",
+		u.ID,
+		u.IDTelegram,
+		u.Email,
+		u.EmailVerifiedAt,
+		u.PendingEmail,
+		u.DisabledAt,
+		u.DeletionScheduledAt,
+	)
+	if err != nil {
+		if isUniqueViolation(err, "users_email_key") {
+			return store.ErrEmailTaken
+		}
+
+		if isUniqueViolation(err, "users_id_telegram_key") {
+			return store.ErrTelegramTaken
+		}
+
+		return err
+	}
+
+	return checkAffected(res)
+}
+
+// Delete removes the user from database together with all records that belong to the user
+// and the audit events the user made, the events of administrators about the user are kept.
+func (r *UserRepository) Delete(id int) error {
+	tx, err := r.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"DELETE FROM users WHERE id = $1",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM audit_events WHERE user_id = $1 AND actor_id IS NULL", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Anonymize removes everything that identifies the user, all credentials of the user
+// and the audit events the user made in one transaction, only the disabled record of the user is kept.
+func (r *UserRepository) Anonymize(id int) error {
+	tx, err := r.store.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		"UPDATE users SET id_telegram = NULL, email = NULL, encrypted_password = NULL, email_verified_at = NULL, pending_email = NULL, totp_secret = NULL, totp_enabled_at = NULL, telegram_first_name = NULL, telegram_last_name = NULL, telegram_username = NULL, telegram_photo_url = NULL, telegram_language_code = NULL, disabled_at = COALESCE(disabled_at, now()), deletion_scheduled_at = NULL WHERE id = $1",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := checkAffected(res); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"sessions", "refresh_tokens", "api_keys", "password_resets", "recovery_codes", "user_roles", "oauth_codes", "oauth_grants", "external_identities"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE user_id = $1", id); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM audit_events WHERE user_id = $1 AND actor_id IS NULL", id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern in the string.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// checkUnlinked tells a missing user from a user whose last login method was kept when nothing was unlinked.
+func (r *UserRepository) checkUnlinked(res sql.Result, id int) error {
+	if err := checkAffected(res); err != store.ErrRecordNotFound {
+		return err
+	}
+
+	if _, err := r.Find(id); err != nil {
+		return err
+	}
+
+	return store.ErrLastLoginMethod
+}
+
+// findBy finds the user in database by the value of the given column.
+func (r *UserRepository) findBy(column string, value interface{}) (*model.User, error) {
+	u, err := scanUser(r.store.db.QueryRow(
+		"SELECT "+userColumns+" FROM users WHERE "+column+" = $1",
+		value,
+	))
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, store.ErrRecordNotFound
 		}
@@ -72,22 +662,29 @@ func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
 	return u, nil
 }
 
-// Find finds the user in database by using his telegram id.
-func (r *UserRepository) FindByIDTelegram(idTelegram int) (*model.User, error) {
+// scanUser reads a user selected with userColumns.
+func scanUser(row interface{ Scan(...interface{}) error }) (*model.User, error) {
 	u := &model.User{}
-	if err := r.store.db.QueryRow(
-		"SELECT id, id_telegram, email, encrypted_password FROM users WHERE id_telegram = $1",
-		idTelegram,
-	).Scan(
+	if err := row.Scan(
 		&u.ID,
 		&u.IDTelegram,
 		&u.Email,
 		&u.EncryptedPassword,
+		&u.EmailVerifiedAt,
+		&u.PendingEmail,
+		&u.TOTPSecret,
+		&u.TOTPEnabledAt,
+		&u.TOTPLastStep,
+		&u.CreatedAt,
+		&u.DisabledAt,
+		&u.DeletionScheduledAt,
+		&u.SessionsValidAfter,
+		&u.TelegramFirstName,
+		&u.TelegramLastName,
+		&u.TelegramUsername,
+		&u.TelegramPhotoURL,
+		&u.TelegramLanguageCode,
 	); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, store.ErrRecordNotFound
-		}
-
 		return nil, err
 	}
 
@@ -2,4 +2,17 @@ package store
 
 type Store interface {
 	User() UserRepository
+	Session() SessionRepository
+	RefreshToken() RefreshTokenRepository
+	APIKey() APIKeyRepository
+	PasswordReset() PasswordResetRepository
+	RecoveryCode() RecoveryCodeRepository
+	LoginAttempt() LoginAttemptRepository
+	Role() RoleRepository
+	OAuthClient() OAuthClientRepository
+	OAuthCode() OAuthCodeRepository
+	OAuthGrant() OAuthGrantRepository
+	ExternalIdentity() ExternalIdentityRepository
+	AuditEvent() AuditEventRepository
+	Revocation() RevocationRepository
 }
@@ -0,0 +1,96 @@
+package teststore
+
+import (
+	"sort"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// APIKeyRepository uses for manipulating with API keys in test store.
+// It including:
+// - store: it is test store.
+// - keys: it is map that uses how database for testing.
+// - lastID: the id of the last created API key.
+type APIKeyRepository struct {
+	store  *Store
+	keys   map[int]*model.APIKey
+	lastID int
+}
+
+// Create adds a new API key into map (it validates before adding).
+func (r *APIKeyRepository) Create(k *model.APIKey) error {
+	if err := k.Validate(); err != nil {
+		return err
+	}
+
+	r.lastID++
+	k.ID = r.lastID
+	c := *k
+	r.keys[k.ID] = &c
+
+	return nil
+}
+
+// Find finds the API key in map by using its id.
+func (r *APIKeyRepository) Find(id int) (*model.APIKey, error) {
+	k, ok := r.keys[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	c := *k
+	return &c, nil
+}
+
+// FindByPrefix finds the API key in map by using its visible prefix.
+func (r *APIKeyRepository) FindByPrefix(prefix string) (*model.APIKey, error) {
+	for _, k := range r.keys {
+		if k.Prefix == prefix {
+			c := *k
+			return &c, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+// FindByUser finds all API keys of the user, the newest first.
+func (r *APIKeyRepository) FindByUser(userID int) ([]*model.APIKey, error) {
+	keys := []*model.APIKey{}
+	for _, k := range r.keys {
+		if k.UserID == userID {
+			c := *k
+			keys = append(keys, &c)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID > keys[j].ID
+	})
+
+	return keys, nil
+}
+
+// UpdateLastUsed saves the time and the address of the last use of the API key.
+func (r *APIKeyRepository) UpdateLastUsed(k *model.APIKey) error {
+	old, ok := r.keys[k.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	old.LastUsedAt = k.LastUsedAt
+	old.LastUsedIP = k.LastUsedIP
+
+	return nil
+}
+
+// Delete removes the API key from map.
+func (r *APIKeyRepository) Delete(id int) error {
+	if _, ok := r.keys[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.keys, id)
+	return nil
+}
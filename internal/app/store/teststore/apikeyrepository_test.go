@@ -0,0 +1,70 @@
+package teststore_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyRepository_Create(t *testing.T) {
+	s := teststore.New()
+	k := model.TestAPIKey(t, 1)
+	assert.NoError(t, s.APIKey().Create(k))
+	assert.NotZero(t, k.ID)
+
+	k = model.TestAPIKey(t, 1)
+	k.Name = ""
+	assert.Error(t, s.APIKey().Create(k))
+}
+
+func TestAPIKeyRepository_FindByPrefix(t *testing.T) {
+	s := teststore.New()
+	k := model.TestAPIKey(t, 1)
+	_, err := s.APIKey().FindByPrefix(k.Prefix)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	s.APIKey().Create(k)
+	k2, err := s.APIKey().FindByPrefix(k.Prefix)
+	assert.NoError(t, err)
+	assert.Equal(t, k.ID, k2.ID)
+}
+
+func TestAPIKeyRepository_FindByUser(t *testing.T) {
+	s := teststore.New()
+	s.APIKey().Create(model.TestAPIKey(t, 1))
+	s.APIKey().Create(model.TestAPIKey(t, 1))
+	s.APIKey().Create(model.TestAPIKey(t, 2))
+
+	keys, err := s.APIKey().FindByUser(1)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+}
+
+func TestAPIKeyRepository_UpdateLastUsed(t *testing.T) {
+	s := teststore.New()
+	k := model.TestAPIKey(t, 1)
+	s.APIKey().Create(k)
+
+	k.LastUsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	k.LastUsedIP = "127.0.0.1"
+	assert.NoError(t, s.APIKey().UpdateLastUsed(k))
+
+	k2, _ := s.APIKey().Find(k.ID)
+	assert.True(t, k2.LastUsedAt.Valid)
+	assert.Equal(t, "127.0.0.1", k2.LastUsedIP)
+}
+
+func TestAPIKeyRepository_Delete(t *testing.T) {
+	s := teststore.New()
+	k := model.TestAPIKey(t, 1)
+	s.APIKey().Create(k)
+
+	assert.NoError(t, s.APIKey().Delete(k.ID))
+	_, err := s.APIKey().Find(k.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
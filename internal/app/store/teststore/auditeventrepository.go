@@ -0,0 +1,92 @@
+package teststore
+
+import (
+	"sort"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// AuditEventRepository uses for manipulating with audit events in test store.
+// It including:
+// - store: it is test store.
+// - events: it is map that uses how database for testing.
+// - lastID: the id of the last recorded event.
+type AuditEventRepository struct {
+	store  *Store
+	events map[int]*model.AuditEvent
+	lastID int
+}
+
+// Create adds the event into map.
+func (r *AuditEventRepository) Create(e *model.AuditEvent) error {
+	r.lastID++
+	e.ID = r.lastID
+	cp := *e
+	r.events[e.ID] = &cp
+
+	return nil
+}
+
+// List finds the page of the events selected by the filter, the newest first, and the number of all selected events.
+func (r *AuditEventRepository) List(f *store.AuditEventFilter) ([]*model.AuditEvent, int, error) {
+	events := []*model.AuditEvent{}
+	for _, e := range r.events {
+		if f.UserID != 0 && e.UserID != f.UserID {
+			continue
+		}
+
+		if f.ActorID != 0 && e.ActorID != f.ActorID {
+			continue
+		}
+
+		if f.Type != "" && e.Type != f.Type {
+			continue
+		}
+
+		if f.IP != "" && e.IP != f.IP {
+			continue
+		}
+
+		if !f.After.IsZero() && e.CreatedAt.Before(f.After) {
+			continue
+		}
+
+		if !f.Before.IsZero() && !e.CreatedAt.Before(f.Before) {
+			continue
+		}
+
+		cp := *e
+		events = append(events, &cp)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+
+		return a.ID > b.ID
+	})
+
+	total := len(events)
+	if f.Offset >= total {
+		return []*model.AuditEvent{}, total, nil
+	}
+
+	events = events[f.Offset:]
+	if f.Limit > 0 && f.Limit < len(events) {
+		events = events[:f.Limit]
+	}
+
+	return events, total, nil
+}
+
+// deleteByUser removes the events the user made, the events of administrators about the user are kept.
+func (r *AuditEventRepository) deleteByUser(userID int) {
+	for id, e := range r.events {
+		if e.UserID == userID && e.ActorID == 0 {
+			delete(r.events, id)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package teststore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditEventRepository_Create(t *testing.T) {
+	s := teststore.New()
+	e := model.TestAuditEvent(t, 0)
+	e.Type = model.AuditLoginFailed
+	assert.NoError(t, s.AuditEvent().Create(e))
+	assert.NotZero(t, e.ID)
+
+	events, total, err := s.AuditEvent().List(&store.AuditEventFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, 0, events[0].UserID)
+	assert.Equal(t, 0, events[0].ActorID)
+	assert.Equal(t, e.RequestID, events[0].RequestID)
+}
+
+func TestAuditEventRepository_List(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	admin := model.TestUser(t)
+	admin.Email.String = "admin@example.org"
+	s.User().Create(admin)
+
+	now := time.Now()
+	e1 := model.TestAuditEvent(t, u.ID)
+	e1.CreatedAt = now.Add(-2 * time.Hour)
+	s.AuditEvent().Create(e1)
+	e2 := model.TestAuditEvent(t, u.ID)
+	e2.Type = model.AuditLogout
+	e2.IP = "192.0.2.2"
+	e2.CreatedAt = now.Add(-time.Hour)
+	s.AuditEvent().Create(e2)
+	e3 := model.TestAuditEvent(t, u.ID)
+	e3.Type = model.AuditAdminUserDisable
+	e3.ActorID = admin.ID
+	e3.CreatedAt = now
+	s.AuditEvent().Create(e3)
+	s.AuditEvent().Create(model.TestAuditEvent(t, admin.ID))
+
+	testCases := []struct {
+		name     string
+		filter   *store.AuditEventFilter
+		expected []int
+	}{
+		{
+			name:     "user",
+			filter:   &store.AuditEventFilter{UserID: u.ID},
+			expected: []int{e3.ID, e2.ID, e1.ID},
+		},
+		{
+			name:     "actor",
+			filter:   &store.AuditEventFilter{ActorID: admin.ID},
+			expected: []int{e3.ID},
+		},
+		{
+			name:     "type",
+			filter:   &store.AuditEventFilter{UserID: u.ID, Type: model.AuditLogout},
+			expected: []int{e2.ID},
+		},
+		{
+			name:     "ip",
+			filter:   &store.AuditEventFilter{IP: "192.0.2.2"},
+			expected: []int{e2.ID},
+		},
+		{
+			name:     "time range",
+			filter:   &store.AuditEventFilter{UserID: u.ID, After: now.Add(-90 * time.Minute), Before: now.Add(-time.Minute)},
+			expected: []int{e2.ID},
+		},
+		{
+			name:     "page",
+			filter:   &store.AuditEventFilter{UserID: u.ID, Limit: 1, Offset: 1},
+			expected: []int{e2.ID},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			events, _, err := s.AuditEvent().List(tc.filter)
+			assert.NoError(t, err)
+
+			ids := []int{}
+			for _, e := range events {
+				ids = append(ids, e.ID)
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+
+	_, total, err := s.AuditEvent().List(&store.AuditEventFilter{UserID: u.ID, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+
+	assert.NoError(t, s.User().Delete(u.ID))
+	events, _, err := s.AuditEvent().List(&store.AuditEventFilter{UserID: u.ID})
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, e3.ID, events[0].ID)
+}
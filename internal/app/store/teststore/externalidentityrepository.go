@@ -0,0 +1,77 @@
+package teststore
+
+import (
+	"sort"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// ExternalIdentityRepository uses for manipulating with the accounts of users at external providers in test store.
+// It including:
+// - store: it is test store.
+// - identities: it is map that uses how database for testing.
+// - lastID: the id of the last linked identity.
+type ExternalIdentityRepository struct {
+	store      *Store
+	identities map[int]*model.ExternalIdentity
+	lastID     int
+}
+
+// Create links the account at the external provider to the user in map (it validates before adding).
+// It returns store.ErrExternalTaken if the account is linked to a user already.
+func (r *ExternalIdentityRepository) Create(i *model.ExternalIdentity) error {
+	if err := i.Validate(); err != nil {
+		return err
+	}
+
+	if r.find(i.Provider, i.Subject) != nil {
+		return store.ErrExternalTaken
+	}
+
+	r.lastID++
+	i.ID = r.lastID
+	cp := *i
+	r.identities[i.ID] = &cp
+
+	return nil
+}
+
+// FindByUser finds all accounts at external providers linked to the user, the oldest first.
+func (r *ExternalIdentityRepository) FindByUser(userID int) ([]*model.ExternalIdentity, error) {
+	identities := []*model.ExternalIdentity{}
+	for _, i := range r.identities {
+		if i.UserID == userID {
+			cp := *i
+			identities = append(identities, &cp)
+		}
+	}
+
+	sort.Slice(identities, func(i, j int) bool {
+		return identities[i].ID < identities[j].ID
+	})
+
+	return identities, nil
+}
+
+// find returns the identity with the subject at the provider, or nil.
+func (r *ExternalIdentityRepository) find(provider string, subject string) *model.ExternalIdentity {
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			return i
+		}
+	}
+
+	return nil
+}
+
+// hasUser reports whether the user has an account at an external provider.
+func (r *ExternalIdentityRepository) hasUser(userID int) bool {
+	for _, i := range r.identities {
+		if i.UserID == userID {
+			return true
+		}
+	}
+
+	return false
+}
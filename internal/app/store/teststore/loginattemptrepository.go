@@ -0,0 +1,51 @@
+package teststore
+
+import (
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// LoginAttemptRepository uses for manipulating with failed login attempts in test store.
+// It including:
+// - store: it is test store.
+// - attempts: it is map that uses how database for testing.
+type LoginAttemptRepository struct {
+	store    *Store
+	attempts map[string]*model.LoginAttempt
+}
+
+// Find finds the failed login attempts in map by using their key.
+func (r *LoginAttemptRepository) Find(key string) (*model.LoginAttempt, error) {
+	a, ok := r.attempts[key]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	c := *a
+	return &c, nil
+}
+
+// Fail records a failed login attempt for the key and returns all failures in a row.
+// The count starts again if the previous failure is older than the window.
+func (r *LoginAttemptRepository) Fail(key string, now time.Time, window time.Duration) (*model.LoginAttempt, error) {
+	a, ok := r.attempts[key]
+	if !ok || a.LastFailedAt.Before(now.Add(-window)) {
+		a = &model.LoginAttempt{Key: key}
+		r.attempts[key] = a
+	}
+
+	a.Failures++
+	a.LastFailedAt = now
+
+	c := *a
+	return &c, nil
+}
+
+// Reset removes the failed login attempts of the key.
+func (r *LoginAttemptRepository) Reset(key string) error {
+	delete(r.attempts, key)
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package teststore_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAttemptRepository_Fail(t *testing.T) {
+	s := teststore.New()
+	now := time.Now().Truncate(time.Second)
+
+	a, err := s.LoginAttempt().Fail("user@example.org", now, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, a.Failures)
+
+	a, err = s.LoginAttempt().Fail("user@example.org", now.Add(time.Second), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, a.Failures)
+	assert.True(t, now.Add(time.Second).Equal(a.LastFailedAt))
+
+	a, err = s.LoginAttempt().Fail("user@example.org", now.Add(time.Hour), time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, a.Failures)
+}
+
+func TestLoginAttemptRepository_Find(t *testing.T) {
+	s := teststore.New()
+	_, err := s.LoginAttempt().Find("user@example.org")
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	s.LoginAttempt().Fail("user@example.org", time.Now(), time.Minute)
+	a, err := s.LoginAttempt().Find("user@example.org")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, a.Failures)
+}
+
+func TestLoginAttemptRepository_Reset(t *testing.T) {
+	s := teststore.New()
+	s.LoginAttempt().Fail("user@example.org", time.Now(), time.Minute)
+	assert.NoError(t, s.LoginAttempt().Reset("user@example.org"))
+
+	_, err := s.LoginAttempt().Find("user@example.org")
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
@@ -0,0 +1,87 @@
+package teststore
+
+import (
+	"sort"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// OAuthClientRepository uses for manipulating with OAuth clients in test store.
+// It including:
+// - store: it is test store.
+// - clients: it is map that uses how database for testing.
+// - lastID: the id of the last registered client.
+type OAuthClientRepository struct {
+	store   *Store
+	clients map[int]*model.OAuthClient
+	lastID  int
+}
+
+// Create adds a new OAuth client into map (it validates before adding).
+func (r *OAuthClientRepository) Create(c *model.OAuthClient) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	r.lastID++
+	c.ID = r.lastID
+	cp := *c
+	cp.RedirectURIs = append([]string{}, c.RedirectURIs...)
+	r.clients[c.ID] = &cp
+
+	return nil
+}
+
+// FindByClientID finds the OAuth client in map by using its public identifier.
+func (r *OAuthClientRepository) FindByClientID(clientID string) (*model.OAuthClient, error) {
+	for _, c := range r.clients {
+		if c.ClientID == clientID {
+			cp := *c
+			return &cp, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+// FindAll finds all OAuth clients in the order they were registered.
+func (r *OAuthClientRepository) FindAll() ([]*model.OAuthClient, error) {
+	clients := []*model.OAuthClient{}
+	for _, c := range r.clients {
+		cp := *c
+		clients = append(clients, &cp)
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ID < clients[j].ID
+	})
+
+	return clients, nil
+}
+
+// Delete removes the OAuth client from map with its codes and grants.
+func (r *OAuthClientRepository) Delete(id int) error {
+	c, ok := r.clients[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.clients, id)
+
+	r.store.OAuthCode()
+	for codeID, code := range r.store.oauthCodeRepository.codes {
+		if code.ClientID == c.ClientID {
+			delete(r.store.oauthCodeRepository.codes, codeID)
+		}
+	}
+
+	r.store.OAuthGrant()
+	for grantID, g := range r.store.oauthGrantRepository.grants {
+		if g.ClientID == c.ClientID {
+			delete(r.store.oauthGrantRepository.grants, grantID)
+		}
+	}
+
+	return nil
+}
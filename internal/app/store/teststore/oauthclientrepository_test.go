@@ -0,0 +1,58 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthClientRepository_Create(t *testing.T) {
+	s := teststore.New()
+	c := model.TestOAuthClient(t)
+	assert.NoError(t, s.OAuthClient().Create(c))
+	assert.NotZero(t, c.ID)
+
+	c = model.TestOAuthClient(t)
+	c.RedirectURIs = nil
+	assert.Error(t, s.OAuthClient().Create(c))
+}
+
+func TestOAuthClientRepository_FindByClientID(t *testing.T) {
+	s := teststore.New()
+	c := model.TestOAuthClient(t)
+	_, err := s.OAuthClient().FindByClientID(c.ClientID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	s.OAuthClient().Create(c)
+	c2, err := s.OAuthClient().FindByClientID(c.ClientID)
+	assert.NoError(t, err)
+	assert.Equal(t, c.ID, c2.ID)
+	assert.Equal(t, c.RedirectURIs, c2.RedirectURIs)
+}
+
+func TestOAuthClientRepository_Delete(t *testing.T) {
+	s := teststore.New()
+	c := model.TestOAuthClient(t)
+	s.OAuthClient().Create(c)
+	s.OAuthCode().Create(model.TestOAuthCode(t, c.ClientID, 1))
+	s.OAuthGrant().Save(model.TestOAuthGrant(t, c.ClientID, 1))
+	c2 := model.TestOAuthClient(t)
+	c2.ClientID = "other"
+	s.OAuthClient().Create(c2)
+
+	assert.NoError(t, s.OAuthClient().Delete(c.ID))
+	assert.EqualError(t, s.OAuthClient().Delete(c.ID), store.ErrRecordNotFound.Error())
+
+	clients, err := s.OAuthClient().FindAll()
+	assert.NoError(t, err)
+	assert.Len(t, clients, 1)
+	assert.Equal(t, c2.ID, clients[0].ID)
+
+	_, err = s.OAuthCode().Consume("codehash")
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	_, err = s.OAuthGrant().Find(1, c.ClientID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
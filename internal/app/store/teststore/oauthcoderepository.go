@@ -0,0 +1,40 @@
+package teststore
+
+import (
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// OAuthCodeRepository uses for manipulating with authorization codes in test store.
+// It including:
+// - store: it is test store.
+// - codes: it is map that uses how database for testing.
+// - lastID: the id of the last issued code.
+type OAuthCodeRepository struct {
+	store  *Store
+	codes  map[int]*model.OAuthCode
+	lastID int
+}
+
+// Create adds a new authorization code into map.
+func (r *OAuthCodeRepository) Create(c *model.OAuthCode) error {
+	r.lastID++
+	c.ID = r.lastID
+	cp := *c
+	r.codes[c.ID] = &cp
+
+	return nil
+}
+
+// Consume removes the authorization code from map by using the hash of the code and returns it.
+// It returns store.ErrRecordNotFound if there is no such code.
+func (r *OAuthCodeRepository) Consume(codeHash string) (*model.OAuthCode, error) {
+	for id, c := range r.codes {
+		if c.CodeHash == codeHash {
+			delete(r.codes, id)
+			return c, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
@@ -0,0 +1,25 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthCodeRepository_Consume(t *testing.T) {
+	s := teststore.New()
+	c := model.TestOAuthCode(t, "client", 1)
+	assert.NoError(t, s.OAuthCode().Create(c))
+	assert.NotZero(t, c.ID)
+
+	c2, err := s.OAuthCode().Consume(c.CodeHash)
+	assert.NoError(t, err)
+	assert.Equal(t, c.ID, c2.ID)
+	assert.Equal(t, c.CodeChallenge, c2.CodeChallenge)
+
+	_, err = s.OAuthCode().Consume(c.CodeHash)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
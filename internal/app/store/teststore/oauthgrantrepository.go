@@ -0,0 +1,82 @@
+package teststore
+
+import (
+	"sort"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// OAuthGrantRepository uses for manipulating with the consents of users to OAuth clients in test store.
+// It including:
+// - store: it is test store.
+// - grants: it is map that uses how database for testing.
+// - lastID: the id of the last saved grant.
+type OAuthGrantRepository struct {
+	store  *Store
+	grants map[int]*model.OAuthGrant
+	lastID int
+}
+
+// Find finds the consent of the user to the client in map.
+func (r *OAuthGrantRepository) Find(userID int, clientID string) (*model.OAuthGrant, error) {
+	for _, g := range r.grants {
+		if g.UserID == userID && g.ClientID == clientID {
+			cp := *g
+			cp.Scopes = append([]string{}, g.Scopes...)
+			return &cp, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+// FindByUser finds all consents of the user, the oldest first.
+func (r *OAuthGrantRepository) FindByUser(userID int) ([]*model.OAuthGrant, error) {
+	grants := []*model.OAuthGrant{}
+	for _, g := range r.grants {
+		if g.UserID == userID {
+			cp := *g
+			cp.Scopes = append([]string{}, g.Scopes...)
+			grants = append(grants, &cp)
+		}
+	}
+
+	sort.Slice(grants, func(i, j int) bool {
+		return grants[i].ID < grants[j].ID
+	})
+
+	return grants, nil
+}
+
+// Save adds the consent of the user to the client into map, or replaces the scopes of the existing one.
+func (r *OAuthGrantRepository) Save(g *model.OAuthGrant) error {
+	for _, old := range r.grants {
+		if old.UserID == g.UserID && old.ClientID == g.ClientID {
+			old.Scopes = append([]string{}, g.Scopes...)
+			g.ID = old.ID
+			g.CreatedAt = old.CreatedAt
+			return nil
+		}
+	}
+
+	r.lastID++
+	g.ID = r.lastID
+	cp := *g
+	cp.Scopes = append([]string{}, g.Scopes...)
+	r.grants[g.ID] = &cp
+
+	return nil
+}
+
+// Delete removes the consent of the user to the client from map.
+func (r *OAuthGrantRepository) Delete(userID int, clientID string) error {
+	for id, g := range r.grants {
+		if g.UserID == userID && g.ClientID == clientID {
+			delete(r.grants, id)
+			return nil
+		}
+	}
+
+	return store.ErrRecordNotFound
+}
@@ -0,0 +1,48 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOAuthGrantRepository_Save(t *testing.T) {
+	s := teststore.New()
+	g := model.TestOAuthGrant(t, "client", 1)
+	_, err := s.OAuthGrant().Find(1, "client")
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	assert.NoError(t, s.OAuthGrant().Save(g))
+	assert.NotZero(t, g.ID)
+
+	g2 := model.TestOAuthGrant(t, "client", 1)
+	g2.Scopes = []string{model.ScopeOpenID, model.ScopeProfile}
+	assert.NoError(t, s.OAuthGrant().Save(g2))
+	assert.Equal(t, g.ID, g2.ID)
+
+	found, err := s.OAuthGrant().Find(1, "client")
+	assert.NoError(t, err)
+	assert.Equal(t, g2.Scopes, found.Scopes)
+}
+
+func TestOAuthGrantRepository_FindByUser(t *testing.T) {
+	s := teststore.New()
+	s.OAuthGrant().Save(model.TestOAuthGrant(t, "client", 1))
+	s.OAuthGrant().Save(model.TestOAuthGrant(t, "other", 1))
+	s.OAuthGrant().Save(model.TestOAuthGrant(t, "client", 2))
+
+	grants, err := s.OAuthGrant().FindByUser(1)
+	assert.NoError(t, err)
+	assert.Len(t, grants, 2)
+}
+
+func TestOAuthGrantRepository_Delete(t *testing.T) {
+	s := teststore.New()
+	s.OAuthGrant().Save(model.TestOAuthGrant(t, "client", 1))
+
+	assert.NoError(t, s.OAuthGrant().Delete(1, "client"))
+	assert.EqualError(t, s.OAuthGrant().Delete(1, "client"), store.ErrRecordNotFound.Error())
+}
@@ -0,0 +1,55 @@
+package teststore
+
+import (
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// PasswordResetRepository uses for manipulating with password resets in test store.
+// It including:
+// - store: it is test store.
+// - resets: it is map that uses how database for testing.
+// - lastID: the id of the last created password reset.
+type PasswordResetRepository struct {
+	store  *Store
+	resets map[int]*model.PasswordReset
+	lastID int
+}
+
+// Create adds a new password reset into map.
+func (r *PasswordResetRepository) Create(p *model.PasswordReset) error {
+	r.lastID++
+	p.ID = r.lastID
+	c := *p
+	r.resets[p.ID] = &c
+
+	return nil
+}
+
+// FindByTokenHash finds the password reset in map by using the hash of its token.
+func (r *PasswordResetRepository) FindByTokenHash(tokenHash string) (*model.PasswordReset, error) {
+	for _, p := range r.resets {
+		if p.TokenHash == tokenHash {
+			c := *p
+			return &c, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+// Use marks the password reset as used.
+// It returns store.ErrRecordNotFound if the reset was already used.
+func (r *PasswordResetRepository) Use(id int) error {
+	p, ok := r.resets[id]
+	if !ok || p.IsUsed() {
+		return store.ErrRecordNotFound
+	}
+
+	p.UsedAt.Time = time.Now()
+	p.UsedAt.Valid = true
+
+	return nil
+}
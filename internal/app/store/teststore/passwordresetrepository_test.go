@@ -0,0 +1,41 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResetRepository_Create(t *testing.T) {
+	s := teststore.New()
+	p := model.TestPasswordReset(t, 1)
+	assert.NoError(t, s.PasswordReset().Create(p))
+	assert.NotZero(t, p.ID)
+}
+
+func TestPasswordResetRepository_FindByTokenHash(t *testing.T) {
+	s := teststore.New()
+	p := model.TestPasswordReset(t, 1)
+	_, err := s.PasswordReset().FindByTokenHash(p.TokenHash)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	s.PasswordReset().Create(p)
+	p2, err := s.PasswordReset().FindByTokenHash(p.TokenHash)
+	assert.NoError(t, err)
+	assert.Equal(t, p.ID, p2.ID)
+}
+
+func TestPasswordResetRepository_Use(t *testing.T) {
+	s := teststore.New()
+	p := model.TestPasswordReset(t, 1)
+	s.PasswordReset().Create(p)
+	assert.NoError(t, s.PasswordReset().Use(p.ID))
+	assert.EqualError(t, s.PasswordReset().Use(p.ID), store.ErrRecordNotFound.Error())
+
+	p, err := s.PasswordReset().FindByTokenHash(p.TokenHash)
+	assert.NoError(t, err)
+	assert.True(t, p.IsUsed())
+}
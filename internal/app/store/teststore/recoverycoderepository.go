@@ -0,0 +1,62 @@
+package teststore
+
+import (
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// RecoveryCodeRepository uses for manipulating with recovery codes in test store.
+// It including:
+// - store: it is test store.
+// - codes: it is map that uses how database for testing.
+// - lastID: the id of the last created recovery code.
+type RecoveryCodeRepository struct {
+	store  *Store
+	codes  map[int]*model.RecoveryCode
+	lastID int
+}
+
+// Replace removes all recovery codes of the user and adds the new ones.
+func (r *RecoveryCodeRepository) Replace(userID int, codes []*model.RecoveryCode) error {
+	if err := r.DeleteByUser(userID); err != nil {
+		return err
+	}
+
+	for _, c := range codes {
+		r.lastID++
+		c.ID = r.lastID
+		c.UserID = userID
+		cp := *c
+		r.codes[c.ID] = &cp
+	}
+
+	return nil
+}
+
+// Use marks the unused recovery code of the user as used.
+// It returns store.ErrRecordNotFound if the user has no such unused code.
+func (r *RecoveryCodeRepository) Use(userID int, codeHash string) error {
+	for _, c := range r.codes {
+		if c.UserID == userID && c.CodeHash == codeHash && !c.UsedAt.Valid {
+			c.UsedAt.Time = time.Now()
+			c.UsedAt.Valid = true
+
+			return nil
+		}
+	}
+
+	return store.ErrRecordNotFound
+}
+
+// DeleteByUser removes all recovery codes of the user.
+func (r *RecoveryCodeRepository) DeleteByUser(userID int) error {
+	for id, c := range r.codes {
+		if c.UserID == userID {
+			delete(r.codes, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoveryCodeRepository_Replace(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	old := model.TestRecoveryCode(t, u.ID)
+	old.CodeHash = "old"
+	assert.NoError(t, s.RecoveryCode().Replace(u.ID, []*model.RecoveryCode{old}))
+	assert.NotZero(t, old.ID)
+
+	c := model.TestRecoveryCode(t, u.ID)
+	assert.NoError(t, s.RecoveryCode().Replace(u.ID, []*model.RecoveryCode{c}))
+	assert.NotZero(t, c.ID)
+	assert.EqualError(t, s.RecoveryCode().Use(u.ID, "old"), store.ErrRecordNotFound.Error())
+	assert.NoError(t, s.RecoveryCode().Use(u.ID, c.CodeHash))
+}
+
+func TestRecoveryCodeRepository_Use(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	c := model.TestRecoveryCode(t, u.ID)
+	s.RecoveryCode().Replace(u.ID, []*model.RecoveryCode{c})
+	assert.EqualError(t, s.RecoveryCode().Use(u.ID+1, c.CodeHash), store.ErrRecordNotFound.Error())
+	assert.NoError(t, s.RecoveryCode().Use(u.ID, c.CodeHash))
+	assert.EqualError(t, s.RecoveryCode().Use(u.ID, c.CodeHash), store.ErrRecordNotFound.Error())
+}
+
+func TestRecoveryCodeRepository_DeleteByUser(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	c := model.TestRecoveryCode(t, u.ID)
+	s.RecoveryCode().Replace(u.ID, []*model.RecoveryCode{c})
+	assert.NoError(t, s.RecoveryCode().DeleteByUser(u.ID))
+	assert.EqualError(t, s.RecoveryCode().Use(u.ID, c.CodeHash), store.ErrRecordNotFound.Error())
+}
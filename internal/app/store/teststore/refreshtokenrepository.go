@@ -0,0 +1,79 @@
+package teststore
+
+import (
+	"time"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// RefreshTokenRepository uses for manipulating with refresh tokens in test store.
+// It including:
+// - store: it is test store.
+// - tokens: it is map that uses how database for testing.
+// - lastID: the id of the last created refresh token.
+type RefreshTokenRepository struct {
+	store  *Store
+	tokens map[int]*model.RefreshToken
+	lastID int
+}
+
+// Create adds a new refresh token into map.
+func (r *RefreshTokenRepository) Create(t *model.RefreshToken) error {
+	r.lastID++
+	t.ID = r.lastID
+	c := *t
+	r.tokens[t.ID] = &c
+
+	return nil
+}
+
+// FindByTokenHash finds the refresh token in map by using its hash.
+func (r *RefreshTokenRepository) FindByTokenHash(tokenHash string) (*model.RefreshToken, error) {
+	for _, t := range r.tokens {
+		if t.TokenHash == tokenHash {
+			c := *t
+			return &c, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+// Rotate marks the refresh token as exchanged.
+// It returns store.ErrRecordNotFound if the token was already rotated or revoked.
+func (r *RefreshTokenRepository) Rotate(id int) error {
+	t, ok := r.tokens[id]
+	if !ok || t.IsUsed() {
+		return store.ErrRecordNotFound
+	}
+
+	t.RotatedAt.Time = time.Now()
+	t.RotatedAt.Valid = true
+
+	return nil
+}
+
+// RevokeFamily revokes all refresh tokens that were rotated from the same login.
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	for _, t := range r.tokens {
+		if t.FamilyID == familyID && !t.RevokedAt.Valid {
+			t.RevokedAt.Time = time.Now()
+			t.RevokedAt.Valid = true
+		}
+	}
+
+	return nil
+}
+
+// RevokeByUser revokes all refresh tokens of the user.
+func (r *RefreshTokenRepository) RevokeByUser(userID int) error {
+	for _, t := range r.tokens {
+		if t.UserID == userID && !t.RevokedAt.Valid {
+			t.RevokedAt.Time = time.Now()
+			t.RevokedAt.Valid = true
+		}
+	}
+
+	return nil
+}
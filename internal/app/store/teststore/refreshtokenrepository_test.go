@@ -0,0 +1,87 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshTokenRepository_Create(t *testing.T) {
+	s := teststore.New()
+	rt := model.TestRefreshToken(t, 1)
+	assert.NoError(t, s.RefreshToken().Create(rt))
+	assert.NotZero(t, rt.ID)
+}
+
+func TestRefreshTokenRepository_FindByTokenHash(t *testing.T) {
+	s := teststore.New()
+	rt := model.TestRefreshToken(t, 1)
+	_, err := s.RefreshToken().FindByTokenHash(rt.TokenHash)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	s.RefreshToken().Create(rt)
+	rt2, err := s.RefreshToken().FindByTokenHash(rt.TokenHash)
+	assert.NoError(t, err)
+	assert.Equal(t, rt.ID, rt2.ID)
+}
+
+func TestRefreshTokenRepository_Rotate(t *testing.T) {
+	s := teststore.New()
+	rt := model.TestRefreshToken(t, 1)
+	s.RefreshToken().Create(rt)
+
+	assert.NoError(t, s.RefreshToken().Rotate(rt.ID))
+	assert.EqualError(t, s.RefreshToken().Rotate(rt.ID), store.ErrRecordNotFound.Error())
+
+	rt, _ = s.RefreshToken().FindByTokenHash(rt.TokenHash)
+	assert.True(t, rt.IsUsed())
+}
+
+func TestRefreshTokenRepository_RevokeFamily(t *testing.T) {
+	s := teststore.New()
+	rt1 := model.TestRefreshToken(t, 1)
+	rt1.TokenHash = "tokenhash1"
+	s.RefreshToken().Create(rt1)
+	rt2 := model.TestRefreshToken(t, 1)
+	rt2.TokenHash = "tokenhash2"
+	s.RefreshToken().Create(rt2)
+	rt3 := model.TestRefreshToken(t, 1)
+	rt3.TokenHash = "tokenhash3"
+	rt3.FamilyID = "other"
+	s.RefreshToken().Create(rt3)
+
+	assert.NoError(t, s.RefreshToken().RevokeFamily(rt1.FamilyID))
+	for _, hash := range []string{"tokenhash1", "tokenhash2"} {
+		rt, _ := s.RefreshToken().FindByTokenHash(hash)
+		assert.True(t, rt.IsUsed())
+	}
+
+	rt, _ := s.RefreshToken().FindByTokenHash("tokenhash3")
+	assert.False(t, rt.IsUsed())
+}
+
+func TestRefreshTokenRepository_RevokeByUser(t *testing.T) {
+	s := teststore.New()
+	rt1 := model.TestRefreshToken(t, 1)
+	rt1.TokenHash = "tokenhash1"
+	s.RefreshToken().Create(rt1)
+	rt2 := model.TestRefreshToken(t, 1)
+	rt2.TokenHash = "tokenhash2"
+	rt2.FamilyID = "other"
+	s.RefreshToken().Create(rt2)
+	rt3 := model.TestRefreshToken(t, 2)
+	rt3.TokenHash = "tokenhash3"
+	s.RefreshToken().Create(rt3)
+
+	assert.NoError(t, s.RefreshToken().RevokeByUser(1))
+	for _, hash := range []string{"tokenhash1", "tokenhash2"} {
+		rt, _ := s.RefreshToken().FindByTokenHash(hash)
+		assert.True(t, rt.IsUsed())
+	}
+
+	rt, _ := s.RefreshToken().FindByTokenHash("tokenhash3")
+	assert.False(t, rt.IsUsed())
+}
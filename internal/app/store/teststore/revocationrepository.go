@@ -0,0 +1,42 @@
+package teststore
+
+import (
+	"time"
+
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// RevocationRepository uses for manipulating with revoked identifiers in test store.
+// It including:
+// - store: it is test store.
+// - ids: it is map of the identifiers to the time they are revoked until, that uses how database for testing.
+type RevocationRepository struct {
+	store *Store
+	ids   map[string]time.Time
+}
+
+// Revoke records in map that the identifier is revoked until the given time and drops the records that are no longer needed.
+// It returns store.ErrRecordNotFound if the identifier was already revoked.
+func (r *RevocationRepository) Revoke(id string, until time.Time) error {
+	now := time.Now()
+	for k, exp := range r.ids {
+		if now.After(exp) {
+			delete(r.ids, k)
+		}
+	}
+
+	if _, ok := r.ids[id]; ok {
+		return store.ErrRecordNotFound
+	}
+
+	r.ids[id] = until
+
+	return nil
+}
+
+// IsRevoked reports whether the identifier was revoked.
+func (r *RevocationRepository) IsRevoked(id string) (bool, error) {
+	_, ok := r.ids[id]
+
+	return ok, nil
+}
@@ -0,0 +1,85 @@
+package teststore
+
+import (
+	"sort"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// RoleRepository uses for manipulating with roles in test store.
+// It including:
+// - store: it is test store.
+// - roles: it is map of the roles that the migrations create, by their names.
+// - userRoles: it is map of the names of the roles assigned to users, by the user ids.
+type RoleRepository struct {
+	store     *Store
+	roles     map[string]*model.Role
+	userRoles map[int]map[string]bool
+}
+
+// newRoles returns the roles with the permissions that the migrations create.
+func newRoles() map[string]*model.Role {
+	return map[string]*model.Role{
+		model.RoleUser: {
+			ID:   1,
+			Name: model.RoleUser,
+		},
+		model.RoleModerator: {
+			ID:          2,
+			Name:        model.RoleModerator,
+			Permissions: []string{model.PermissionUsersRead},
+		},
+		model.RoleAdmin: {
+			ID:          3,
+			Name:        model.RoleAdmin,
+			Permissions: []string{model.PermissionAuditRead, model.PermissionClientsWrite, model.PermissionRolesWrite, model.PermissionUsersRead, model.PermissionUsersWrite},
+		},
+	}
+}
+
+// FindByUser finds the roles of the user with their permissions, including model.RoleUser that every user has.
+func (r *RoleRepository) FindByUser(userID int) ([]*model.Role, error) {
+	roles := []*model.Role{}
+	for name, role := range r.roles {
+		if name == model.RoleUser || r.userRoles[userID][name] {
+			cp := *role
+			cp.Permissions = append([]string{}, role.Permissions...)
+			roles = append(roles, &cp)
+		}
+	}
+
+	sort.Slice(roles, func(i, j int) bool {
+		return roles[i].ID < roles[j].ID
+	})
+
+	return roles, nil
+}
+
+// Assign gives the role to the user, assigning a role the user already has is not an error.
+// It returns store.ErrRecordNotFound if there is no such role.
+func (r *RoleRepository) Assign(userID int, role string) error {
+	if _, ok := r.roles[role]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if r.userRoles[userID] == nil {
+		r.userRoles[userID] = make(map[string]bool)
+	}
+
+	r.userRoles[userID][role] = true
+
+	return nil
+}
+
+// Unassign takes the role from the user.
+// It returns store.ErrRecordNotFound if the user does not have the assigned role.
+func (r *RoleRepository) Unassign(userID int, role string) error {
+	if !r.userRoles[userID][role] {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.userRoles[userID], role)
+
+	return nil
+}
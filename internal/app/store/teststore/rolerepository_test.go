@@ -0,0 +1,54 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoleRepository_FindByUser(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	roles, err := s.Role().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{model.RoleUser}, model.RoleNames(roles))
+
+	s.Role().Assign(u.ID, model.RoleAdmin)
+	roles, err = s.Role().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{model.RoleUser, model.RoleAdmin}, model.RoleNames(roles))
+	assert.True(t, model.HasPermission(roles, model.PermissionUsersWrite))
+}
+
+func TestRoleRepository_Assign(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	assert.NoError(t, s.Role().Assign(u.ID, model.RoleModerator))
+	assert.NoError(t, s.Role().Assign(u.ID, model.RoleModerator))
+	assert.EqualError(t, s.Role().Assign(u.ID, "unknown"), store.ErrRecordNotFound.Error())
+
+	roles, err := s.Role().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{model.RoleUser, model.RoleModerator}, model.RoleNames(roles))
+}
+
+func TestRoleRepository_Unassign(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	assert.EqualError(t, s.Role().Unassign(u.ID, model.RoleAdmin), store.ErrRecordNotFound.Error())
+	s.Role().Assign(u.ID, model.RoleAdmin)
+	assert.NoError(t, s.Role().Unassign(u.ID, model.RoleAdmin))
+
+	roles, err := s.Role().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{model.RoleUser}, model.RoleNames(roles))
+}
@@ -0,0 +1,106 @@
+package teststore
+
+import (
+	"sort"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+)
+
+// SessionRepository uses for manipulating with session records in test store.
+// It including:
+// - store: it is test store.
+// - sessions: it is map that uses how database for testing.
+// - lastID: the id of the last created session record.
+type SessionRepository struct {
+	store    *Store
+	sessions map[int]*model.Session
+	lastID   int
+}
+
+// Create adds a new session record into map.
+func (r *SessionRepository) Create(s *model.Session) error {
+	r.lastID++
+	s.ID = r.lastID
+	c := *s
+	r.sessions[s.ID] = &c
+
+	return nil
+}
+
+// Find finds the session record in map by using its id.
+func (r *SessionRepository) Find(id int) (*model.Session, error) {
+	s, ok := r.sessions[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	c := *s
+	return &c, nil
+}
+
+// FindByTokenHash finds the session record in map by using the hash of its token.
+func (r *SessionRepository) FindByTokenHash(tokenHash string) (*model.Session, error) {
+	for _, s := range r.sessions {
+		if s.TokenHash == tokenHash {
+			c := *s
+			return &c, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+// FindByUser finds all session records of the user, the most recently used first.
+func (r *SessionRepository) FindByUser(userID int) ([]*model.Session, error) {
+	sessions := []*model.Session{}
+	for _, s := range r.sessions {
+		if s.UserID == userID {
+			c := *s
+			sessions = append(sessions, &c)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+// Update saves the data, client information and times of the session record.
+func (r *SessionRepository) Update(s *model.Session) error {
+	old, ok := r.sessions[s.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	old.Data = s.Data
+	old.IP = s.IP
+	old.UserAgent = s.UserAgent
+	old.LastSeenAt = s.LastSeenAt
+	old.ExpiresAt = s.ExpiresAt
+
+	return nil
+}
+
+// Delete removes the session record from map.
+func (r *SessionRepository) Delete(id int) error {
+	if _, ok := r.sessions[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.sessions, id)
+	return nil
+}
+
+// DeleteByUser removes all session records of the user except the one with exceptID.
+func (r *SessionRepository) DeleteByUser(userID int, exceptID int) error {
+	for id, s := range r.sessions {
+		if s.UserID == userID && id != exceptID {
+			delete(r.sessions, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package teststore_test
+
+import (
+	"testing"
+
+	"github.com/http-rest-API/internal/app/model"
+	"github.com/http-rest-API/internal/app/store"
+	"github.com/http-rest-API/internal/app/store/teststore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionRepository_Create(t *testing.T) {
+	s := teststore.New()
+	ss := model.TestSession(t, 1)
+	assert.NoError(t, s.Session().Create(ss))
+	assert.NotZero(t, ss.ID)
+}
+
+func TestSessionRepository_FindByTokenHash(t *testing.T) {
+	s := teststore.New()
+	ss := model.TestSession(t, 1)
+	_, err := s.Session().FindByTokenHash(ss.TokenHash)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	s.Session().Create(ss)
+	ss2, err := s.Session().FindByTokenHash(ss.TokenHash)
+	assert.NoError(t, err)
+	assert.Equal(t, ss.ID, ss2.ID)
+}
+
+func TestSessionRepository_Update(t *testing.T) {
+	s := teststore.New()
+	ss := model.TestSession(t, 1)
+	s.Session().Create(ss)
+
+	ss.Data = "updated"
+	assert.NoError(t, s.Session().Update(ss))
+	ss2, err := s.Session().Find(ss.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "updated", ss2.Data)
+}
+
+func TestSessionRepository_Delete(t *testing.T) {
+	s := teststore.New()
+	ss := model.TestSession(t, 1)
+	s.Session().Create(ss)
+
+	assert.NoError(t, s.Session().Delete(ss.ID))
+	_, err := s.Session().Find(ss.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+	assert.EqualError(t, s.Session().Delete(ss.ID), store.ErrRecordNotFound.Error())
+}
+
+func TestSessionRepository_FindByUser(t *testing.T) {
+	s := teststore.New()
+	s.Session().Create(model.TestSession(t, 1))
+	s.Session().Create(model.TestSession(t, 1))
+	s.Session().Create(model.TestSession(t, 2))
+
+	sessions, err := s.Session().FindByUser(1)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+}
+
+func TestSessionRepository_DeleteByUser(t *testing.T) {
+	s := teststore.New()
+	ss := model.TestSession(t, 1)
+	s.Session().Create(ss)
+	s.Session().Create(model.TestSession(t, 1))
+	s.Session().Create(model.TestSession(t, 2))
+
+	assert.NoError(t, s.Session().DeleteByUser(1, ss.ID))
+	sessions, _ := s.Session().FindByUser(1)
+	assert.Len(t, sessions, 1)
+	sessions, _ = s.Session().FindByUser(2)
+	assert.Len(t, sessions, 1)
+}
@@ -1,14 +1,42 @@
 package teststore
 
 import (
+	"time"
+
 	"github.com/http-rest-API/internal/app/model"
 	"github.com/http-rest-API/internal/app/store"
 )
 
 // Store is a test storage that includes the following fields:
 // - userRepository: the interface for calling function.
+// - sessionRepository: the repository of session records.
+// - refreshTokenRepository: the repository of refresh tokens.
+// - apiKeyRepository: the repository of API keys.
+// - passwordResetRepository: the repository of password resets.
+// - recoveryCodeRepository: the repository of recovery codes.
+// - loginAttemptRepository: the repository of failed login attempts.
+// - roleRepository: the repository of roles and their assignments to users.
+// - oauthClientRepository: the repository of OAuth clients.
+// - oauthCodeRepository: the repository of authorization codes.
+// - oauthGrantRepository: the repository of the consents of users to OAuth clients.
+// - externalIdentityRepository: the repository of the accounts of users at external providers.
+// - auditEventRepository: the repository of audit events.
+// - revocationRepository: the repository of revoked identifiers.
 type Store struct {
-	userRepository *UserRepository
+	userRepository             *UserRepository
+	sessionRepository          *SessionRepository
+	refreshTokenRepository     *RefreshTokenRepository
+	apiKeyRepository           *APIKeyRepository
+	passwordResetRepository    *PasswordResetRepository
+	recoveryCodeRepository     *RecoveryCodeRepository
+	loginAttemptRepository     *LoginAttemptRepository
+	roleRepository             *RoleRepository
+	oauthClientRepository      *OAuthClientRepository
+	oauthCodeRepository        *OAuthCodeRepository
+	oauthGrantRepository       *OAuthGrantRepository
+	externalIdentityRepository *ExternalIdentityRepository
+	auditEventRepository       *AuditEventRepository
+	revocationRepository       *RevocationRepository
 }
 
 // New returns a new Store.
@@ -29,3 +57,186 @@ func (s *Store) User() store.UserRepository {
 
 	return s.userRepository
 }
+
+// Session uses for calling SessionRepository.
+func (s *Store) Session() store.SessionRepository {
+	if s.sessionRepository != nil {
+		return s.sessionRepository
+	}
+
+	s.sessionRepository = &SessionRepository{
+		store:    s,
+		sessions: make(map[int]*model.Session),
+	}
+
+	return s.sessionRepository
+}
+
+// RefreshToken uses for calling RefreshTokenRepository.
+func (s *Store) RefreshToken() store.RefreshTokenRepository {
+	if s.refreshTokenRepository != nil {
+		return s.refreshTokenRepository
+	}
+
+	s.refreshTokenRepository = &RefreshTokenRepository{
+		store:  s,
+		tokens: make(map[int]*model.RefreshToken),
+	}
+
+	return s.refreshTokenRepository
+}
+
+// APIKey uses for calling APIKeyRepository.
+func (s *Store) APIKey() store.APIKeyRepository {
+	if s.apiKeyRepository != nil {
+		return s.apiKeyRepository
+	}
+
+	s.apiKeyRepository = &APIKeyRepository{
+		store: s,
+		keys:  make(map[int]*model.APIKey),
+	}
+
+	return s.apiKeyRepository
+}
+
+// PasswordReset uses for calling PasswordResetRepository.
+func (s *Store) PasswordReset() store.PasswordResetRepository {
+	if s.passwordResetRepository != nil {
+		return s.passwordResetRepository
+	}
+
+	s.passwordResetRepository = &PasswordResetRepository{
+		store:  s,
+		resets: make(map[int]*model.PasswordReset),
+	}
+
+	return s.passwordResetRepository
+}
+
+// RecoveryCode uses for calling RecoveryCodeRepository.
+func (s *Store) RecoveryCode() store.RecoveryCodeRepository {
+	if s.recoveryCodeRepository != nil {
+		return s.recoveryCodeRepository
+	}
+
+	s.recoveryCodeRepository = &RecoveryCodeRepository{
+		store: s,
+		codes: make(map[int]*model.RecoveryCode),
+	}
+
+	return s.recoveryCodeRepository
+}
+
+// LoginAttempt uses for calling LoginAttemptRepository.
+func (s *Store) LoginAttempt() store.LoginAttemptRepository {
+	if s.loginAttemptRepository != nil {
+		return s.loginAttemptRepository
+	}
+
+	s.loginAttemptRepository = &LoginAttemptRepository{
+		store:    s,
+		attempts: make(map[string]*model.LoginAttempt),
+	}
+
+	return s.loginAttemptRepository
+}
+
+// Role uses for calling RoleRepository.
+func (s *Store) Role() store.RoleRepository {
+	if s.roleRepository != nil {
+		return s.roleRepository
+	}
+
+	s.roleRepository = &RoleRepository{
+		store:     s,
+		roles:     newRoles(),
+		userRoles: make(map[int]map[string]bool),
+	}
+
+	return s.roleRepository
+}
+
+// OAuthClient uses for calling OAuthClientRepository.
+func (s *Store) OAuthClient() store.OAuthClientRepository {
+	if s.oauthClientRepository != nil {
+		return s.oauthClientRepository
+	}
+
+	s.oauthClientRepository = &OAuthClientRepository{
+		store:   s,
+		clients: make(map[int]*model.OAuthClient),
+	}
+
+	return s.oauthClientRepository
+}
+
+// OAuthCode uses for calling OAuthCodeRepository.
+func (s *Store) OAuthCode() store.OAuthCodeRepository {
+	if s.oauthCodeRepository != nil {
+		return s.oauthCodeRepository
+	}
+
+	s.oauthCodeRepository = &OAuthCodeRepository{
+		store: s,
+		codes: make(map[int]*model.OAuthCode),
+	}
+
+	return s.oauthCodeRepository
+}
+
+// OAuthGrant uses for calling OAuthGrantRepository.
+func (s *Store) OAuthGrant() store.OAuthGrantRepository {
+	if s.oauthGrantRepository != nil {
+		return s.oauthGrantRepository
+	}
+
+	s.oauthGrantRepository = &OAuthGrantRepository{
+		store:  s,
+		grants: make(map[int]*model.OAuthGrant),
+	}
+
+	return s.oauthGrantRepository
+}
+
+// ExternalIdentity uses for calling ExternalIdentityRepository.
+func (s *Store) ExternalIdentity() store.ExternalIdentityRepository {
+	if s.externalIdentityRepository != nil {
+		return s.externalIdentityRepository
+	}
+
+	s.externalIdentityRepository = &ExternalIdentityRepository{
+		store:      s,
+		identities: make(map[int]*model.ExternalIdentity),
+	}
+
+	return s.externalIdentityRepository
+}
+
+// AuditEvent uses for calling AuditEventRepository.
+func (s *Store) AuditEvent() store.AuditEventRepository {
+	if s.auditEventRepository != nil {
+		return s.auditEventRepository
+	}
+
+	s.auditEventRepository = &AuditEventRepository{
+		store:  s,
+		events: make(map[int]*model.AuditEvent),
+	}
+
+	return s.auditEventRepository
+}
+
+// Revocation uses for calling RevocationRepository.
+func (s *Store) Revocation() store.RevocationRepository {
+	if s.revocationRepository != nil {
+		return s.revocationRepository
+	}
+
+	s.revocationRepository = &RevocationRepository{
+		store: s,
+		ids:   make(map[string]time.Time),
+	}
+
+	return s.revocationRepository
+}
@@ -1,6 +1,11 @@
 package teststore
 
 import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/http-rest-API/internal/app/model"
 	"github.com/http-rest-API/internal/app/store"
 )
@@ -9,24 +14,38 @@ import (
 // It including:
 // - store: it is test store.
 // - users: it is map that uses how database for testing.
+// - lastID: the id of the last created user.
 type UserRepository struct {
-	store *Store
-	users map[int]*model.User
+	store  *Store
+	users  map[int]*model.User
+	lastID int
 }
 
 // Create adds a new user into map (it validates before adding).
+// It returns store.ErrEmailTaken or store.ErrTelegramTaken if the email or the Telegram account belongs to another user.
 func (r *UserRepository) Create(u *model.User) error {
 	if err := u.Validate(); err != nil {
 		return err
 	}
 
+	for _, other := range r.users {
+		if u.Email.Valid && other.Email.Valid && other.Email.String == u.Email.String {
+			return store.ErrEmailTaken
+		}
+
+		if u.IDTelegram.Valid && other.IDTelegram.Valid && other.IDTelegram.Int64 == u.IDTelegram.Int64 {
+			return store.ErrTelegramTaken
+		}
+	}
+
 	if err := u.BeforeCreate(); err != nil {
 		return err
 	}
 
-	u.ID = len(r.users) + 1
+	r.lastID++
+	u.ID = r.lastID
+	u.CreatedAt = time.Now()
 	r.users[u.ID] = u
-	u.ID = len(r.users)
 
 	return nil
 }
@@ -62,3 +81,516 @@ func (r *UserRepository) FindByIDTelegram(idTelegram int) (*model.User, error) {
 
 	return nil, store.ErrRecordNotFound
 }
+
+// FindByExternalIdentity finds the user in map by the subject of the linked account at the external provider.
+func (r *UserRepository) FindByExternalIdentity(provider string, subject string) (*model.User, error) {
+	r.store.ExternalIdentity()
+	i := r.store.externalIdentityRepository.find(provider, subject)
+	if i == nil {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return r.Find(i.UserID)
+}
+
+// CreateWithExternalIdentity adds a new user who registers with the account at the external provider into map,
+// the user gets no password and may get no email.
+// It returns store.ErrEmailTaken or store.ErrExternalTaken if the email or the account belongs to another user.
+func (r *UserRepository) CreateWithExternalIdentity(u *model.User, i *model.ExternalIdentity) error {
+	if err := u.ValidateExternal(); err != nil {
+		return err
+	}
+
+	if err := i.Validate(); err != nil {
+		return err
+	}
+
+	if u.Email.Valid {
+		for _, other := range r.users {
+			if other.Email.Valid && other.Email.String == u.Email.String {
+				return store.ErrEmailTaken
+			}
+		}
+	}
+
+	r.store.ExternalIdentity()
+	if r.store.externalIdentityRepository.find(i.Provider, i.Subject) != nil {
+		return store.ErrExternalTaken
+	}
+
+	r.lastID++
+	u.ID = r.lastID
+	u.CreatedAt = time.Now()
+	r.users[u.ID] = u
+
+	i.UserID = u.ID
+
+	return r.store.ExternalIdentity().Create(i)
+}
+
+// UpdateTelegramProfile saves the profile of the linked Telegram account.
+func (r *UserRepository) UpdateTelegramProfile(u *model.User) error {
+	stored, ok := r.users[u.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	stored.TelegramFirstName = u.TelegramFirstName
+	stored.TelegramLastName = u.TelegramLastName
+	stored.TelegramUsername = u.TelegramUsername
+	stored.TelegramPhotoURL = u.TelegramPhotoURL
+	stored.TelegramLanguageCode = u.TelegramLanguageCode
+
+	return nil
+}
+
+// LinkTelegram attaches the Telegram account with its profile to the user.
+// It returns store.ErrTelegramTaken if the Telegram account belongs to another user.
+func (r *UserRepository) LinkTelegram(u *model.User) error {
+	stored, ok := r.users[u.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	for _, other := range r.users {
+		if other.ID != u.ID && other.IDTelegram.Valid && other.IDTelegram.Int64 == u.IDTelegram.Int64 {
+			return store.ErrTelegramTaken
+		}
+	}
+
+	stored.IDTelegram = u.IDTelegram
+	return r.UpdateTelegramProfile(u)
+}
+
+// UnlinkTelegram detaches the Telegram account from the user.
+// It returns store.ErrLastLoginMethod if the user has no email or external account to log in with.
+func (r *UserRepository) UnlinkTelegram(id int) error {
+	u, ok := r.users[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	r.store.ExternalIdentity()
+	if !u.Email.Valid && !r.store.externalIdentityRepository.hasUser(id) {
+		return store.ErrLastLoginMethod
+	}
+
+	u.IDTelegram = sql.NullInt64{}
+	u.TelegramFirstName = sql.NullString{}
+	u.TelegramLastName = sql.NullString{}
+	u.TelegramUsername = sql.NullString{}
+	u.TelegramPhotoURL = sql.NullString{}
+	u.TelegramLanguageCode = sql.NullString{}
+
+	return nil
+}
+
+// LinkEmail adds the email and the password to the user (it validates before adding).
+// It returns store.ErrEmailTaken if the email belongs to another user.
+func (r *UserRepository) LinkEmail(u *model.User) error {
+	stored, ok := r.users[u.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if err := u.ValidateEmailLogin(); err != nil {
+		return err
+	}
+
+	for _, other := range r.users {
+		if other.ID != u.ID && other.Email.Valid && other.Email.String == u.Email.String {
+			return store.ErrEmailTaken
+		}
+	}
+
+	if err := u.BeforeCreate(); err != nil {
+		return err
+	}
+
+	stored.Email = u.Email
+	stored.EncryptedPassword = u.EncryptedPassword
+	stored.EmailVerifiedAt = sql.NullTime{}
+	stored.PendingEmail = sql.NullString{}
+
+	return nil
+}
+
+// UnlinkEmail removes the email and the password from the user.
+// It returns store.ErrLastLoginMethod if the user has no Telegram or external account to log in with.
+func (r *UserRepository) UnlinkEmail(id int) error {
+	u, ok := r.users[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	r.store.ExternalIdentity()
+	if !u.IDTelegram.Valid && !r.store.externalIdentityRepository.hasUser(id) {
+		return store.ErrLastLoginMethod
+	}
+
+	u.Email = sql.NullString{}
+	u.EncryptedPassword = sql.NullString{}
+	u.EmailVerifiedAt = sql.NullTime{}
+	u.PendingEmail = sql.NullString{}
+
+	return nil
+}
+
+// VerifyEmail marks the email of the user as confirmed.
+// It returns store.ErrRecordNotFound if the user no longer has this email.
+func (r *UserRepository) VerifyEmail(id int, email string) error {
+	u, ok := r.users[id]
+	if !ok || !u.Email.Valid || u.Email.String != email {
+		return store.ErrRecordNotFound
+	}
+
+	u.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	return nil
+}
+
+// SetPendingEmail stores the new email that replaces the current one once the user confirms it (it validates before saving).
+func (r *UserRepository) SetPendingEmail(u *model.User) error {
+	stored, ok := r.users[u.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if err := u.ValidatePendingEmail(); err != nil {
+		return err
+	}
+
+	stored.PendingEmail = u.PendingEmail
+
+	return nil
+}
+
+// ConfirmEmail replaces the email of the user with the pending one and marks it as verified.
+// It returns store.ErrRecordNotFound if the pending email of the user is no longer the given one
+// and store.ErrEmailTaken if the email was taken by another user in the meantime.
+func (r *UserRepository) ConfirmEmail(id int, email string) error {
+	u, ok := r.users[id]
+	if !ok || !u.PendingEmail.Valid || u.PendingEmail.String != email {
+		return store.ErrRecordNotFound
+	}
+
+	for _, other := range r.users {
+		if other.ID != id && other.Email.Valid && other.Email.String == email {
+			return store.ErrEmailTaken
+		}
+	}
+
+	u.Email = u.PendingEmail
+	u.PendingEmail = sql.NullString{}
+	u.EmailVerifiedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	return nil
+}
+
+// UpdatePassword sets the new password of the user (it validates before saving).
+func (r *UserRepository) UpdatePassword(u *model.User) error {
+	stored, ok := r.users[u.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if err := u.ValidatePassword(); err != nil {
+		return err
+	}
+
+	if err := u.BeforeCreate(); err != nil {
+		return err
+	}
+
+	stored.EncryptedPassword = u.EncryptedPassword
+
+	return nil
+}
+
+// ResetPassword sets the new password of the user and marks the password reset as used (it validates before saving).
+// It returns store.ErrRecordNotFound if the reset was already used, then the password is left as it is.
+func (r *UserRepository) ResetPassword(u *model.User, resetID int) error {
+	stored, ok := r.users[u.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if err := u.ValidatePassword(); err != nil {
+		return err
+	}
+
+	c := *u
+	if err := c.BeforeCreate(); err != nil {
+		return err
+	}
+
+	if err := r.store.PasswordReset().Use(resetID); err != nil {
+		return err
+	}
+
+	u.EncryptedPassword = c.EncryptedPassword
+	stored.EncryptedPassword = c.EncryptedPassword
+
+	return nil
+}
+
+// ReplaceEncryptedPassword sets the new hash of the password if the user still has the old one.
+// It returns store.ErrRecordNotFound if the password was changed in the meantime.
+func (r *UserRepository) ReplaceEncryptedPassword(id int, old string, new string) error {
+	u, ok := r.users[id]
+	if !ok || u.EncryptedPassword.String != old {
+		return store.ErrRecordNotFound
+	}
+
+	u.EncryptedPassword.String = new
+
+	return nil
+}
+
+// SetTOTPSecret stores the secret of the TOTP second factor that is not enabled until EnableTOTP.
+func (r *UserRepository) SetTOTPSecret(id int, secret string) error {
+	u, ok := r.users[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	u.TOTPSecret = sql.NullString{String: secret, Valid: true}
+	u.TOTPEnabledAt = sql.NullTime{}
+
+	return nil
+}
+
+// EnableTOTP enables the TOTP second factor with the stored secret.
+// It returns store.ErrRecordNotFound if the user has no TOTP secret.
+func (r *UserRepository) EnableTOTP(id int) error {
+	u, ok := r.users[id]
+	if !ok || !u.TOTPSecret.Valid {
+		return store.ErrRecordNotFound
+	}
+
+	u.TOTPEnabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	return nil
+}
+
+// DisableTOTP disables the TOTP second factor and removes its secret.
+func (r *UserRepository) DisableTOTP(id int) error {
+	u, ok := r.users[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	u.TOTPSecret = sql.NullString{}
+	u.TOTPEnabledAt = sql.NullTime{}
+
+	return nil
+}
+
+// UseTOTPStep records that a TOTP code of the time step was accepted for the user.
+// It returns store.ErrRecordNotFound if a code of this or a later step was accepted before.
+func (r *UserRepository) UseTOTPStep(id int, step int64) error {
+	u, ok := r.users[id]
+	if !ok || (u.TOTPLastStep.Valid && u.TOTPLastStep.Int64 >= step) {
+		return store.ErrRecordNotFound
+	}
+
+	u.TOTPLastStep = sql.NullInt64{Int64: step, Valid: true}
+
+	return nil
+}
+
+// SetSessionsValidAfter makes the sessions of the user that started before the time invalid.
+func (r *UserRepository) SetSessionsValidAfter(id int, t time.Time) error {
+	u, ok := r.users[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	u.SessionsValidAfter = sql.NullTime{Time: t, Valid: true}
+
+	return nil
+}
+
+// List finds the users selected by the filter in the order and the page it asks for.
+// It also returns the number of all selected users regardless of the page.
+func (r *UserRepository) List(f *store.UserFilter) ([]*model.User, int, error) {
+	users := []*model.User{}
+	for _, u := range r.users {
+		if f.Email != "" && !strings.Contains(strings.ToLower(u.Email.String), strings.ToLower(f.Email)) {
+			continue
+		}
+
+		if f.Telegram != nil && u.IDTelegram.Valid != *f.Telegram {
+			continue
+		}
+
+		if !f.CreatedAfter.IsZero() && u.CreatedAt.Before(f.CreatedAfter) {
+			continue
+		}
+
+		if !f.CreatedBefore.IsZero() && !u.CreatedAt.Before(f.CreatedBefore) {
+			continue
+		}
+
+		if !f.DeletionScheduledBefore.IsZero() && (!u.DeletionScheduledAt.Valid || !u.DeletionScheduledAt.Time.Before(f.DeletionScheduledBefore)) {
+			continue
+		}
+
+		if f.Status == store.UserStatusActive && u.IsDisabled() || f.Status == store.UserStatusDisabled && !u.IsDisabled() {
+			continue
+		}
+
+		users = append(users, u)
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		a, b := users[i], users[j]
+		switch {
+		case f.Sort == store.UserSortEmail && a.Email != b.Email:
+			if a.Email.Valid != b.Email.Valid {
+				return a.Email.Valid
+			}
+
+			return a.Email.String < b.Email.String != f.Desc
+		case f.Sort == store.UserSortCreatedAt && !a.CreatedAt.Equal(b.CreatedAt):
+			return a.CreatedAt.Before(b.CreatedAt) != f.Desc
+		default:
+			return a.ID < b.ID != f.Desc
+		}
+	})
+
+	total := len(users)
+	if f.Offset >= total {
+		return []*model.User{}, total, nil
+	}
+
+	users = users[f.Offset:]
+	if f.Limit > 0 && f.Limit < len(users) {
+		users = users[:f.Limit]
+	}
+
+	return users, total, nil
+}
+
+// Update saves the email, the Telegram ID, the email verification, the pending email, the disabled time
+// and the scheduled deletion of the user (it validates before saving).
+// It returns store.ErrEmailTaken or store.ErrTelegramTaken if the email or the Telegram account belongs to another user.
+func (r *UserRepository) Update(u *model.User) error {
+	if err := u.ValidateUpdate(); err != nil {
+		return err
+	}
+
+	stored, ok := r.users[u.ID]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	for _, other := range r.users {
+		if other.ID == u.ID {
+			continue
+		}
+
+		if u.Email.Valid && other.Email.Valid && other.Email.String == u.Email.String {
+			return store.ErrEmailTaken
+		}
+
+		if u.IDTelegram.Valid && other.IDTelegram.Valid && other.IDTelegram.Int64 == u.IDTelegram.Int64 {
+			return store.ErrTelegramTaken
+		}
+	}
+
+	stored.IDTelegram = u.IDTelegram
+	stored.Email = u.Email
+	stored.EmailVerifiedAt = u.EmailVerifiedAt
+	stored.PendingEmail = u.PendingEmail
+	stored.DisabledAt = u.DisabledAt
+	stored.DeletionScheduledAt = u.DeletionScheduledAt
+
+	return nil
+}
+
+// Delete removes the user from map with the audit events the user made.
+func (r *UserRepository) Delete(id int) error {
+	if _, ok := r.users[id]; !ok {
+		return store.ErrRecordNotFound
+	}
+
+	delete(r.users, id)
+
+	r.store.AuditEvent()
+	r.store.auditEventRepository.deleteByUser(id)
+
+	return nil
+}
+
+// Anonymize removes everything that identifies the user, all credentials of the user
+// and the audit events the user made, only the disabled record of the user is kept.
+func (r *UserRepository) Anonymize(id int) error {
+	u, ok := r.users[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	disabledAt := u.DisabledAt
+	if !disabledAt.Valid {
+		disabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	*u = model.User{
+		ID:         u.ID,
+		CreatedAt:  u.CreatedAt,
+		DisabledAt: disabledAt,
+	}
+
+	if err := r.store.Session().DeleteByUser(id, 0); err != nil {
+		return err
+	}
+
+	if err := r.store.RefreshToken().RevokeByUser(id); err != nil {
+		return err
+	}
+
+	if err := r.store.RecoveryCode().DeleteByUser(id); err != nil {
+		return err
+	}
+
+	keys, err := r.store.APIKey().FindByUser(id)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		if err := r.store.APIKey().Delete(k.ID); err != nil {
+			return err
+		}
+	}
+
+	r.store.Role()
+	delete(r.store.roleRepository.userRoles, id)
+
+	r.store.OAuthCode()
+	for codeID, c := range r.store.oauthCodeRepository.codes {
+		if c.UserID == id {
+			delete(r.store.oauthCodeRepository.codes, codeID)
+		}
+	}
+
+	r.store.OAuthGrant()
+	for grantID, g := range r.store.oauthGrantRepository.grants {
+		if g.UserID == id {
+			delete(r.store.oauthGrantRepository.grants, grantID)
+		}
+	}
+
+	r.store.ExternalIdentity()
+	for identityID, i := range r.store.externalIdentityRepository.identities {
+		if i.UserID == id {
+			delete(r.store.externalIdentityRepository.identities, identityID)
+		}
+	}
+
+	r.store.AuditEvent()
+	r.store.auditEventRepository.deleteByUser(id)
+
+	return nil
+}
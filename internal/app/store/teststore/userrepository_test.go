@@ -3,6 +3,7 @@ package teststore_test
 import (
 	"database/sql"
 	"testing"
+	"time"
 
 	"github.com/http-rest-API/internal/app/model"
 	"github.com/http-rest-API/internal/app/store"
@@ -56,3 +57,440 @@ func TestUserRepository_FindByIDTelegram(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotNil(t, u)
 }
+
+func TestUserRepository_CreateWithExternalIdentity(t *testing.T) {
+	s := teststore.New()
+	_, err := s.User().FindByExternalIdentity("google", "110169484474386276334")
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	u := &model.User{Email: sql.NullString{String: "invalid", Valid: true}}
+	assert.Error(t, s.User().CreateWithExternalIdentity(u, model.TestExternalIdentity(t, 0)))
+
+	u = &model.User{}
+	i := model.TestExternalIdentity(t, 0)
+	assert.NoError(t, s.User().CreateWithExternalIdentity(u, i))
+	assert.NotZero(t, u.ID)
+	assert.Equal(t, u.ID, i.UserID)
+
+	found, err := s.User().FindByExternalIdentity(i.Provider, i.Subject)
+	assert.NoError(t, err)
+	assert.Equal(t, u.ID, found.ID)
+	assert.False(t, found.Email.Valid)
+
+	assert.EqualError(t, s.User().CreateWithExternalIdentity(&model.User{}, model.TestExternalIdentity(t, 0)), store.ErrExternalTaken.Error())
+
+	other := model.TestUser(t)
+	s.User().Create(other)
+	i = model.TestExternalIdentity(t, 0)
+	i.Subject = "another"
+	assert.EqualError(t, s.User().CreateWithExternalIdentity(&model.User{Email: other.Email}, i), store.ErrEmailTaken.Error())
+
+	_, err = s.User().FindByExternalIdentity(i.Provider, i.Subject)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	u.IDTelegram = sql.NullInt64{Int64: 12345678, Valid: true}
+	assert.NoError(t, s.User().LinkTelegram(u))
+	assert.NoError(t, s.User().UnlinkTelegram(u.ID))
+}
+
+func TestUserRepository_UpdateTelegramProfile(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUserWithTelegram(t)
+	assert.EqualError(t, s.User().UpdateTelegramProfile(u), store.ErrRecordNotFound.Error())
+
+	s.User().Create(u)
+	u.TelegramUsername = sql.NullString{String: "durov", Valid: true}
+	u.TelegramLanguageCode = sql.NullString{String: "en", Valid: true}
+	assert.NoError(t, s.User().UpdateTelegramProfile(u))
+
+	u, err := s.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "durov", u.TelegramUsername.String)
+	assert.Equal(t, "en", u.TelegramLanguageCode.String)
+}
+
+func TestUserRepository_LinkTelegram(t *testing.T) {
+	s := teststore.New()
+	u1 := model.TestUser(t)
+	s.User().Create(u1)
+	u2 := model.TestUserWithTelegram(t)
+	s.User().Create(u2)
+
+	u1.IDTelegram = u2.IDTelegram
+	assert.EqualError(t, s.User().LinkTelegram(u1), store.ErrTelegramTaken.Error())
+
+	u1.IDTelegram = sql.NullInt64{Int64: 87654321, Valid: true}
+	assert.NoError(t, s.User().LinkTelegram(u1))
+
+	u, err := s.User().FindByIDTelegram(87654321)
+	assert.NoError(t, err)
+	assert.Equal(t, u1.ID, u.ID)
+}
+
+func TestUserRepository_UnlinkTelegram(t *testing.T) {
+	s := teststore.New()
+	assert.EqualError(t, s.User().UnlinkTelegram(1), store.ErrRecordNotFound.Error())
+
+	u := model.TestUserWithTelegram(t)
+	s.User().Create(u)
+	assert.EqualError(t, s.User().UnlinkTelegram(u.ID), store.ErrLastLoginMethod.Error())
+
+	u.Email = sql.NullString{String: "user@example.org", Valid: true}
+	assert.NoError(t, s.User().LinkEmail(u))
+	assert.NoError(t, s.User().UnlinkTelegram(u.ID))
+
+	_, err := s.User().FindByIDTelegram(12345678)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
+
+func TestUserRepository_LinkEmail(t *testing.T) {
+	s := teststore.New()
+	u1 := model.TestUser(t)
+	s.User().Create(u1)
+	u2 := model.TestUserWithTelegram(t)
+	s.User().Create(u2)
+
+	u2.Email = sql.NullString{String: "invalid", Valid: true}
+	assert.Error(t, s.User().LinkEmail(u2))
+
+	u2.Email = u1.Email
+	assert.EqualError(t, s.User().LinkEmail(u2), store.ErrEmailTaken.Error())
+
+	u2.Email = sql.NullString{String: "telegram@example.org", Valid: true}
+	assert.NoError(t, s.User().LinkEmail(u2))
+
+	u, err := s.User().FindByEmail("telegram@example.org")
+	assert.NoError(t, err)
+	assert.True(t, u.ComparePassword("password"))
+}
+
+func TestUserRepository_UnlinkEmail(t *testing.T) {
+	s := teststore.New()
+	assert.EqualError(t, s.User().UnlinkEmail(1), store.ErrRecordNotFound.Error())
+
+	u := model.TestUser(t)
+	email := u.Email.String
+	s.User().Create(u)
+	assert.EqualError(t, s.User().UnlinkEmail(u.ID), store.ErrLastLoginMethod.Error())
+
+	u.IDTelegram = sql.NullInt64{Int64: 12345678, Valid: true}
+	assert.NoError(t, s.User().LinkTelegram(u))
+	assert.NoError(t, s.User().UnlinkEmail(u.ID))
+
+	_, err := s.User().FindByEmail(email)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+}
+
+func TestUserRepository_UpdatePassword(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	u.Password = ""
+	assert.Error(t, s.User().UpdatePassword(u))
+
+	u.Password = "NewPassword1"
+	assert.NoError(t, s.User().UpdatePassword(u))
+
+	u, err := s.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.True(t, u.ComparePassword("NewPassword1"))
+	assert.False(t, u.ComparePassword("password"))
+}
+
+func TestUserRepository_ResetPassword(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	pr := model.TestPasswordReset(t, u.ID)
+	s.PasswordReset().Create(pr)
+
+	u.Password = ""
+	assert.Error(t, s.User().ResetPassword(u, pr.ID))
+
+	u.Password = "NewPassword1"
+	assert.NoError(t, s.User().ResetPassword(u, pr.ID))
+
+	u.Password = "NewPassword2"
+	assert.EqualError(t, s.User().ResetPassword(u, pr.ID), store.ErrRecordNotFound.Error())
+
+	u, err := s.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.True(t, u.ComparePassword("NewPassword1"))
+
+	pr, err = s.PasswordReset().FindByTokenHash(pr.TokenHash)
+	assert.NoError(t, err)
+	assert.True(t, pr.IsUsed())
+}
+
+func TestUserRepository_VerifyEmail(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	email := u.Email.String
+	s.User().Create(u)
+
+	assert.EqualError(t, s.User().VerifyEmail(u.ID, "other@example.org"), store.ErrRecordNotFound.Error())
+	assert.NoError(t, s.User().VerifyEmail(u.ID, email))
+
+	u, err := s.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.False(t, u.NeedsEmailVerification())
+}
+
+func TestUserRepository_CreateTaken(t *testing.T) {
+	s := teststore.New()
+	s.User().Create(model.TestUser(t))
+	s.User().Create(model.TestUserWithTelegram(t))
+
+	assert.EqualError(t, s.User().Create(model.TestUser(t)), store.ErrEmailTaken.Error())
+	assert.EqualError(t, s.User().Create(model.TestUserWithTelegram(t)), store.ErrTelegramTaken.Error())
+}
+
+func TestUserRepository_ConfirmEmail(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	other := model.TestUser(t)
+	other.Email.String = "other@example.org"
+	s.User().Create(other)
+
+	u.PendingEmail = sql.NullString{String: "invalid", Valid: true}
+	assert.Error(t, s.User().SetPendingEmail(u))
+
+	u.PendingEmail = sql.NullString{String: "new@example.org", Valid: true}
+	assert.NoError(t, s.User().SetPendingEmail(u))
+	assert.EqualError(t, s.User().ConfirmEmail(u.ID, "wrong@example.org"), store.ErrRecordNotFound.Error())
+	assert.NoError(t, s.User().ConfirmEmail(u.ID, "new@example.org"))
+
+	u, err := s.User().FindByEmail("new@example.org")
+	assert.NoError(t, err)
+	assert.False(t, u.PendingEmail.Valid)
+	assert.False(t, u.NeedsEmailVerification())
+
+	u.PendingEmail = other.Email
+	assert.NoError(t, s.User().SetPendingEmail(u))
+	assert.EqualError(t, s.User().ConfirmEmail(u.ID, other.Email.String), store.ErrEmailTaken.Error())
+}
+
+func TestUserRepository_TOTP(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	assert.EqualError(t, s.User().EnableTOTP(u.ID), store.ErrRecordNotFound.Error())
+	assert.NoError(t, s.User().SetTOTPSecret(u.ID, "secret"))
+
+	u, err := s.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", u.TOTPSecret.String)
+	assert.False(t, u.HasTwoFactor())
+
+	assert.NoError(t, s.User().EnableTOTP(u.ID))
+	u, err = s.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.True(t, u.HasTwoFactor())
+
+	assert.NoError(t, s.User().DisableTOTP(u.ID))
+	u, err = s.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.False(t, u.TOTPSecret.Valid)
+	assert.False(t, u.HasTwoFactor())
+}
+
+func TestUserRepository_UseTOTPStep(t *testing.T) {
+	s := teststore.New()
+	assert.EqualError(t, s.User().UseTOTPStep(1, 100), store.ErrRecordNotFound.Error())
+
+	u := model.TestUser(t)
+	s.User().Create(u)
+	assert.NoError(t, s.User().UseTOTPStep(u.ID, 100))
+	assert.EqualError(t, s.User().UseTOTPStep(u.ID, 100), store.ErrRecordNotFound.Error())
+	assert.EqualError(t, s.User().UseTOTPStep(u.ID, 99), store.ErrRecordNotFound.Error())
+	assert.NoError(t, s.User().UseTOTPStep(u.ID, 101))
+
+	u, err := s.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(101), u.TOTPLastStep.Int64)
+}
+
+func TestUserRepository_SetSessionsValidAfter(t *testing.T) {
+	s := teststore.New()
+	now := time.Now().Truncate(time.Microsecond)
+	assert.EqualError(t, s.User().SetSessionsValidAfter(1, now), store.ErrRecordNotFound.Error())
+
+	u := model.TestUser(t)
+	s.User().Create(u)
+	assert.NoError(t, s.User().SetSessionsValidAfter(u.ID, now))
+
+	u, err := s.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.True(t, u.SessionsValidAfter.Valid)
+	assert.True(t, now.Equal(u.SessionsValidAfter.Time))
+}
+
+func TestUserRepository_ReplaceEncryptedPassword(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	old := u.EncryptedPassword.String
+
+	assert.EqualError(t, s.User().ReplaceEncryptedPassword(u.ID, "other", "new"), store.ErrRecordNotFound.Error())
+	assert.NoError(t, s.User().ReplaceEncryptedPassword(u.ID, old, "new"))
+	assert.EqualError(t, s.User().ReplaceEncryptedPassword(u.ID, old, "newer"), store.ErrRecordNotFound.Error())
+
+	u, err := s.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", u.EncryptedPassword.String)
+}
+
+func TestUserRepository_List(t *testing.T) {
+	s := teststore.New()
+	start := time.Now().Add(-time.Minute)
+	u1 := model.TestUser(t)
+	u1.Email.String = "bob@example.org"
+	s.User().Create(u1)
+	u2 := model.TestUser(t)
+	u2.Email.String = "Alice@example.org"
+	s.User().Create(u2)
+	u3 := model.TestUserWithTelegram(t)
+	s.User().Create(u3)
+	u2.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.User().Update(u2)
+
+	ids := func(users []*model.User) []int {
+		res := []int{}
+		for _, u := range users {
+			res = append(res, u.ID)
+		}
+
+		return res
+	}
+
+	users, total, err := s.User().List(&store.UserFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []int{u1.ID, u2.ID, u3.ID}, ids(users))
+
+	users, total, err = s.User().List(&store.UserFilter{Sort: store.UserSortEmail, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []int{u2.ID, u1.ID}, ids(users))
+
+	users, _, err = s.User().List(&store.UserFilter{Desc: true, Limit: 2, Offset: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{u2.ID, u1.ID}, ids(users))
+
+	users, total, err = s.User().List(&store.UserFilter{Email: "ALICE"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []int{u2.ID}, ids(users))
+
+	telegram := true
+	users, _, err = s.User().List(&store.UserFilter{Telegram: &telegram})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{u3.ID}, ids(users))
+
+	users, _, err = s.User().List(&store.UserFilter{Status: store.UserStatusDisabled})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{u2.ID}, ids(users))
+
+	users, _, err = s.User().List(&store.UserFilter{Status: store.UserStatusActive, CreatedAfter: start})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{u1.ID, u3.ID}, ids(users))
+
+	users, total, err = s.User().List(&store.UserFilter{CreatedBefore: start})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, total)
+	assert.Empty(t, users)
+}
+
+func TestUserRepository_Update(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	other := model.TestUserWithTelegram(t)
+	s.User().Create(other)
+
+	u.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	assert.NoError(t, s.User().Update(u))
+
+	u, err := s.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.True(t, u.IsDisabled())
+
+	u.IDTelegram = other.IDTelegram
+	assert.EqualError(t, s.User().Update(u), store.ErrTelegramTaken.Error())
+
+	u.ID = other.ID + 1
+	u.IDTelegram = sql.NullInt64{}
+	assert.EqualError(t, s.User().Update(u), store.ErrRecordNotFound.Error())
+}
+
+func TestUserRepository_UpdateExternalOnly(t *testing.T) {
+	s := teststore.New()
+	u := &model.User{}
+	s.User().CreateWithExternalIdentity(u, model.TestExternalIdentity(t, 0))
+
+	u.DisabledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	assert.NoError(t, s.User().Update(u))
+
+	u.Email = sql.NullString{String: "invalid", Valid: true}
+	assert.Error(t, s.User().Update(u))
+}
+
+func TestUserRepository_Delete(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+
+	assert.NoError(t, s.User().Delete(u.ID))
+	assert.EqualError(t, s.User().Delete(u.ID), store.ErrRecordNotFound.Error())
+
+	_, err := s.User().Find(u.ID)
+	assert.EqualError(t, err, store.ErrRecordNotFound.Error())
+
+	other := model.TestUser(t)
+	assert.NoError(t, s.User().Create(other))
+	assert.NotEqual(t, u.ID, other.ID)
+}
+
+func TestUserRepository_Anonymize(t *testing.T) {
+	s := teststore.New()
+	u := model.TestUser(t)
+	s.User().Create(u)
+	s.Session().Create(model.TestSession(t, u.ID))
+	s.Role().Assign(u.ID, model.RoleAdmin)
+	s.OAuthGrant().Save(model.TestOAuthGrant(t, "client", u.ID))
+	u.DeletionScheduledAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+	s.User().Update(u)
+
+	users, _, err := s.User().List(&store.UserFilter{DeletionScheduledBefore: time.Now()})
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	assert.NoError(t, s.User().Anonymize(u.ID))
+	assert.EqualError(t, s.User().Anonymize(u.ID+1), store.ErrRecordNotFound.Error())
+
+	u, err = s.User().Find(u.ID)
+	assert.NoError(t, err)
+	assert.False(t, u.Email.Valid)
+	assert.False(t, u.EncryptedPassword.Valid)
+	assert.False(t, u.DeletionScheduledAt.Valid)
+	assert.True(t, u.IsDisabled())
+
+	sessions, err := s.Session().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	roles, err := s.Role().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{model.RoleUser}, model.RoleNames(roles))
+
+	grants, err := s.OAuthGrant().FindByUser(u.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, grants)
+
+	users, _, err = s.User().List(&store.UserFilter{DeletionScheduledBefore: time.Now()})
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+}
@@ -0,0 +1,39 @@
+package store
+
+import "time"
+
+// The statuses of users that UserFilter can select.
+const (
+	UserStatusActive   = "active"
+	UserStatusDisabled = "disabled"
+)
+
+// The fields that UserFilter can sort users by.
+const (
+	UserSortID        = "id"
+	UserSortEmail     = "email"
+	UserSortCreatedAt = "created_at"
+)
+
+// UserFilter selects, sorts and pages the users returned by UserRepository.List.
+// It includes the following fields:
+// - Email: a part of the email the users must have, the case is ignored, empty means any email.
+// - Telegram: whether the users must or must not have a linked Telegram account, nil means either.
+// - CreatedAfter, CreatedBefore: the range the registration time of the users must be in, zero means no bound.
+// - DeletionScheduledBefore: the time before which the deletion of the users must be scheduled, zero means any users.
+// - Status: UserStatusActive or UserStatusDisabled, empty means any status.
+// - Sort: the field the users are sorted by, UserSortID if empty.
+// - Desc: whether the users are sorted in descending order.
+// - Limit, Offset: the page of the sorted users, zero Limit means all users.
+type UserFilter struct {
+	Email                   string
+	Telegram                *bool
+	CreatedAfter            time.Time
+	CreatedBefore           time.Time
+	DeletionScheduledBefore time.Time
+	Status                  string
+	Sort                    string
+	Desc                    bool
+	Limit                   int
+	Offset                  int
+}
@@ -0,0 +1,111 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoBotToken  = errors.New("telegram bot token is not configured")
+	ErrInvalidHash = errors.New("telegram data has an invalid hash")
+	ErrExpired     = errors.New("telegram data is expired")
+)
+
+// LoginData is the payload that the Telegram Login Widget passes to the site.
+// See https://core.telegram.org/widgets/login#checking-authorization.
+type LoginData struct {
+	ID        int64  `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Username  string `json:"username"`
+	PhotoURL  string `json:"photo_url"`
+	AuthDate  int64  `json:"auth_date"`
+	Hash      string `json:"hash"`
+}
+
+// Verify checks that the data was signed by Telegram for the bot with the given token
+// and that it is not older than maxAge. A maxAge of 0 disables the age check.
+func (d *LoginData) Verify(botToken string, maxAge time.Duration, now time.Time) error {
+	if botToken == "" {
+		return ErrNoBotToken
+	}
+
+	if !hmac.Equal([]byte(d.sign(botToken)), []byte(strings.ToLower(d.Hash))) {
+		return ErrInvalidHash
+	}
+
+	return checkAuthDate(d.AuthDate, maxAge, now)
+}
+
+// sign returns the hash of the data as Telegram computes it:
+// HMAC-SHA256 of the data-check-string with the SHA256 of the bot token as the key.
+func (d *LoginData) sign(botToken string) string {
+	secret := sha256.Sum256([]byte(botToken))
+	return hmacHex(secret[:], d.checkString())
+}
+
+// User returns the Telegram account described by the data.
+func (d *LoginData) User() *User {
+	return &User{
+		ID:        d.ID,
+		FirstName: d.FirstName,
+		LastName:  d.LastName,
+		Username:  d.Username,
+		PhotoURL:  d.PhotoURL,
+	}
+}
+
+// checkString returns the data-check-string: all present fields except hash
+// sorted alphabetically in the key=value form and joined with line breaks.
+func (d *LoginData) checkString() string {
+	fields := map[string]string{
+		"id":         strconv.FormatInt(d.ID, 10),
+		"first_name": d.FirstName,
+		"last_name":  d.LastName,
+		"username":   d.Username,
+		"photo_url":  d.PhotoURL,
+		"auth_date":  strconv.FormatInt(d.AuthDate, 10),
+	}
+
+	return joinFields(fields)
+}
+
+// joinFields builds a data-check-string from the non-empty fields.
+func joinFields(fields map[string]string) string {
+	pairs := make([]string, 0, len(fields))
+	for k, v := range fields {
+		if v != "" {
+			pairs = append(pairs, k+"="+v)
+		}
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, "\n")
+}
+
+// hmacHex returns the hex encoded HMAC-SHA256 of the message.
+func hmacHex(key []byte, message string) string {
+	return hex.EncodeToString(hmacRaw(key, message))
+}
+
+// hmacRaw returns the HMAC-SHA256 of the message.
+func hmacRaw(key []byte, message string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// checkAuthDate checks that the unix time authDate is not older than maxAge.
+func checkAuthDate(authDate int64, maxAge time.Duration, now time.Time) error {
+	if maxAge > 0 && now.Sub(time.Unix(authDate, 0)) > maxAge {
+		return ErrExpired
+	}
+
+	return nil
+}
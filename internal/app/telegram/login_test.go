@@ -0,0 +1,82 @@
+package telegram_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/telegram"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginData_Verify(t *testing.T) {
+	const botToken = "123456:test-bot-token"
+	now := time.Now()
+
+	testCases := []struct {
+		name     string
+		d        func() *telegram.LoginData
+		botToken string
+		err      error
+	}{
+		{
+			name: "valid",
+			d: func() *telegram.LoginData {
+				return telegram.TestLoginData(t, botToken, 12345678, now)
+			},
+			botToken: botToken,
+			err:      nil,
+		},
+		{
+			name: "tampered id",
+			d: func() *telegram.LoginData {
+				d := telegram.TestLoginData(t, botToken, 12345678, now)
+				d.ID = 87654321
+
+				return d
+			},
+			botToken: botToken,
+			err:      telegram.ErrInvalidHash,
+		},
+		{
+			name: "tampered username",
+			d: func() *telegram.LoginData {
+				d := telegram.TestLoginData(t, botToken, 12345678, now)
+				d.Username = "admin"
+
+				return d
+			},
+			botToken: botToken,
+			err:      telegram.ErrInvalidHash,
+		},
+		{
+			name: "other bot",
+			d: func() *telegram.LoginData {
+				return telegram.TestLoginData(t, "654321:other-bot-token", 12345678, now)
+			},
+			botToken: botToken,
+			err:      telegram.ErrInvalidHash,
+		},
+		{
+			name: "expired",
+			d: func() *telegram.LoginData {
+				return telegram.TestLoginData(t, botToken, 12345678, now.Add(-2*time.Hour))
+			},
+			botToken: botToken,
+			err:      telegram.ErrExpired,
+		},
+		{
+			name: "no bot token",
+			d: func() *telegram.LoginData {
+				return telegram.TestLoginData(t, botToken, 12345678, now)
+			},
+			botToken: "",
+			err:      telegram.ErrNoBotToken,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.err, tc.d().Verify(tc.botToken, time.Hour, now))
+		})
+	}
+}
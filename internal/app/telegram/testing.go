@@ -0,0 +1,52 @@
+package telegram
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLoginData returns login data signed with the given bot token for testing.
+func TestLoginData(t *testing.T, botToken string, id int64, authDate time.Time) *LoginData {
+	d := &LoginData{
+		ID:        id,
+		FirstName: "John",
+		Username:  "john",
+		PhotoURL:  "https://t.me/i/userpic/320/john.jpg",
+		AuthDate:  authDate.Unix(),
+	}
+	d.Hash = d.sign(botToken)
+
+	return d
+}
+
+// TestWebAppInitData returns raw Mini App init data signed with the given bot token for testing.
+func TestWebAppInitData(t *testing.T, botToken string, id int64, authDate time.Time) string {
+	user, err := json.Marshal(&User{
+		ID:           id,
+		FirstName:    "John",
+		Username:     "john",
+		LanguageCode: "en",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := url.Values{}
+	values.Set("query_id", "AAHdF6IQAAAAAN0XohDhrOrc")
+	values.Set("user", string(user))
+	values.Set("auth_date", strconv.FormatInt(authDate.Unix(), 10))
+
+	pairs := make([]string, 0, len(values))
+	for k := range values {
+		pairs = append(pairs, k+"="+values.Get(k))
+	}
+	sort.Strings(pairs)
+	values.Set("hash", hmacHex(hmacRaw([]byte("WebAppData"), botToken), strings.Join(pairs, "\n")))
+
+	return values.Encode()
+}
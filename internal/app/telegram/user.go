@@ -0,0 +1,11 @@
+package telegram
+
+// User is the Telegram account of a user as Telegram passes it to the site or the Mini App.
+type User struct {
+	ID           int64  `json:"id"`
+	FirstName    string `json:"first_name"`
+	LastName     string `json:"last_name"`
+	Username     string `json:"username"`
+	PhotoURL     string `json:"photo_url"`
+	LanguageCode string `json:"language_code"`
+}
@@ -0,0 +1,76 @@
+package telegram
+
+import (
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoUser = errors.New("telegram init data has no user")
+)
+
+// WebAppData is the parsed init data that Telegram passes to a Mini App.
+// See https://core.telegram.org/bots/webapps#validating-data-received-via-the-mini-app.
+type WebAppData struct {
+	QueryID  string
+	User     *User
+	AuthDate int64
+}
+
+// ParseWebAppInitData checks that the raw init data was signed by Telegram for the bot with the given token
+// and that it is not older than maxAge, then parses it. A maxAge of 0 disables the age check.
+func ParseWebAppInitData(initData string, botToken string, maxAge time.Duration, now time.Time) (*WebAppData, error) {
+	if botToken == "" {
+		return nil, ErrNoBotToken
+	}
+
+	values, err := url.ParseQuery(initData)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := values.Get("hash")
+	values.Del("hash")
+
+	pairs := make([]string, 0, len(values))
+	for k := range values {
+		pairs = append(pairs, k+"="+values.Get(k))
+	}
+	sort.Strings(pairs)
+
+	secret := hmacRaw([]byte("WebAppData"), botToken)
+	if hash == "" || !hmac.Equal([]byte(hmacHex(secret, strings.Join(pairs, "\n"))), []byte(strings.ToLower(hash))) {
+		return nil, ErrInvalidHash
+	}
+
+	authDate, err := strconv.ParseInt(values.Get("auth_date"), 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkAuthDate(authDate, maxAge, now); err != nil {
+		return nil, err
+	}
+
+	d := &WebAppData{
+		QueryID:  values.Get("query_id"),
+		AuthDate: authDate,
+	}
+
+	if values.Get("user") == "" {
+		return nil, ErrNoUser
+	}
+
+	d.User = &User{}
+	if err := json.Unmarshal([]byte(values.Get("user")), d.User); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
@@ -0,0 +1,74 @@
+package telegram_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/http-rest-API/internal/app/telegram"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWebAppInitData(t *testing.T) {
+	const botToken = "123456:test-bot-token"
+	now := time.Now()
+
+	testCases := []struct {
+		name     string
+		initData func() string
+		err      error
+	}{
+		{
+			name: "valid",
+			initData: func() string {
+				return telegram.TestWebAppInitData(t, botToken, 12345678, now)
+			},
+			err: nil,
+		},
+		{
+			name: "tampered user",
+			initData: func() string {
+				values, _ := url.ParseQuery(telegram.TestWebAppInitData(t, botToken, 12345678, now))
+				values.Set("user", `{"id":87654321}`)
+
+				return values.Encode()
+			},
+			err: telegram.ErrInvalidHash,
+		},
+		{
+			name: "without hash",
+			initData: func() string {
+				values, _ := url.ParseQuery(telegram.TestWebAppInitData(t, botToken, 12345678, now))
+				values.Del("hash")
+
+				return values.Encode()
+			},
+			err: telegram.ErrInvalidHash,
+		},
+		{
+			name: "other bot",
+			initData: func() string {
+				return telegram.TestWebAppInitData(t, "654321:other-bot-token", 12345678, now)
+			},
+			err: telegram.ErrInvalidHash,
+		},
+		{
+			name: "expired",
+			initData: func() string {
+				return telegram.TestWebAppInitData(t, botToken, 12345678, now.Add(-2*time.Hour))
+			},
+			err: telegram.ErrExpired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := telegram.ParseWebAppInitData(tc.initData(), botToken, time.Hour, now)
+			assert.Equal(t, tc.err, err)
+			if tc.err == nil {
+				assert.Equal(t, int64(12345678), d.User.ID)
+				assert.Equal(t, "john", d.User.Username)
+			}
+		})
+	}
+}
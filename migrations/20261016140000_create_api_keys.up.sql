@@ -0,0 +1,14 @@
+CREATE TABLE api_keys (
+  id BIGSERIAL NOT NULL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  name VARCHAR NOT NULL,
+  prefix VARCHAR NOT NULL UNIQUE,
+  key_hash VARCHAR NOT NULL,
+  scopes VARCHAR[] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMPTZ NOT NULL,
+  expires_at TIMESTAMPTZ,
+  last_used_at TIMESTAMPTZ,
+  last_used_ip VARCHAR NOT NULL DEFAULT ''
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
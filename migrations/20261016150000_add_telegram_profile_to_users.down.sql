@@ -0,0 +1,6 @@
+ALTER TABLE users
+  DROP COLUMN telegram_first_name,
+  DROP COLUMN telegram_last_name,
+  DROP COLUMN telegram_username,
+  DROP COLUMN telegram_photo_url,
+  DROP COLUMN telegram_language_code;
\ No newline at end of file
@@ -0,0 +1,6 @@
+ALTER TABLE users
+  ADD COLUMN telegram_first_name VARCHAR,
+  ADD COLUMN telegram_last_name VARCHAR,
+  ADD COLUMN telegram_username VARCHAR,
+  ADD COLUMN telegram_photo_url VARCHAR,
+  ADD COLUMN telegram_language_code VARCHAR;
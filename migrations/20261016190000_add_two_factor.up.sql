@@ -0,0 +1,14 @@
+ALTER TABLE users
+  ADD COLUMN totp_secret VARCHAR,
+  ADD COLUMN totp_enabled_at TIMESTAMPTZ,
+  ADD COLUMN totp_last_step BIGINT;
+
+CREATE TABLE recovery_codes (
+  id BIGSERIAL NOT NULL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  code_hash VARCHAR NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  used_at TIMESTAMPTZ
+);
+
+CREATE INDEX recovery_codes_user_id_idx ON recovery_codes (user_id);
@@ -0,0 +1,5 @@
+CREATE TABLE login_attempts (
+  key VARCHAR NOT NULL PRIMARY KEY,
+  failures INT NOT NULL,
+  last_failed_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,30 @@
+CREATE TABLE roles (
+  id BIGSERIAL NOT NULL PRIMARY KEY,
+  name VARCHAR NOT NULL UNIQUE
+);
+
+CREATE TABLE permissions (
+  id BIGSERIAL NOT NULL PRIMARY KEY,
+  name VARCHAR NOT NULL UNIQUE
+);
+
+CREATE TABLE role_permissions (
+  role_id BIGINT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+  permission_id BIGINT NOT NULL REFERENCES permissions (id) ON DELETE CASCADE,
+  PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE user_roles (
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  role_id BIGINT NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+  PRIMARY KEY (user_id, role_id)
+);
+
+INSERT INTO roles (name) VALUES ('user'), ('moderator'), ('admin');
+
+INSERT INTO permissions (name) VALUES ('users:read'), ('users:write'), ('roles:write');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE (r.name = 'moderator' AND p.name = 'users:read')
+  OR r.name = 'admin';
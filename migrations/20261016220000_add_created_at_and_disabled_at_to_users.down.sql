@@ -0,0 +1,5 @@
+DROP INDEX users_created_at_idx;
+
+ALTER TABLE users
+  DROP COLUMN created_at,
+  DROP COLUMN disabled_at;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE users
+  ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  ADD COLUMN disabled_at TIMESTAMPTZ;
+
+CREATE INDEX users_created_at_idx ON users (created_at);
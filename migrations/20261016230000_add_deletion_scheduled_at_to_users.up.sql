@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN deletion_scheduled_at TIMESTAMPTZ;
\ No newline at end of file
@@ -0,0 +1,7 @@
+DELETE FROM permissions WHERE name = 'clients:write';
+
+DROP TABLE oauth_grants;
+
+DROP TABLE oauth_codes;
+
+DROP TABLE oauth_clients;
\ No newline at end of file
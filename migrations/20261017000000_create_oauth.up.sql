@@ -0,0 +1,36 @@
+CREATE TABLE oauth_clients (
+  id BIGSERIAL NOT NULL PRIMARY KEY,
+  client_id VARCHAR NOT NULL UNIQUE,
+  secret_hash VARCHAR NOT NULL DEFAULT '',
+  name VARCHAR NOT NULL,
+  redirect_uris VARCHAR[] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE oauth_codes (
+  id BIGSERIAL NOT NULL PRIMARY KEY,
+  code_hash VARCHAR NOT NULL UNIQUE,
+  client_id VARCHAR NOT NULL REFERENCES oauth_clients (client_id) ON DELETE CASCADE,
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  redirect_uri VARCHAR NOT NULL,
+  scopes VARCHAR[] NOT NULL DEFAULT '{}',
+  nonce VARCHAR NOT NULL DEFAULT '',
+  code_challenge VARCHAR NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE oauth_grants (
+  id BIGSERIAL NOT NULL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  client_id VARCHAR NOT NULL REFERENCES oauth_clients (client_id) ON DELETE CASCADE,
+  scopes VARCHAR[] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMPTZ NOT NULL,
+  UNIQUE (user_id, client_id)
+);
+
+INSERT INTO permissions (name) VALUES ('clients:write');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'clients:write';
@@ -0,0 +1 @@
+DROP TABLE external_identities;
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE external_identities (
+  id BIGSERIAL NOT NULL PRIMARY KEY,
+  user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  provider VARCHAR NOT NULL,
+  subject VARCHAR NOT NULL,
+  email VARCHAR NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL,
+  UNIQUE (provider, subject)
+);
+
+CREATE INDEX external_identities_user_id_idx ON external_identities (user_id);
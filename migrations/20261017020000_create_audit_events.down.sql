@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'audit:read';
+
+DROP TABLE audit_events;
\ No newline at end of file
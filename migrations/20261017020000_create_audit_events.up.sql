@@ -0,0 +1,20 @@
+CREATE TABLE audit_events (
+  id BIGSERIAL NOT NULL PRIMARY KEY,
+  type VARCHAR NOT NULL,
+  user_id BIGINT,
+  actor_id BIGINT,
+  ip VARCHAR NOT NULL DEFAULT '',
+  user_agent VARCHAR NOT NULL DEFAULT '',
+  request_id VARCHAR NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX audit_events_user_id_created_at_idx ON audit_events (user_id, created_at);
+
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at);
+
+INSERT INTO permissions (name) VALUES ('audit:read');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'audit:read';
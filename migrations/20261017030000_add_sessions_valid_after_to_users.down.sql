@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN sessions_valid_after;
\ No newline at end of file
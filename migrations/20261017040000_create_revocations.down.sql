@@ -0,0 +1 @@
+DROP TABLE revocations;
\ No newline at end of file
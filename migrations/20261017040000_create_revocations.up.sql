@@ -0,0 +1,6 @@
+CREATE TABLE revocations (
+  id VARCHAR NOT NULL PRIMARY KEY,
+  expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX revocations_expires_at_idx ON revocations (expires_at);